@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 const (
@@ -10,6 +11,34 @@ const (
 	GrafanaOrganizationFinalizer = "observability.giantswarm.io/grafanaorganization"
 )
 
+// Condition types of the GrafanaOrganization status.
+const (
+	// ReadyCondition is true when the organization was fully reconciled in Grafana.
+	ReadyCondition = "Ready"
+	// DatasourcesConfiguredCondition is true when the datasources of the organization are configured in Grafana.
+	DatasourcesConfiguredCondition = "DatasourcesConfigured"
+	// RBACConfiguredCondition is true when the role mapping of the organization is configured in Grafana.
+	RBACConfiguredCondition = "RBACConfigured"
+	// AlertingConfiguredCondition is true when the Alertmanager configuration of the organization tenants is configured.
+	AlertingConfiguredCondition = "AlertingConfigured"
+)
+
+// Condition reasons of the GrafanaOrganization status.
+const (
+	ReconciliationSucceededReason            = "ReconciliationSucceeded"
+	DisplayNameConflictReason                = "DisplayNameConflict"
+	InvalidTenantIDReason                    = "InvalidTenantID"
+	InvalidRBACReason                        = "InvalidRBAC"
+	OrganizationNotManagedReason             = "OrganizationNotManaged"
+	OrganizationConfigurationFailedReason    = "OrganizationConfigurationFailed"
+	DatasourcesConfigurationFailedReason     = "DatasourcesConfigurationFailed"
+	InvalidDatasourcesConfigurationReason    = "InvalidDatasourcesConfiguration"
+	ServiceAccountsConfigurationFailedReason = "ServiceAccountsConfigurationFailed"
+	RBACConfigurationFailedReason            = "RBACConfigurationFailed"
+	AlertingConfigurationFailedReason        = "AlertingConfigurationFailed"
+	InvalidAlertingConfigurationReason       = "InvalidAlertingConfiguration"
+)
+
 // GrafanaOrganizationSpec defines the desired state of GrafanaOrganization
 type GrafanaOrganizationSpec struct {
 	// DisplayName is the name displayed when viewing the organization in Grafana. It can be different from the actual org's name.
@@ -25,8 +54,153 @@ type GrafanaOrganizationSpec struct {
 	// +kubebuilder:example={"giantswarm"}
 	// +kube:validation:MinItems=1
 	Tenants []TenantID `json:"tenants"`
+
+	// FederatedReadTenants is a list of tenants queried together through a single federated Mimir datasource.
+	// It requires tenant federation to be enabled in Mimir.
+	// +kubebuilder:example={"giantswarm","atlas"}
+	// +optional
+	FederatedReadTenants []TenantID `json:"federatedReadTenants,omitempty"`
+
+	// DefaultHomeDashboardUID is the UID of the dashboard the organization opens to.
+	// +optional
+	DefaultHomeDashboardUID string `json:"defaultHomeDashboardUID,omitempty"`
+
+	// DefaultTheme is the default Grafana theme of the organization.
+	// +kubebuilder:validation:Enum=light;dark;system
+	// +optional
+	DefaultTheme string `json:"defaultTheme,omitempty"`
+
+	// DashboardManagementMode controls how the dashboards provisioned by the operator in the organization are managed.
+	// enforce overwrites the changes made in Grafana, adopt only creates the missing dashboards so they can be edited in Grafana, and ignore does not push any dashboard.
+	// +kubebuilder:validation:Enum=enforce;adopt;ignore
+	// +kubebuilder:default=enforce
+	// +optional
+	DashboardManagementMode string `json:"dashboardManagementMode,omitempty"`
+
+	// ExtraDatasources is a list of additional datasource types configured in the organization on top of the default ones.
+	// +kubebuilder:example={"graphite"}
+	// +optional
+	ExtraDatasources []ExtraDatasourceType `json:"extraDatasources,omitempty"`
+
+	// DatasourceJSONDataOverrides are merged on top of the default JSON data of the datasources of the organization, indexed by datasource type.
+	// Only the given fields are overridden, e.g. {"prometheus": {"timeInterval": "30s"}}.
+	// The fields set by the operator, httpHeaderName1, managedBy and secureJsonDataHash, cannot be overridden.
+	// +optional
+	DatasourceJSONDataOverrides map[string]runtime.RawExtension `json:"datasourceJSONDataOverrides,omitempty"`
+
+	// ServiceAccounts is a list of service accounts provisioned in the organization for external tools.
+	// The token of each service account is stored in a secret managed by the operator.
+	// +optional
+	ServiceAccounts []ServiceAccount `json:"serviceAccounts,omitempty"`
+
+	// Alerting defines the contact points and notification policies configured in the Alertmanager of each tenant of the organization.
+	// The configuration previously set by the organization is deleted when it is not set. The platform tenant and the tenants
+	// configured by another organization are rejected.
+	// +optional
+	Alerting *Alerting `json:"alerting,omitempty"`
+}
+
+// Alerting defines the Alertmanager configuration of the tenants of the organization.
+type Alerting struct {
+	// ContactPoints is the list of contact points notified of the alerts.
+	// +kubebuilder:validation:MinItems=1
+	ContactPoints []ContactPoint `json:"contactPoints"`
+
+	// NotificationPolicy is the root of the routing tree deciding which contact point is notified of each alert.
+	NotificationPolicy NotificationPolicy `json:"notificationPolicy"`
+}
+
+// ContactPoint defines a set of integrations notified of the alerts routed to it.
+type ContactPoint struct {
+	// Name is the name of the contact point, referenced by the notification policies.
+	// +kubebuilder:example="team-pager"
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Webhooks is the list of webhooks notified of the alerts.
+	// +optional
+	Webhooks []WebhookConfig `json:"webhooks,omitempty"`
+}
+
+// WebhookConfig defines a webhook notified of the alerts.
+type WebhookConfig struct {
+	// URL is the URL the alerts are sent to.
+	// +kubebuilder:validation:Pattern="^https?://"
+	URL string `json:"url"`
+
+	// SendResolved controls whether resolved alerts are sent too. It defaults to true.
+	// +optional
+	SendResolved *bool `json:"sendResolved,omitempty"`
 }
 
+// NotificationPolicy is the root of the routing tree. Alerts which do not match any of its routes are sent to its contact point.
+type NotificationPolicy struct {
+	// ContactPoint is the name of the contact point notified of the alerts which do not match any route.
+	// +kubebuilder:validation:MinLength=1
+	ContactPoint string `json:"contactPoint"`
+
+	// GroupBy is the list of labels alerts are grouped by in notifications.
+	// +kubebuilder:example={"alertname","cluster_id"}
+	// +optional
+	GroupBy []string `json:"groupBy,omitempty"`
+
+	// Routes is the list of routes alerts are matched against, in order.
+	// +optional
+	Routes []NotificationRoute `json:"routes,omitempty"`
+}
+
+// NotificationRoute sends the alerts matching its matchers to a contact point.
+type NotificationRoute struct {
+	// ContactPoint is the name of the contact point notified of the alerts matching the route.
+	// +kubebuilder:validation:MinLength=1
+	ContactPoint string `json:"contactPoint"`
+
+	// Matchers is the list of matchers, in the Alertmanager matcher syntax, the alerts must all match.
+	// +kubebuilder:example={"severity=\"page\""}
+	// +optional
+	Matchers []string `json:"matchers,omitempty"`
+
+	// GroupBy is the list of labels alerts are grouped by in notifications. It defaults to the one of the notification policy.
+	// +optional
+	GroupBy []string `json:"groupBy,omitempty"`
+
+	// Continue controls whether the alerts matching the route are matched against the next routes too.
+	// +optional
+	Continue bool `json:"continue,omitempty"`
+}
+
+// ServiceAccount defines a Grafana service account provisioned in the organization.
+type ServiceAccount struct {
+	// Name is the name of the service account. Its token is stored in the grafana-org-<organization ID>-<name>-token secret.
+	// +kubebuilder:example="dashboards-pusher"
+	// +kubebuilder:validation:Pattern="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Role is the role of the service account in the organization.
+	// +kubebuilder:validation:Enum=Admin;Editor;Viewer
+	// +kubebuilder:default=Editor
+	// +optional
+	Role string `json:"role,omitempty"`
+}
+
+// ExtraDatasourceType is the type of an additional datasource supported by the operator.
+// +kubebuilder:validation:Enum=graphite
+type ExtraDatasourceType string
+
+// Dashboard management modes of the GrafanaOrganization.
+const (
+	// DashboardManagementModeEnforce pushes the dashboards whenever they change or are modified in Grafana.
+	DashboardManagementModeEnforce = "enforce"
+	// DashboardManagementModeAdopt pushes the dashboards once and leaves them to the users of Grafana thereafter.
+	DashboardManagementModeAdopt = "adopt"
+	// DashboardManagementModeIgnore does not push the dashboards.
+	DashboardManagementModeIgnore = "ignore"
+)
+
+// TenantIDMaxLength is the maximum length of a tenant ID enforced by the CRD.
+const TenantIDMaxLength = 63
+
 // TenantID is a unique identifier for a tenant. It must be lowercase.
 // +kubebuilder:validation:Pattern="^[a-z]*$"
 // +kubebuilder:validation:MinLength=1
@@ -46,6 +220,7 @@ type TenantID string
 // More info on Grafana org attribute and role mapping at [Configure role mapping](https://grafana.com/docs/grafana/latest/setup-grafana/configure-security/configure-authentication/generic-oauth/#configure-role-mapping)
 type RBAC struct {
 	// Admins is a list of user organizations that have admin access to the grafanaorganization.
+	// +kubebuilder:validation:items:MinLength=1
 	Admins []string `json:"admins"`
 
 	// Editors is a list of user organizations that have editor access to the grafanaorganization.
@@ -66,6 +241,20 @@ type GrafanaOrganizationStatus struct {
 	// DataSources is a list of grafana data sources that are available to the Grafana organization.
 	// +optional
 	DataSources []DataSource `json:"dataSources"`
+
+	// Dashboards is a list of grafana dashboards managed by the operator in the Grafana organization.
+	// +optional
+	Dashboards []Dashboard `json:"dashboards"`
+
+	// AlertingTenants is the list of tenants whose Alertmanager configuration is managed by the organization.
+	// +optional
+	AlertingTenants []TenantID `json:"alertingTenants,omitempty"`
+
+	// Conditions describe the state of the reconciliation of the organization in Grafana.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // DataSource defines the name and id for data sources.
@@ -77,11 +266,21 @@ type DataSource struct {
 	Name string `json:"name"`
 }
 
+// Dashboard defines the uid and source configmap for dashboards.
+type Dashboard struct {
+	// UID is the unique id of the dashboard.
+	UID string `json:"uid"`
+
+	// ConfigMap is the namespaced name of the configmap the dashboard is defined in.
+	ConfigMap string `json:"configMap"`
+}
+
 //+kubebuilder:object:root=true
 //+kubebuilder:resource:scope=Cluster
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:JSONPath=".spec.displayName",name=DisplayName,type=string
 //+kubebuilder:printcolumn:JSONPath=".status.orgID",name=OrgID,type=integer
+//+kubebuilder:printcolumn:JSONPath=".status.conditions[?(@.type==\"Ready\")].status",name=Ready,type=string
 
 // GrafanaOrganization is the Schema describing a Grafana organization. Its lifecycle is managed by the observability-operator.
 type GrafanaOrganization struct {
@@ -0,0 +1,103 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MaintenanceWindowFinalizer is used to expire the Alertmanager silence before the maintenance window is deleted.
+	MaintenanceWindowFinalizer = "observability.giantswarm.io/maintenancewindow"
+)
+
+// Condition reasons of the MaintenanceWindow status, the Ready condition is shared with the GrafanaOrganization status.
+const (
+	InvalidMaintenanceWindowReason = "InvalidMaintenanceWindow"
+)
+
+// MaintenanceWindowSpec defines the desired state of MaintenanceWindow
+type MaintenanceWindowSpec struct {
+	// Tenant is the Alertmanager tenant the silence is created for. Defaults to the tenant used to configure Alertmanager.
+	// +kubebuilder:example="giantswarm"
+	// +optional
+	Tenant TenantID `json:"tenant,omitempty"`
+
+	// Clusters is a list of cluster names whose alerts are silenced during the maintenance window.
+	// +kubebuilder:example={"golem"}
+	// +optional
+	Clusters []string `json:"clusters,omitempty"`
+
+	// Matchers is a list of additional label matchers the silenced alerts must match.
+	// +optional
+	Matchers []SilenceMatcher `json:"matchers,omitempty"`
+
+	// StartsAt is the time the maintenance window starts at.
+	StartsAt metav1.Time `json:"startsAt"`
+
+	// EndsAt is the time the maintenance window ends at.
+	EndsAt metav1.Time `json:"endsAt"`
+
+	// Comment is the comment attached to the silence in Alertmanager.
+	// +optional
+	Comment string `json:"comment,omitempty"`
+}
+
+// SilenceMatcher defines a label matcher of an Alertmanager silence.
+type SilenceMatcher struct {
+	// Name is the name of the label to match.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Value is the value or the regular expression the label is matched against.
+	Value string `json:"value"`
+
+	// IsRegex is true when the value is a regular expression.
+	// +optional
+	IsRegex bool `json:"isRegex,omitempty"`
+}
+
+// MaintenanceWindowStatus defines the observed state of MaintenanceWindow
+type MaintenanceWindowStatus struct {
+	// SilenceID is the ID of the Alertmanager silence created for the maintenance window.
+	// +optional
+	SilenceID string `json:"silenceID,omitempty"`
+
+	// Tenant is the Alertmanager tenant the silence was created for, so it is expired there when the tenant changes.
+	// +optional
+	Tenant TenantID `json:"tenant,omitempty"`
+
+	// Conditions describe the state of the reconciliation of the maintenance window.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:JSONPath=".spec.startsAt",name=StartsAt,type=date
+//+kubebuilder:printcolumn:JSONPath=".spec.endsAt",name=EndsAt,type=date
+//+kubebuilder:printcolumn:JSONPath=".status.silenceID",name=SilenceID,type=string
+
+// MaintenanceWindow is the Schema describing a maintenance window during which alerts are silenced in Alertmanager. Its lifecycle is managed by the observability-operator.
+type MaintenanceWindow struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MaintenanceWindowSpec   `json:"spec,omitempty"`
+	Status MaintenanceWindowStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+
+// MaintenanceWindowList contains a list of MaintenanceWindow
+type MaintenanceWindowList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MaintenanceWindow `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MaintenanceWindow{}, &MaintenanceWindowList{})
+}
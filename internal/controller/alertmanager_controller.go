@@ -8,6 +8,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -19,47 +20,67 @@ import (
 	"github.com/giantswarm/observability-operator/pkg/config"
 )
 
-// AlertmanagerReconciler reconciles the Alertmanager secret created by the observability-operator Helm chart
-// and configures the Alertmanager instance with the configuration stored in the secret.
+// AlertmanagerReconciler reconciles the Alertmanager secret created by the observability-operator Helm chart, or the Alertmanager configmap when configured,
+// and configures the Alertmanager instance with the configuration stored in it.
 // This controller do not make use of finalizers as the configuration is not removed from Alertmanager when the secret is deleted.
 type AlertmanagerReconciler struct {
 	client client.Client
 
 	alertmanagerService alertmanager.Service
+
+	// configMapSource is true when the configuration is read from a configmap instead of a secret.
+	configMapSource bool
 }
 
-// SetupAlertmanagerReconciler adds a controller into mgr that reconciles the Alertmanager secret.
+// SetupAlertmanagerReconciler adds a controller into mgr that reconciles the Alertmanager secret or configmap.
 func SetupAlertmanagerReconciler(mgr ctrl.Manager, conf config.Config) error {
+	if conf.Monitoring.AlertmanagerSecretName != "" && conf.Monitoring.AlertmanagerConfigMapName != "" {
+		return errors.New("alertmanager secret name and configmap name cannot be set at the same time")
+	}
+
 	r := &AlertmanagerReconciler{
 		client:              mgr.GetClient(),
 		alertmanagerService: alertmanager.New(conf),
+		configMapSource:     conf.Monitoring.AlertmanagerConfigMapName != "",
 	}
 
-	// Filter only the Alertmanager secret created by the observability-operator Helm chart
-	secretPredicate := predicates.NewAlertmanagerSecretPredicate(conf)
-
 	// Filter only the Mimir Alertmanager pod
 	podPredicate := predicates.NewAlertmanagerPodPredicate()
 
-	// Requeue the Alertmanager secret when the Mimir Alertmanager pod changes
+	// Requeue the Alertmanager secret or configmap when the Mimir Alertmanager pod changes
 	p := podEventHandler(conf)
 
 	// Setup the controller
-	return ctrl.NewControllerManagedBy(mgr).
-		Named("alertmanager").
-		For(&v1.Secret{}, builder.WithPredicates(secretPredicate)).
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named("alertmanager")
+
+	if r.configMapSource {
+		// Filter only the Alertmanager configmap
+		b = b.For(&v1.ConfigMap{}, builder.WithPredicates(predicates.NewAlertmanagerConfigMapPredicate(conf)))
+	} else {
+		// Filter only the Alertmanager secret created by the observability-operator Helm chart
+		b = b.For(&v1.Secret{}, builder.WithPredicates(predicates.NewAlertmanagerSecretPredicate(conf)))
+	}
+
+	return b.
 		Watches(&v1.Pod{}, p, builder.WithPredicates(podPredicate)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: conf.MaxConcurrentReconciles.Alertmanager}).
 		Complete(r)
 }
 
-// podEventHandler returns an event handler that enqueues requests for the Alertmanager secret only.
-// For now there is only one Alertmanager secret to be reconciled.
+// podEventHandler returns an event handler that enqueues requests for the Alertmanager secret or configmap only.
+// For now there is only one Alertmanager secret or configmap to be reconciled.
 func podEventHandler(conf config.Config) handler.EventHandler {
+	name := conf.Monitoring.AlertmanagerSecretName
+	if conf.Monitoring.AlertmanagerConfigMapName != "" {
+		name = conf.Monitoring.AlertmanagerConfigMapName
+	}
+
 	return handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
 		return []reconcile.Request{
 			{
 				NamespacedName: types.NamespacedName{
-					Name:      conf.Monitoring.AlertmanagerSecretName,
+					Name:      name,
 					Namespace: conf.OperatorNamespace,
 				},
 			},
@@ -73,12 +94,21 @@ func (r AlertmanagerReconciler) Reconcile(ctx context.Context, req reconcile.Req
 
 	logger.Info("Started reconciling")
 
+	if r.configMapSource {
+		return r.reconcileConfigMap(ctx, req)
+	}
+
 	// Retrieve the secret being reconciled
 	secret := &v1.Secret{}
 	if err := r.client.Get(ctx, req.NamespacedName, secret); err != nil {
 		return ctrl.Result{}, errors.WithStack(err)
 	}
 
+	if isPaused(secret) {
+		logger.Info("Skipping Alertmanager configuration, reconciliation is paused", "annotation", pausedAnnotation)
+		return ctrl.Result{}, nil
+	}
+
 	if !secret.DeletionTimestamp.IsZero() {
 		// Nothing to do if the secret is being deleted
 		// Configuration is not removed from Alertmanager when the secret is deleted.
@@ -94,3 +124,34 @@ func (r AlertmanagerReconciler) Reconcile(ctx context.Context, req reconcile.Req
 
 	return ctrl.Result{}, nil
 }
+
+// reconcileConfigMap configures Alertmanager from the configmap being reconciled.
+func (r AlertmanagerReconciler) reconcileConfigMap(ctx context.Context, req reconcile.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	// Retrieve the configmap being reconciled
+	configMap := &v1.ConfigMap{}
+	if err := r.client.Get(ctx, req.NamespacedName, configMap); err != nil {
+		return ctrl.Result{}, errors.WithStack(err)
+	}
+
+	if isPaused(configMap) {
+		logger.Info("Skipping Alertmanager configuration, reconciliation is paused", "annotation", pausedAnnotation)
+		return ctrl.Result{}, nil
+	}
+
+	if !configMap.DeletionTimestamp.IsZero() {
+		// Nothing to do if the configmap is being deleted
+		// Configuration is not removed from Alertmanager when the configmap is deleted.
+		return ctrl.Result{}, nil
+	}
+
+	err := r.alertmanagerService.ConfigureFromConfigMap(ctx, configMap)
+	if err != nil {
+		return ctrl.Result{}, errors.WithStack(err)
+	}
+
+	logger.Info("Finished reconciling")
+
+	return ctrl.Result{}, nil
+}
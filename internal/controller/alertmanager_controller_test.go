@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/giantswarm/observability-operator/pkg/alertmanager"
+	"github.com/giantswarm/observability-operator/pkg/config"
+	"github.com/giantswarm/observability-operator/pkg/monitoring"
+)
+
+func TestAlertmanagerReconcilerConfigMapSource(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request body: %v", err)
+		}
+		received = string(body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	conf := config.Config{
+		OperatorNamespace: "monitoring",
+		Monitoring: monitoring.Config{
+			AlertmanagerConfigMapName: "alertmanager-config",
+			AlertmanagerURL:           server.URL,
+		},
+	}
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "alertmanager-config",
+			Namespace: "monitoring",
+		},
+		Data: map[string]string{
+			"alertmanager.yaml": "route:\n  receiver: default\nreceivers:\n- name: default\n",
+			"notification.tmpl": "{{ define \"test\" }}test{{ end }}",
+		},
+	}
+
+	r := AlertmanagerReconciler{
+		client:              fake.NewClientBuilder().WithObjects(configMap).Build(),
+		alertmanagerService: alertmanager.New(conf),
+		configMapSource:     true,
+	}
+
+	_, err := r.Reconcile(context.Background(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: "alertmanager-config", Namespace: "monitoring"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(received, "receiver: default") {
+		t.Errorf("expected the configmap configuration to be sent, got %q", received)
+	}
+	if !strings.Contains(received, "notification.tmpl") {
+		t.Errorf("expected the configmap templates to be sent, got %q", received)
+	}
+}
+
+func TestAlertmanagerReconcilerPaused(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected Alertmanager request %s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	conf := config.Config{
+		OperatorNamespace: "monitoring",
+		Monitoring: monitoring.Config{
+			AlertmanagerConfigMapName: "alertmanager-config",
+			AlertmanagerURL:           server.URL,
+		},
+	}
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "alertmanager-config",
+			Namespace:   "monitoring",
+			Annotations: map[string]string{pausedAnnotation: "true"},
+		},
+		Data: map[string]string{
+			"alertmanager.yaml": "route:\n  receiver: default\nreceivers:\n- name: default\n",
+		},
+	}
+
+	r := AlertmanagerReconciler{
+		client:              fake.NewClientBuilder().WithObjects(configMap).Build(),
+		alertmanagerService: alertmanager.New(conf),
+		configMapSource:     true,
+	}
+
+	_, err := r.Reconcile(context.Background(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: "alertmanager-config", Namespace: "monitoring"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	grafanaAPI "github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/giantswarm/observability-operator/pkg/config"
+	"github.com/giantswarm/observability-operator/pkg/grafana"
+	grafanaclient "github.com/giantswarm/observability-operator/pkg/grafana/client"
+)
+
+// AlertRuleReconciler provisions Grafana-managed alert rules from configmaps.
+type AlertRuleReconciler struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	GrafanaAPI *grafanaAPI.GrafanaHTTPAPI
+
+	// ManagedOrganizations are the display names of the organizations the operator is allowed to manage. All organizations are managed when it is empty.
+	ManagedOrganizations []string
+	// AllowedOrganizations maps namespaces to the organizations their alert rules may be pushed to, like the dashboards.
+	AllowedOrganizations map[string][]string
+	// MaxConcurrentReconciles is the maximum number of alert rule configmaps reconciled concurrently.
+	MaxConcurrentReconciles int
+}
+
+const (
+	AlertRuleFinalizer          = "observability.giantswarm.io/grafanaalertrule"
+	AlertRuleSelectorLabelValue = "alert-rule"
+
+	// syncedAlertRulesAnnotation records the UIDs of the alert rules of the configmap which were pushed to Grafana.
+	syncedAlertRulesAnnotation = "observability.giantswarm.io/synced-alert-rules"
+)
+
+func SetupAlertRuleReconciler(mgr manager.Manager, conf config.Config) error {
+	grafanaAPI, err := grafanaclient.GenerateGrafanaClient(conf.GrafanaURL, conf)
+	if err != nil {
+		return fmt.Errorf("unable to create grafana client: %w", err)
+	}
+
+	r := &AlertRuleReconciler{
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		GrafanaAPI:              grafanaAPI,
+		ManagedOrganizations:    conf.GrafanaManagedOrganizations,
+		AllowedOrganizations:    conf.DashboardAllowedOrganizations,
+		MaxConcurrentReconciles: conf.MaxConcurrentReconciles.AlertRule,
+	}
+
+	return r.SetupWithManager(mgr)
+}
+
+// Reconcile provisions the alert rules of the configmap in the Grafana organization of the configmap.
+func (r *AlertRuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	logger.Info("Started reconciling Grafana alert rule configmaps")
+	defer logger.Info("Finished reconciling Grafana alert rule configmaps")
+
+	alertRules := &v1.ConfigMap{}
+	err := r.Client.Get(ctx, req.NamespacedName, alertRules)
+	if err != nil {
+		return ctrl.Result{}, errors.WithStack(client.IgnoreNotFound(err))
+	}
+
+	if isPaused(alertRules) {
+		logger.Info("Skipping alert rules, reconciliation is paused", "annotation", pausedAnnotation)
+		return ctrl.Result{}, nil
+	}
+
+	// Handle deleted alert rules
+	if !alertRules.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.reconcileDelete(ctx, alertRules)
+	}
+
+	// Add finalizer first if not set to avoid the race condition between init and delete.
+	if !controllerutil.ContainsFinalizer(alertRules, AlertRuleFinalizer) {
+		logger.Info("adding finalizer", "finalizer", AlertRuleFinalizer)
+		patchHelper, err := patch.NewHelper(alertRules, r.Client)
+		if err != nil {
+			return ctrl.Result{}, errors.WithStack(err)
+		}
+		controllerutil.AddFinalizer(alertRules, AlertRuleFinalizer)
+		if err := patchHelper.Patch(ctx, alertRules); err != nil {
+			logger.Error(err, "failed to add finalizer", "finalizer", AlertRuleFinalizer)
+			return ctrl.Result{}, errors.WithStack(err)
+		}
+		logger.Info("added finalizer", "finalizer", AlertRuleFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{}, errors.WithStack(r.configureAlertRules(ctx, alertRules))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AlertRuleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	labelSelectorPredicate, err := predicate.LabelSelectorPredicate(metav1.LabelSelector{MatchLabels: map[string]string{DashboardSelectorLabelName: AlertRuleSelectorLabelValue}})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("alertrules").
+		For(&v1.ConfigMap{}, builder.WithPredicates(labelSelectorPredicate)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
+		Complete(r)
+}
+
+// configureAlertRules creates or updates the alert rules of the configmap and deletes the ones removed from it.
+// Rules without a UID or a group are skipped and block the deletions until they are fixed, the other rules are retried when they fail.
+func (r AlertRuleReconciler) configureAlertRules(ctx context.Context, alertRulesCM *v1.ConfigMap) error {
+	logger := log.FromContext(ctx)
+
+	organization, orgAPI, err := resolveConfigMapOrganization(ctx, r.GrafanaAPI, r.ManagedOrganizations, r.AllowedOrganizations, alertRulesCM)
+	if err != nil || organization == nil {
+		return errors.WithStack(err)
+	}
+
+	previouslySynced := getSyncedUIDs(alertRulesCM, syncedAlertRulesAnnotation)
+	synced := make([]string, 0, len(alertRulesCM.Data))
+	currentAlertRuleUIDs := make(map[string]bool, len(alertRulesCM.Data))
+	var alertRuleErrors []error
+	// An alert rule which cannot be parsed cannot be told apart from a removed one, its UID is unknown
+	parsingFailed := false
+	for _, key := range slices.Sorted(maps.Keys(alertRulesCM.Data)) {
+		alertRule, err := getAlertRule(alertRulesCM.Data[key])
+		if err != nil {
+			logger.Error(err, "Skipping alert rule", "key", key)
+			parsingFailed = true
+			continue
+		}
+		currentAlertRuleUIDs[alertRule.UID] = true
+
+		err = grafana.PublishAlertRule(ctx, orgAPI, organization.ID, alertRule)
+		if err != nil {
+			logger.Error(err, "Failed updating alert rule", "Alert rule UID", alertRule.UID)
+			alertRuleErrors = append(alertRuleErrors, errors.Wrapf(err, "alert rule %q", alertRule.UID))
+			// Keep track of a previous version of the alert rule so it is still deleted once removed from the configmap
+			if slices.Contains(previouslySynced, alertRule.UID) {
+				synced = append(synced, alertRule.UID)
+			}
+			continue
+		}
+		synced = append(synced, alertRule.UID)
+		logger.Info("updated alert rule", "Alert rule UID", alertRule.UID, "Alert rule Org", organization.Name)
+	}
+
+	// The alert rules are only deleted once all of them are parsed, so a typo does not delete a live alert rule
+	if parsingFailed {
+		logger.Info("skipping the deletion of the alert rules removed from the configmap as some alert rules could not be parsed")
+		for _, alertRuleUID := range previouslySynced {
+			if !currentAlertRuleUIDs[alertRuleUID] {
+				currentAlertRuleUIDs[alertRuleUID] = true
+				synced = append(synced, alertRuleUID)
+			}
+		}
+	}
+
+	// Delete the alert rules which were removed from the configmap since they were pushed
+	for _, alertRuleUID := range previouslySynced {
+		if currentAlertRuleUIDs[alertRuleUID] {
+			continue
+		}
+
+		err = grafana.DeleteAlertRule(ctx, orgAPI, organization.ID, alertRuleUID)
+		if err != nil && !grafana.IsNotFound(err) {
+			logger.Error(err, "Failed deleting alert rule", "Alert rule UID", alertRuleUID)
+			alertRuleErrors = append(alertRuleErrors, errors.Wrapf(err, "alert rule %q", alertRuleUID))
+			// Keep track of the alert rule so its deletion is retried
+			synced = append(synced, alertRuleUID)
+			continue
+		}
+		logger.Info("deleted alert rule removed from the configmap", "Alert rule UID", alertRuleUID, "Alert rule Org", organization.Name)
+	}
+
+	err = updateSyncedUIDs(ctx, r.Client, alertRulesCM, syncedAlertRulesAnnotation, synced)
+	if err != nil {
+		logger.Error(err, "failed to record the synced alert rules in the configmap")
+		return errors.WithStack(err)
+	}
+
+	return kerrors.NewAggregate(alertRuleErrors)
+}
+
+// reconcileDelete deletes the alert rules of the configmap from Grafana and removes the finalizer.
+func (r AlertRuleReconciler) reconcileDelete(ctx context.Context, alertRulesCM *v1.ConfigMap) error {
+	logger := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(alertRulesCM, AlertRuleFinalizer) {
+		return nil
+	}
+
+	organization, orgAPI, err := resolveConfigMapOrganization(ctx, r.GrafanaAPI, r.ManagedOrganizations, r.AllowedOrganizations, alertRulesCM)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if organization != nil {
+		// Alert rules removed from the configmap whose deletion failed are still recorded as synced
+		alertRuleUIDs := getSyncedUIDs(alertRulesCM, syncedAlertRulesAnnotation)
+		for _, alertRuleString := range alertRulesCM.Data {
+			alertRule, err := getAlertRule(alertRuleString)
+			if err != nil {
+				continue
+			}
+			alertRuleUIDs = append(alertRuleUIDs, alertRule.UID)
+		}
+
+		slices.Sort(alertRuleUIDs)
+		for _, alertRuleUID := range slices.Compact(alertRuleUIDs) {
+			err = grafana.DeleteAlertRule(ctx, orgAPI, organization.ID, alertRuleUID)
+			if err != nil && !grafana.IsNotFound(err) {
+				logger.Error(err, "Failed deleting alert rule", "Alert rule UID", alertRuleUID)
+				return errors.WithStack(err)
+			}
+			logger.Info("deleted alert rule", "Alert rule UID", alertRuleUID, "Alert rule Org", organization.Name)
+		}
+	}
+
+	// We use the patch from sigs.k8s.io/cluster-api/util/patch to handle the patching without conflicts
+	logger.Info("removing finalizer", "finalizer", AlertRuleFinalizer)
+	patchHelper, err := patch.NewHelper(alertRulesCM, r.Client)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	controllerutil.RemoveFinalizer(alertRulesCM, AlertRuleFinalizer)
+	if err := patchHelper.Patch(ctx, alertRulesCM); err != nil {
+		logger.Error(err, "failed to remove finalizer, requeuing", "finalizer", AlertRuleFinalizer)
+		return errors.WithStack(err)
+	}
+	logger.Info("removed finalizer", "finalizer", AlertRuleFinalizer)
+
+	return nil
+}
+
+// getAlertRule parses the alert rule, which must have a UID and a group.
+func getAlertRule(alertRuleString string) (*models.ProvisionedAlertRule, error) {
+	alertRule := &models.ProvisionedAlertRule{}
+	err := json.Unmarshal([]byte(alertRuleString), alertRule)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if alertRule.UID == "" {
+		return nil, errors.New("alert rule UID not found in configmap")
+	}
+	if alertRule.RuleGroup == nil || *alertRule.RuleGroup == "" {
+		return nil, errors.Errorf("alert rule %q group not found in configmap", alertRule.UID)
+	}
+
+	return alertRule, nil
+}
@@ -0,0 +1,251 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	grafanaAPI "github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/client/provisioning"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// fakeProvisioning stores the alert rules in memory, indexed by UID.
+type fakeProvisioning struct {
+	provisioning.ClientService
+
+	rules   map[string]*models.ProvisionedAlertRule
+	created []string
+	updated []string
+	deleted []string
+}
+
+func (f *fakeProvisioning) GetAlertRule(uid string, opts ...provisioning.ClientOption) (*provisioning.GetAlertRuleOK, error) {
+	rule, ok := f.rules[uid]
+	if !ok {
+		return nil, errors.New("[GET /v1/provisioning/alert-rules/{UID}][404] getAlertRuleNotFound (status 404)")
+	}
+	return &provisioning.GetAlertRuleOK{Payload: rule}, nil
+}
+
+func (f *fakeProvisioning) PostAlertRule(params *provisioning.PostAlertRuleParams, opts ...provisioning.ClientOption) (*provisioning.PostAlertRuleCreated, error) {
+	f.created = append(f.created, params.Body.UID)
+	f.rules[params.Body.UID] = params.Body
+	return &provisioning.PostAlertRuleCreated{Payload: params.Body}, nil
+}
+
+func (f *fakeProvisioning) PutAlertRule(params *provisioning.PutAlertRuleParams, opts ...provisioning.ClientOption) (*provisioning.PutAlertRuleOK, error) {
+	f.updated = append(f.updated, params.UID)
+	f.rules[params.UID] = params.Body
+	return &provisioning.PutAlertRuleOK{Payload: params.Body}, nil
+}
+
+func (f *fakeProvisioning) DeleteAlertRule(params *provisioning.DeleteAlertRuleParams, opts ...provisioning.ClientOption) (*provisioning.DeleteAlertRuleNoContent, error) {
+	if _, ok := f.rules[params.UID]; !ok {
+		return nil, errors.New("[DELETE /v1/provisioning/alert-rules/{UID}][404] deleteAlertRuleNotFound (status 404)")
+	}
+	f.deleted = append(f.deleted, params.UID)
+	delete(f.rules, params.UID)
+	return &provisioning.DeleteAlertRuleNoContent{}, nil
+}
+
+func TestConfigureAlertRules(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "alert-rules",
+			Namespace:   "default",
+			Labels:      map[string]string{DashboardSelectorLabelName: AlertRuleSelectorLabelValue},
+			Annotations: map[string]string{grafanaOrganizationLabel: "Test"},
+			Finalizers:  []string{AlertRuleFinalizer},
+		},
+		Data: map[string]string{
+			"high-cpu.json": `{"uid": "high-cpu", "title": "High CPU", "ruleGroup": "nodes", "folderUID": "alerts"}`,
+			"no-uid.json":   `{"title": "Without UID", "ruleGroup": "nodes"}`,
+			"no-group.json": `{"uid": "no-group", "title": "Without group"}`,
+			"invalid.json":  `{"uid": `,
+		},
+	}
+
+	alertRules := &fakeProvisioning{
+		rules: map[string]*models.ProvisionedAlertRule{},
+	}
+	r := AlertRuleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap).Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:         &fakeOrgs{},
+			Provisioning: alertRules,
+		},
+	}
+
+	t.Run("create", func(t *testing.T) {
+		if err := r.configureAlertRules(context.Background(), configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(alertRules.created) != 1 || alertRules.created[0] != "high-cpu" {
+			t.Fatalf("expected only the alert rule with a UID and a group to be created, got %v", alertRules.created)
+		}
+		rule := alertRules.rules["high-cpu"]
+		if rule.OrgID == nil || *rule.OrgID != 2 {
+			t.Errorf("expected the alert rule to be created in organization 2, got %v", rule.OrgID)
+		}
+		if len(alertRules.updated) != 0 {
+			t.Errorf("expected no alert rule to be updated, got %v", alertRules.updated)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		configMap.Data["high-cpu.json"] = `{"uid": "high-cpu", "title": "Very high CPU", "ruleGroup": "nodes", "folderUID": "alerts"}`
+
+		if err := r.configureAlertRules(context.Background(), configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(alertRules.updated) != 1 || alertRules.updated[0] != "high-cpu" {
+			t.Fatalf("expected the existing alert rule to be updated, got %v", alertRules.updated)
+		}
+		if rule := alertRules.rules["high-cpu"]; rule.Title == nil || *rule.Title != "Very high CPU" {
+			t.Errorf("expected the alert rule to be renamed, got %v", rule.Title)
+		}
+	})
+
+	t.Run("keep alert rules while some cannot be parsed", func(t *testing.T) {
+		configMap.Data["high-memory.json"] = `{"uid": "high-memory", "title": "High memory", "ruleGroup": "nodes"}`
+		if err := r.configureAlertRules(context.Background(), configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// The group of the synced alert rule was dropped by mistake
+		configMap.Data["high-memory.json"] = `{"uid": "high-memory", "title": "High memory"}`
+		if err := r.configureAlertRules(context.Background(), configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(alertRules.deleted) != 0 {
+			t.Errorf("expected no alert rule to be deleted, got %v", alertRules.deleted)
+		}
+		if synced := getSyncedUIDs(configMap, syncedAlertRulesAnnotation); len(synced) != 2 {
+			t.Errorf("expected the alert rules to still be recorded as synced, got %v", synced)
+		}
+	})
+
+	t.Run("delete removed alert rules", func(t *testing.T) {
+		// The alert rules are only deleted once all the remaining ones can be parsed
+		delete(configMap.Data, "high-memory.json")
+		delete(configMap.Data, "no-uid.json")
+		delete(configMap.Data, "no-group.json")
+		delete(configMap.Data, "invalid.json")
+		if err := r.configureAlertRules(context.Background(), configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(alertRules.deleted) != 1 || alertRules.deleted[0] != "high-memory" {
+			t.Fatalf("expected the removed alert rule to be deleted, got %v", alertRules.deleted)
+		}
+		if synced := getSyncedUIDs(configMap, syncedAlertRulesAnnotation); !slices.Equal(synced, []string{"high-cpu"}) {
+			t.Errorf("expected only the remaining alert rule to be recorded as synced, got %v", synced)
+		}
+		alertRules.deleted = nil
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		// rules which were never created in Grafana do not block the deletion
+		configMap.Data["other.json"] = `{"uid": "other", "title": "Other", "ruleGroup": "nodes"}`
+
+		if err := r.reconcileDelete(context.Background(), configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(alertRules.deleted) != 1 || alertRules.deleted[0] != "high-cpu" {
+			t.Fatalf("expected the alert rule to be deleted, got %v", alertRules.deleted)
+		}
+		if len(configMap.Finalizers) != 0 {
+			t.Errorf("expected the finalizer to be removed, got %v", configMap.Finalizers)
+		}
+	})
+}
+
+func TestConfigureAlertRulesNotAllowed(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "alert-rules",
+			Namespace:   "team",
+			Labels:      map[string]string{DashboardSelectorLabelName: AlertRuleSelectorLabelValue},
+			Annotations: map[string]string{grafanaOrganizationLabel: "Test"},
+		},
+		Data: map[string]string{
+			"high-cpu.json": `{"uid": "high-cpu", "title": "High CPU", "ruleGroup": "nodes"}`,
+		},
+	}
+
+	alertRules := &fakeProvisioning{rules: map[string]*models.ProvisionedAlertRule{}}
+	r := AlertRuleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap).Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:         &fakeOrgs{},
+			Provisioning: alertRules,
+		},
+		AllowedOrganizations: map[string][]string{"team": {"Other"}},
+	}
+
+	if err := r.configureAlertRules(context.Background(), configMap); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(alertRules.created) != 0 {
+		t.Errorf("expected no alert rule to be pushed to an organization the namespace is not allowed to push to, got %v", alertRules.created)
+	}
+}
+
+func TestReconcileAlertRulesPaused(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "alert-rules",
+			Namespace: "default",
+			Labels:    map[string]string{DashboardSelectorLabelName: AlertRuleSelectorLabelValue},
+			Annotations: map[string]string{
+				grafanaOrganizationLabel: "Test",
+				pausedAnnotation:         "true",
+			},
+		},
+		Data: map[string]string{
+			"high-cpu.json": `{"uid": "high-cpu", "title": "High CPU", "ruleGroup": "nodes"}`,
+		},
+	}
+
+	alertRules := &fakeProvisioning{rules: map[string]*models.ProvisionedAlertRule{}}
+	r := AlertRuleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap).Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:         &fakeOrgs{},
+			Provisioning: alertRules,
+		},
+	}
+
+	if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(configMap)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	current := &v1.ConfigMap{}
+	if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(configMap), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(current.Finalizers) != 0 {
+		t.Errorf("expected no finalizer on a paused configmap, got %v", current.Finalizers)
+	}
+	if len(alertRules.created) != 0 {
+		t.Errorf("expected no alert rule to be pushed, got %v", alertRules.created)
+	}
+}
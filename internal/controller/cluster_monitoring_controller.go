@@ -2,32 +2,45 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/blang/semver"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	"github.com/giantswarm/observability-operator/internal/controller/predicates"
 	"github.com/giantswarm/observability-operator/pkg/bundle"
 	"github.com/giantswarm/observability-operator/pkg/common"
 	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
 	"github.com/giantswarm/observability-operator/pkg/common/organization"
 	"github.com/giantswarm/observability-operator/pkg/common/password"
 	"github.com/giantswarm/observability-operator/pkg/config"
+	"github.com/giantswarm/observability-operator/pkg/metrics"
 	"github.com/giantswarm/observability-operator/pkg/monitoring"
 	"github.com/giantswarm/observability-operator/pkg/monitoring/alloy"
 	"github.com/giantswarm/observability-operator/pkg/monitoring/heartbeat"
 	"github.com/giantswarm/observability-operator/pkg/monitoring/mimir"
 	"github.com/giantswarm/observability-operator/pkg/monitoring/prometheusagent"
+	"github.com/giantswarm/observability-operator/pkg/monitoring/prometheusagent/sharding"
 )
 
 var (
@@ -51,6 +64,15 @@ type ClusterMonitoringReconciler struct {
 	*bundle.BundleConfigurationService
 	// MonitoringConfig is the configuration for the monitoring package.
 	MonitoringConfig monitoring.Config
+	// ClusterLabelSelector selects the clusters reconciled by the controller, all clusters are reconciled when it is nil.
+	ClusterLabelSelector labels.Selector
+	// MaxConcurrentReconciles is the maximum number of clusters reconciled concurrently.
+	MaxConcurrentReconciles int
+	// Finalizer is the finalizer added to the clusters, so several instances of the operator can manage the same clusters. Defaults to monitoring.MonitoringFinalizer.
+	Finalizer string
+
+	// clusterEvents enqueues the clusters whose monitoring agents must pick up a rotated Mimir password.
+	clusterEvents chan event.GenericEvent
 }
 
 func SetupClusterMonitoringReconciler(mgr manager.Manager, conf config.Config) error {
@@ -60,15 +82,28 @@ func SetupClusterMonitoringReconciler(mgr manager.Manager, conf config.Config) e
 		return fmt.Errorf("OpsgenieApiKey not set: %q", conf.Environment.OpsgenieApiKey)
 	}
 
-	heartbeatRepository, err := heartbeat.NewOpsgenieHeartbeatRepository(conf.Environment.OpsgenieApiKey, conf.ManagementCluster)
+	heartbeatRepository, err := heartbeat.NewOpsgenieHeartbeatRepository(conf.Environment.OpsgenieApiKey, conf.Monitoring.HeartbeatOpsgenieRegion, conf.ManagementCluster,
+		conf.Monitoring.HeartbeatInterval, conf.Monitoring.HeartbeatRetryCount)
 	if err != nil {
 		return fmt.Errorf("unable to create heartbeat repository: %w", err)
 	}
 
+	if conf.Environment.HeartbeatFailureWebhookURL != "" {
+		heartbeatRepository, err = heartbeat.NewNotifyingHeartbeatRepository(heartbeatRepository, conf.ManagementCluster,
+			heartbeat.NewWebhookNotifier(conf.Environment.HeartbeatFailureWebhookURL), conf.Monitoring.HeartbeatFailureThreshold)
+		if err != nil {
+			return fmt.Errorf("unable to create heartbeat repository: %w", err)
+		}
+	}
+
 	organizationRepository := organization.NewNamespaceRepository(managerClient)
+	if len(conf.OrganizationOverrides) > 0 {
+		organizationRepository = organization.NewOverrideRepository(conf.OrganizationOverrides, organizationRepository)
+	}
 
 	prometheusAgentService := prometheusagent.PrometheusAgentService{
 		Client:                 managerClient,
+		APIReader:              mgr.GetAPIReader(),
 		OrganizationRepository: organizationRepository,
 		PasswordManager:        password.SimpleManager{},
 		ManagementCluster:      conf.ManagementCluster,
@@ -77,16 +112,27 @@ func SetupClusterMonitoringReconciler(mgr manager.Manager, conf config.Config) e
 
 	alloyService := alloy.Service{
 		Client:                 managerClient,
+		APIReader:              mgr.GetAPIReader(),
 		OrganizationRepository: organizationRepository,
 		PasswordManager:        password.SimpleManager{},
 		ManagementCluster:      conf.ManagementCluster,
 		MonitoringConfig:       conf.Monitoring,
 	}
 
+	if conf.Monitoring.AlloyConfigDebugEndpointEnabled {
+		// The debug endpoint is only served on the metrics address.
+		err = mgr.AddMetricsServerExtraHandler(alloy.DebugConfigPath, alloyService.DebugConfigHandler())
+		if err != nil {
+			return fmt.Errorf("unable to register the alloy config debug endpoint: %w", err)
+		}
+	}
+
 	mimirService := mimir.MimirService{
 		Client:            managerClient,
+		APIReader:         mgr.GetAPIReader(),
 		PasswordManager:   password.SimpleManager{},
 		ManagementCluster: conf.ManagementCluster,
+		MonitoringConfig:  conf.Monitoring,
 	}
 
 	r := &ClusterMonitoringReconciler{
@@ -98,6 +144,9 @@ func SetupClusterMonitoringReconciler(mgr manager.Manager, conf config.Config) e
 		MimirService:               mimirService,
 		MonitoringConfig:           conf.Monitoring,
 		BundleConfigurationService: bundle.NewBundleConfigurationService(managerClient, conf.Monitoring),
+		ClusterLabelSelector:       conf.ClusterLabelSelector,
+		MaxConcurrentReconciles:    conf.MaxConcurrentReconciles.ClusterMonitoring,
+		Finalizer:                  conf.MonitoringFinalizer,
 	}
 
 	err = r.SetupWithManager(mgr)
@@ -110,8 +159,29 @@ func SetupClusterMonitoringReconciler(mgr manager.Manager, conf config.Config) e
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterMonitoringReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	selector := r.ClusterLabelSelector
+	if selector == nil {
+		selector = labels.Everything()
+	}
+
+	r.clusterEvents = make(chan event.GenericEvent)
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&clusterv1.Cluster{}).
+		For(&clusterv1.Cluster{}, builder.WithPredicates(
+			predicates.NewClusterLabelSelectorPredicate(selector, r.finalizer(), r.ManagementCluster.Name),
+			// The monitoring status and pending scaling annotations are set by this controller so they must not trigger a new reconciliation.
+			predicates.NewIgnoreAnnotationsChangedPredicate(
+				monitoring.LastReconcileTimeAnnotation,
+				monitoring.MonitoringAgentAnnotation,
+				monitoring.ReconcileErrorAnnotation,
+				monitoring.MonitoringEnabledAnnotation,
+				monitoring.MonitoringDisabledTimeAnnotation,
+				monitoring.ObservabilityBundleNotFoundAttemptsAnnotation,
+				commonmonitoring.PendingScalingAnnotation,
+			),
+		)).
+		WatchesRawSource(source.Channel(r.clusterEvents, &handler.EnqueueRequestForObject{})).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
@@ -143,6 +213,11 @@ func (r *ClusterMonitoringReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	logger := log.FromContext(ctx).WithValues("installation", r.ManagementCluster.Name) // nolint
 	ctx = log.IntoContext(ctx, logger)
 
+	if isPaused(cluster) {
+		logger.Info("reconciliation is paused for this cluster.", "annotation", pausedAnnotation)
+		return ctrl.Result{}, nil
+	}
+
 	if !r.MonitoringConfig.Enabled {
 		logger.Info("monitoring is disabled at the installation level.")
 	}
@@ -157,6 +232,13 @@ func (r *ClusterMonitoringReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		return r.reconcileDelete(ctx, cluster)
 	}
 
+	// Clusters which stop matching the selector are cleaned up like deleted ones so they do not keep the finalizer.
+	// The management cluster is always reconciled as the remote write of every cluster depends on its secrets.
+	if r.ClusterLabelSelector != nil && cluster.Name != r.ManagementCluster.Name && !r.ClusterLabelSelector.Matches(labels.Set(cluster.GetLabels())) {
+		logger.Info("cluster does not match the cluster label selector anymore, removing its monitoring")
+		return r.reconcileDelete(ctx, cluster)
+	}
+
 	logger.Info("reconciling cluster")
 	// Handle normal reconciliation loop.
 	return r.reconcile(ctx, cluster)
@@ -169,53 +251,102 @@ func (r *ClusterMonitoringReconciler) reconcile(ctx context.Context, cluster *cl
 
 	// Add finalizer first if not set to avoid the race condition between init and delete.
 	// Note: Finalizers in general can only be added when the deletionTimestamp is not set.
-	if !controllerutil.ContainsFinalizer(cluster, monitoring.MonitoringFinalizer) {
+	if !controllerutil.ContainsFinalizer(cluster, r.finalizer()) {
 		return r.addFinalizer(ctx, cluster)
 	}
 
+	// The teardown of the monitoring is delayed by the grace period once it is disabled.
+	gracePeriodRemaining, err := r.trackMonitoringDisabledTime(ctx, cluster)
+	if err != nil {
+		return ctrl.Result{}, errors.WithStack(err)
+	}
+
 	// Management cluster specific configuration
 	if cluster.Name == r.ManagementCluster.Name {
-		result := r.reconcileManagementCluster(ctx)
-		if result != nil {
-			return *result, nil
+		err = r.reconcileManagementCluster(ctx)
+		if err != nil {
+			return r.reconcileFailed(ctx, cluster, err)
 		}
 	}
 
+	// The monitoring agent can be pinned per cluster
+	monitoringAgent, err := r.MonitoringConfig.ClusterMonitoringAgent(cluster)
+	if err != nil {
+		logger.Error(err, "failed to get the monitoring agent of the cluster")
+		return r.reconcileFailed(ctx, cluster, err)
+	}
+
 	// Enforce prometheus-agent as monitoring agent when observability-bundle version < 1.6.0
-	monitoringAgent := r.MonitoringConfig.MonitoringAgent
 	observabilityBundleVersion, err := commonmonitoring.GetObservabilityBundleAppVersion(cluster, r.Client, ctx)
+	if apierrors.IsNotFound(err) {
+		return r.reconcileObservabilityBundleNotFound(ctx, cluster)
+	} else if err != nil {
+		logger.Error(err, "failed to get the observability-bundle version")
+		return r.reconcileFailed(ctx, cluster, err)
+	}
+	err = r.setObservabilityBundleNotFoundAttempts(ctx, cluster, 0)
 	if err != nil {
-		logger.Error(err, "failed to configure get observability-bundle version")
-		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+		return ctrl.Result{}, errors.WithStack(err)
 	}
 	if observabilityBundleVersion.LT(observabilityBundleVersionSupportAlloyMetrics) && monitoringAgent != commonmonitoring.MonitoringAgentPrometheus {
 		logger.Info("Monitoring agent is not supported by observability bundle, using prometheus-agent instead.", "observability-bundle-version", observabilityBundleVersion, "monitoring-agent", monitoringAgent)
+		// The event is only emitted when the cluster falls back, not on every reconciliation
+		if cluster.GetAnnotations()[monitoring.MonitoringAgentAnnotation] != commonmonitoring.MonitoringAgentPrometheus {
+			record.Warnf(cluster, "MonitoringAgentFallback", "Observability bundle %s does not support %s, using %s instead", observabilityBundleVersion, monitoringAgent, commonmonitoring.MonitoringAgentPrometheus)
+		}
 		monitoringAgent = commonmonitoring.MonitoringAgentPrometheus
 	}
 
-	// We always configure the bundle, even if monitoring is disabled for the cluster.
-	err = r.BundleConfigurationService.Configure(ctx, cluster, monitoringAgent)
+	// Refuse to configure an agent which cannot authenticate against Mimir
+	err = r.MonitoringConfig.ValidateMonitoringAgentRemoteWriteAuth(monitoringAgent)
 	if err != nil {
-		logger.Error(err, "failed to configure the observability-bundle")
-		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+		logger.Error(err, "monitoring agent does not support the remote write authentication method", "monitoring-agent", monitoringAgent)
+		return r.reconcileFailed(ctx, cluster, err)
+	}
+	setMonitoringAgentMetric(cluster, monitoringAgent)
+
+	// We always configure the bundle, even if monitoring is disabled for the cluster, unless it is configured by another tool.
+	if !r.MonitoringConfig.SkipObservabilityBundleManagement {
+		err = r.BundleConfigurationService.Configure(ctx, cluster, monitoringAgent)
+		if err != nil {
+			logger.Error(err, "failed to configure the observability-bundle")
+			return r.reconcileFailed(ctx, cluster, err)
+		}
+	} else {
+		logger.Info("observability-bundle is not managed by the operator, skipping its configuration")
 	}
 
 	// Cluster specific configuration
+	var pendingScaling sharding.PendingScaling
 	if r.MonitoringConfig.IsMonitored(cluster) {
 		switch monitoringAgent {
 		case commonmonitoring.MonitoringAgentPrometheus:
 			// Create or update PrometheusAgent remote write configuration.
-			err = r.PrometheusAgentService.ReconcileRemoteWriteConfiguration(ctx, cluster)
+			pendingScaling, err = r.PrometheusAgentService.ReconcileRemoteWriteConfiguration(ctx, cluster)
 			if err != nil {
 				logger.Error(err, "failed to create or update prometheus agent remote write config")
-				return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+				return r.reconcileFailed(ctx, cluster, err)
+			}
+
+			// clean up the alloy monitoring configuration left by a previous agent
+			err = r.AlloyService.ReconcileDelete(ctx, cluster)
+			if err != nil {
+				logger.Error(err, "failed to delete alloy monitoring config")
+				return r.reconcileFailed(ctx, cluster, err)
 			}
 		case commonmonitoring.MonitoringAgentAlloy:
 			// Create or update Alloy monitoring configuration.
-			err = r.AlloyService.ReconcileCreate(ctx, cluster)
+			pendingScaling, err = r.AlloyService.ReconcileCreate(ctx, cluster, observabilityBundleVersion)
 			if err != nil {
 				logger.Error(err, "failed to create or update alloy monitoring config")
-				return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+				return r.reconcileFailed(ctx, cluster, err)
+			}
+
+			// clean up the prometheus agent configuration left by a previous agent
+			err = r.PrometheusAgentService.DeleteRemoteWriteConfiguration(ctx, cluster)
+			if err != nil {
+				logger.Error(err, "failed to delete prometheus agent remote write config")
+				return r.reconcileFailed(ctx, cluster, err)
 			}
 		default:
 			return ctrl.Result{}, errors.Errorf("unsupported monitoring agent %q", monitoringAgent)
@@ -225,18 +356,239 @@ func (r *ClusterMonitoringReconciler) reconcile(ctx context.Context, cluster *cl
 		err := r.PrometheusAgentService.DeleteRemoteWriteConfiguration(ctx, cluster)
 		if err != nil {
 			logger.Error(err, "failed to delete prometheus agent remote write config")
-			return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+			return r.reconcileFailed(ctx, cluster, err)
 		}
 
 		// clean up any existing alloy monitoring configuration
 		err = r.AlloyService.ReconcileDelete(ctx, cluster)
 		if err != nil {
 			logger.Error(err, "failed to delete alloy monitoring config")
-			return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+			return r.reconcileFailed(ctx, cluster, err)
 		}
 	}
 
-	return ctrl.Result{}, nil
+	err = r.setPendingScaling(ctx, cluster, pendingScaling)
+	if err != nil {
+		return ctrl.Result{}, errors.WithStack(err)
+	}
+
+	err = r.setMonitoringStatus(ctx, cluster, monitoringAgent, nil)
+	if err != nil {
+		return ctrl.Result{}, errors.WithStack(err)
+	}
+
+	// Requeue the cluster to tear down its monitoring once the grace period is over.
+	return ctrl.Result{RequeueAfter: gracePeriodRemaining}, nil
+}
+
+// setMonitoringAgentMetric records the active monitoring agent of the cluster, so clusters stuck on prometheus-agent can be alerted on.
+func setMonitoringAgentMetric(cluster *clusterv1.Cluster, monitoringAgent string) {
+	for _, agent := range []string{commonmonitoring.MonitoringAgentPrometheus, commonmonitoring.MonitoringAgentAlloy} {
+		if agent == monitoringAgent {
+			metrics.ClusterMonitoringAgent.WithLabelValues(cluster.Name, cluster.Namespace, agent).Set(1)
+		} else {
+			metrics.ClusterMonitoringAgent.DeleteLabelValues(cluster.Name, cluster.Namespace, agent)
+		}
+	}
+}
+
+// trackMonitoringDisabledTime records the time the monitoring of the cluster gets disabled, from which the teardown grace period starts,
+// and clears it once the monitoring is enabled again. It returns the time left before the monitoring is torn down.
+func (r *ClusterMonitoringReconciler) trackMonitoringDisabledTime(ctx context.Context, cluster *clusterv1.Cluster) (time.Duration, error) {
+	logger := log.FromContext(ctx)
+
+	annotations := cluster.GetAnnotations()
+	_, disabled := annotations[monitoring.MonitoringDisabledTimeAnnotation]
+
+	switch {
+	case r.MonitoringConfig.IsMonitoringRequested(cluster):
+		if !disabled {
+			return 0, nil
+		}
+		logger.Info("monitoring was enabled again, cancelling its teardown")
+	case disabled:
+		return r.MonitoringConfig.UnmonitoredGracePeriodRemaining(cluster), nil
+	case r.MonitoringConfig.Enabled && r.MonitoringConfig.UnmonitoredGracePeriod > 0 && annotations[monitoring.MonitoringEnabledAnnotation] == "true":
+		logger.Info("monitoring was disabled, delaying its teardown", "gracePeriod", r.MonitoringConfig.UnmonitoredGracePeriod)
+	default:
+		return 0, nil
+	}
+
+	patchHelper, err := patch.NewHelper(cluster, r.Client)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	if disabled {
+		delete(annotations, monitoring.MonitoringDisabledTimeAnnotation)
+	} else {
+		annotations[monitoring.MonitoringDisabledTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	}
+	cluster.SetAnnotations(annotations)
+
+	if err := patchHelper.Patch(ctx, cluster); err != nil {
+		logger.Error(err, "failed to update the monitoring disabled time annotation")
+		return 0, errors.WithStack(err)
+	}
+
+	return r.MonitoringConfig.UnmonitoredGracePeriodRemaining(cluster), nil
+}
+
+// reconcileObservabilityBundleNotFound requeues clusters whose observability-bundle app is not installed yet, which is expected while they are created.
+// Clusters which never install the bundle are not requeued anymore once the configured number of attempts is reached.
+// The attempts are only counted when a maximum is configured so the clusters are not patched on every requeue otherwise.
+func (r *ClusterMonitoringReconciler) reconcileObservabilityBundleNotFound(ctx context.Context, cluster *clusterv1.Cluster) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	maxAttempts := r.MonitoringConfig.ObservabilityBundleNotFoundMaxAttempts
+	if maxAttempts > 0 {
+		// An invalid annotation only restarts the count.
+		attempts, _ := strconv.Atoi(cluster.GetAnnotations()[monitoring.ObservabilityBundleNotFoundAttemptsAnnotation])
+		attempts++
+		err := r.setObservabilityBundleNotFoundAttempts(ctx, cluster, attempts)
+		if err != nil {
+			return ctrl.Result{}, errors.WithStack(err)
+		}
+
+		if attempts >= maxAttempts {
+			logger.Info("observability-bundle app not found, not requeuing the cluster anymore", "attempts", attempts)
+			return ctrl.Result{}, nil
+		}
+	}
+
+	logger.V(1).Info("observability-bundle app not found yet, requeuing the cluster", "requeueAfter", r.MonitoringConfig.ObservabilityBundleNotFoundRequeueAfter)
+	return ctrl.Result{RequeueAfter: r.MonitoringConfig.ObservabilityBundleNotFoundRequeueAfter}, nil
+}
+
+// setObservabilityBundleNotFoundAttempts records the number of consecutive reconciliations which did not find the observability-bundle app of the cluster.
+// The annotation is removed when it is 0.
+func (r *ClusterMonitoringReconciler) setObservabilityBundleNotFoundAttempts(ctx context.Context, cluster *clusterv1.Cluster, attempts int) error {
+	logger := log.FromContext(ctx)
+
+	annotations := cluster.GetAnnotations()
+	if _, ok := annotations[monitoring.ObservabilityBundleNotFoundAttemptsAnnotation]; !ok && attempts == 0 {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(cluster, r.Client)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if attempts == 0 {
+		delete(annotations, monitoring.ObservabilityBundleNotFoundAttemptsAnnotation)
+	} else {
+		annotations[monitoring.ObservabilityBundleNotFoundAttemptsAnnotation] = strconv.Itoa(attempts)
+	}
+	cluster.SetAnnotations(annotations)
+
+	if err := patchHelper.Patch(ctx, cluster); err != nil {
+		logger.Error(err, "failed to update the observability-bundle not found attempts annotation")
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// setPendingScaling records the change of the number of monitoring agent shards which is not applied yet, so the next reconciliation carries on with it.
+// The annotation is removed when no scaling is pending.
+func (r *ClusterMonitoringReconciler) setPendingScaling(ctx context.Context, cluster *clusterv1.Cluster, pending sharding.PendingScaling) error {
+	logger := log.FromContext(ctx)
+
+	annotations := cluster.GetAnnotations()
+	var value string
+	if pending.Reconciles > 0 {
+		data, err := json.Marshal(pending)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		value = string(data)
+	}
+	current, ok := annotations[commonmonitoring.PendingScalingAnnotation]
+	if (value == "" && !ok) || (value != "" && current == value) {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(cluster, r.Client)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if value == "" {
+		delete(annotations, commonmonitoring.PendingScalingAnnotation)
+	} else {
+		annotations[commonmonitoring.PendingScalingAnnotation] = value
+	}
+	cluster.SetAnnotations(annotations)
+
+	if err := patchHelper.Patch(ctx, cluster); err != nil {
+		logger.Error(err, "failed to update the pending scaling annotation")
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// reconcileFailed records the error in the cluster annotations and requeues the cluster.
+func (r *ClusterMonitoringReconciler) reconcileFailed(ctx context.Context, cluster *clusterv1.Cluster, reconcileErr error) (ctrl.Result, error) {
+	err := r.setMonitoringStatus(ctx, cluster, "", reconcileErr)
+	if err != nil {
+		return ctrl.Result{}, errors.WithStack(err)
+	}
+
+	return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+}
+
+// setMonitoringStatus records the outcome of the monitoring reconciliation in the cluster annotations.
+// On success, the monitoring agent and whether the cluster is monitored are recorded and the previous error is cleared.
+// The cluster is only patched when the agent, the error or the monitored state changes, in which case the time of the change is recorded as well.
+func (r *ClusterMonitoringReconciler) setMonitoringStatus(ctx context.Context, cluster *clusterv1.Cluster, monitoringAgent string, reconcileErr error) error {
+	logger := log.FromContext(ctx)
+
+	annotations := cluster.GetAnnotations()
+	if reconcileErr != nil {
+		if annotations[monitoring.ReconcileErrorAnnotation] == reconcileErr.Error() {
+			return nil
+		}
+	} else {
+		_, hasError := annotations[monitoring.ReconcileErrorAnnotation]
+		if !hasError &&
+			annotations[monitoring.MonitoringAgentAnnotation] == monitoringAgent &&
+			annotations[monitoring.MonitoringEnabledAnnotation] == strconv.FormatBool(r.MonitoringConfig.IsMonitored(cluster)) {
+			return nil
+		}
+	}
+
+	patchHelper, err := patch.NewHelper(cluster, r.Client)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if reconcileErr != nil {
+		annotations[monitoring.ReconcileErrorAnnotation] = reconcileErr.Error()
+	} else {
+		annotations[monitoring.LastReconcileTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		annotations[monitoring.MonitoringAgentAnnotation] = monitoringAgent
+		annotations[monitoring.MonitoringEnabledAnnotation] = strconv.FormatBool(r.MonitoringConfig.IsMonitored(cluster))
+		delete(annotations, monitoring.ReconcileErrorAnnotation)
+	}
+	cluster.SetAnnotations(annotations)
+
+	if err := patchHelper.Patch(ctx, cluster); err != nil {
+		logger.Error(err, "failed to update the monitoring status annotations")
+		return errors.WithStack(err)
+	}
+
+	return nil
 }
 
 // reconcileDelete handles cluster deletion.
@@ -244,12 +596,14 @@ func (r *ClusterMonitoringReconciler) reconcileDelete(ctx context.Context, clust
 	logger := log.FromContext(ctx)
 
 	// We do not need to delete anything if there is no finalizer on the cluster
-	if controllerutil.ContainsFinalizer(cluster, monitoring.MonitoringFinalizer) {
-		// We always remove the bundle configure, even if monitoring is disabled for the cluster.
-		err := r.BundleConfigurationService.RemoveConfiguration(ctx, cluster)
-		if err != nil {
-			logger.Error(err, "failed to remove the observability-bundle configuration")
-			return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+	if controllerutil.ContainsFinalizer(cluster, r.finalizer()) {
+		// We always remove the bundle configure, even if monitoring is disabled for the cluster, unless it is configured by another tool.
+		if !r.MonitoringConfig.SkipObservabilityBundleManagement {
+			err := r.BundleConfigurationService.RemoveConfiguration(ctx, cluster)
+			if err != nil {
+				logger.Error(err, "failed to remove the observability-bundle configuration")
+				return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+			}
 		}
 
 		// Cluster specific configuration
@@ -270,9 +624,11 @@ func (r *ClusterMonitoringReconciler) reconcileDelete(ctx context.Context, clust
 			}
 		}
 
+		metrics.ClusterMonitoringAgent.DeletePartialMatch(prometheus.Labels{"cluster": cluster.Name, "namespace": cluster.Namespace})
+
 		// We get the latest state of the object to avoid race conditions.
 		// Finalizer handling needs to come last.
-		err = r.removeFinalizer(ctx, cluster)
+		err := r.removeFinalizer(ctx, cluster)
 		if err != nil {
 			return ctrl.Result{}, errors.WithStack(err)
 		}
@@ -281,22 +637,30 @@ func (r *ClusterMonitoringReconciler) reconcileDelete(ctx context.Context, clust
 	return ctrl.Result{}, nil
 }
 
+// finalizer returns the finalizer added to the clusters.
+func (r *ClusterMonitoringReconciler) finalizer() string {
+	if r.Finalizer == "" {
+		return monitoring.MonitoringFinalizer
+	}
+	return r.Finalizer
+}
+
 func (r *ClusterMonitoringReconciler) addFinalizer(ctx context.Context, cluster *clusterv1.Cluster) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
 	// We use a patch rather than an update to avoid conflicts when multiple controllers are adding their finalizer to the ClusterCR
 	// We use the patch from sigs.k8s.io/cluster-api/util/patch to handle the patching without conflicts
-	logger.Info("adding finalizer", "finalizer", monitoring.MonitoringFinalizer)
+	logger.Info("adding finalizer", "finalizer", r.finalizer())
 	patchHelper, err := patch.NewHelper(cluster, r.Client)
 	if err != nil {
 		return ctrl.Result{}, errors.WithStack(err)
 	}
-	controllerutil.AddFinalizer(cluster, monitoring.MonitoringFinalizer)
+	controllerutil.AddFinalizer(cluster, r.finalizer())
 	if err := patchHelper.Patch(ctx, cluster); err != nil {
-		logger.Error(err, "failed to add finalizer", "finalizer", monitoring.MonitoringFinalizer)
+		logger.Error(err, "failed to add finalizer", "finalizer", r.finalizer())
 		return ctrl.Result{}, errors.WithStack(err)
 	}
-	logger.Info("added finalizer", "finalizer", monitoring.MonitoringFinalizer)
+	logger.Info("added finalizer", "finalizer", r.finalizer())
 	return ctrl.Result{}, nil
 }
 
@@ -305,22 +669,22 @@ func (r *ClusterMonitoringReconciler) removeFinalizer(ctx context.Context, clust
 
 	// We use a patch rather than an update to avoid conflicts when multiple controllers are removing their finalizer from the ClusterCR
 	// We use the patch from sigs.k8s.io/cluster-api/util/patch to handle the patching without conflicts
-	logger.Info("removing finalizer", "finalizer", monitoring.MonitoringFinalizer)
+	logger.Info("removing finalizer", "finalizer", r.finalizer())
 	patchHelper, err := patch.NewHelper(cluster, r.Client)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	controllerutil.RemoveFinalizer(cluster, monitoring.MonitoringFinalizer)
+	controllerutil.RemoveFinalizer(cluster, r.finalizer())
 	if err := patchHelper.Patch(ctx, cluster); err != nil {
-		logger.Error(err, "failed to remove finalizer, requeuing", "finalizer", monitoring.MonitoringFinalizer)
+		logger.Error(err, "failed to remove finalizer, requeuing", "finalizer", r.finalizer())
 		return errors.WithStack(err)
 	}
-	logger.Info("removed finalizer", "finalizer", monitoring.MonitoringFinalizer)
+	logger.Info("removed finalizer", "finalizer", r.finalizer())
 	return nil
 }
 
-func (r *ClusterMonitoringReconciler) reconcileManagementCluster(ctx context.Context) *ctrl.Result {
+func (r *ClusterMonitoringReconciler) reconcileManagementCluster(ctx context.Context) error {
 	logger := log.FromContext(ctx)
 
 	// If monitoring is enabled as the installation level, configure the monitoring stack, otherwise, tear it down.
@@ -328,19 +692,56 @@ func (r *ClusterMonitoringReconciler) reconcileManagementCluster(ctx context.Con
 		err := r.HeartbeatRepository.CreateOrUpdate(ctx)
 		if err != nil {
 			logger.Error(err, "failed to create or update heartbeat")
-			return &ctrl.Result{RequeueAfter: 5 * time.Minute}
+			return errors.WithStack(err)
 		}
 
-		err = r.MimirService.ConfigureMimir(ctx)
+		rotated, err := r.MimirService.ConfigureMimir(ctx)
 		if err != nil {
 			logger.Error(err, "failed to configure mimir")
-			return &ctrl.Result{RequeueAfter: 5 * time.Minute}
+			return errors.WithStack(err)
+		}
+
+		// The remote write secrets of all clusters are updated right away, the previous password is only accepted for the rotation grace period.
+		if rotated {
+			err = r.enqueueClusters(ctx)
+			if err != nil {
+				logger.Error(err, "failed to enqueue the clusters after the mimir password rotation")
+				return errors.WithStack(err)
+			}
 		}
 	} else {
 		err := r.tearDown(ctx)
 		if err != nil {
 			logger.Error(err, "failed to tear down the monitoring stack")
-			return &ctrl.Result{RequeueAfter: 5 * time.Minute}
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// enqueueClusters enqueues all the clusters selected by the controller.
+func (r *ClusterMonitoringReconciler) enqueueClusters(ctx context.Context) error {
+	if r.clusterEvents == nil {
+		return nil
+	}
+
+	clusterList := &clusterv1.ClusterList{}
+	err := r.Client.List(ctx, clusterList)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for i := range clusterList.Items {
+		cluster := &clusterList.Items[i]
+		if r.ClusterLabelSelector != nil && !r.ClusterLabelSelector.Matches(labels.Set(cluster.GetLabels())) {
+			continue
+		}
+
+		select {
+		case r.clusterEvents <- event.GenericEvent{Object: cluster}:
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
 		}
 	}
 
@@ -1,7 +1,37 @@
 package controller
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	appv1 "github.com/giantswarm/apiextensions-application/api/v1alpha1"
 	. "github.com/onsi/ginkgo/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	kuberecord "k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/giantswarm/observability-operator/pkg/bundle"
+	"github.com/giantswarm/observability-operator/pkg/common"
+	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
+	"github.com/giantswarm/observability-operator/pkg/common/organization"
+	"github.com/giantswarm/observability-operator/pkg/metrics"
+	"github.com/giantswarm/observability-operator/pkg/monitoring"
+	"github.com/giantswarm/observability-operator/pkg/monitoring/alloy"
+	"github.com/giantswarm/observability-operator/pkg/monitoring/prometheusagent"
+	"github.com/giantswarm/observability-operator/pkg/monitoring/prometheusagent/sharding"
 )
 
 var _ = Describe("Cluster Controller", func() {
@@ -14,3 +44,840 @@ var _ = Describe("Cluster Controller", func() {
 		})
 	})
 })
+
+// newClusterMonitoringReconciler returns a reconciler of the clusters of the management installation whose services use the given client.
+// The clusters belong to the test organization when they are in the org-test namespace.
+func newClusterMonitoringReconciler(k8sClient client.Client, monitoringConfig monitoring.Config) ClusterMonitoringReconciler {
+	managementCluster := common.ManagementCluster{Name: "management"}
+	return ClusterMonitoringReconciler{
+		Client:                 k8sClient,
+		ManagementCluster:      managementCluster,
+		PrometheusAgentService: prometheusagent.PrometheusAgentService{Client: k8sClient, APIReader: k8sClient},
+		AlloyService: alloy.Service{
+			Client:                 k8sClient,
+			APIReader:              k8sClient,
+			OrganizationRepository: organization.NewOverrideRepository(map[string]string{"org-test": "test"}, nil),
+			ManagementCluster:      managementCluster,
+			MonitoringConfig:       monitoringConfig,
+		},
+		BundleConfigurationService: bundle.NewBundleConfigurationService(k8sClient, monitoringConfig),
+		MonitoringConfig:           monitoringConfig,
+	}
+}
+
+func TestReconcileMonitoringStatusAnnotations(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Namespace:  "org-test",
+			Finalizers: []string{monitoring.MonitoringFinalizer},
+			Annotations: map[string]string{
+				monitoring.ReconcileErrorAnnotation: "previous error",
+			},
+		},
+	}
+	bundleApp := &appv1.App{
+		ObjectMeta: commonmonitoring.ObservabilityBundleAppMeta(cluster),
+		Spec:       appv1.AppSpec{Version: "1.7.0"},
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, bundleApp).Build()
+	monitoringConfig := monitoring.Config{MonitoringAgent: commonmonitoring.MonitoringAgentAlloy}
+	r := newClusterMonitoringReconciler(k8sClient, monitoringConfig)
+
+	start := time.Now().Add(-time.Second)
+	if _, err := r.reconcile(context.Background(), cluster); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	current := &clusterv1.Cluster{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	annotations := current.GetAnnotations()
+
+	if annotations[monitoring.MonitoringAgentAnnotation] != commonmonitoring.MonitoringAgentAlloy {
+		t.Errorf("expected monitoring agent %q, got %q", commonmonitoring.MonitoringAgentAlloy, annotations[monitoring.MonitoringAgentAnnotation])
+	}
+
+	lastReconcileTime, err := time.Parse(time.RFC3339, annotations[monitoring.LastReconcileTimeAnnotation])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lastReconcileTime.Before(start.Truncate(time.Second)) {
+		t.Errorf("expected the last reconcile time to be recent, got %s", lastReconcileTime)
+	}
+
+	if _, ok := annotations[monitoring.ReconcileErrorAnnotation]; ok {
+		t.Errorf("expected the error annotation to be removed, got %q", annotations[monitoring.ReconcileErrorAnnotation])
+	}
+
+	// The cluster is not patched again while the monitoring status does not change
+	if _, err := r.reconcile(context.Background(), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reconciled := &clusterv1.Cluster{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), reconciled); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reconciled.ResourceVersion != current.ResourceVersion {
+		t.Errorf("expected the cluster not to be patched, resource version changed from %s to %s", current.ResourceVersion, reconciled.ResourceVersion)
+	}
+}
+
+func TestReconcileSkipObservabilityBundleManagement(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	for _, skipObservabilityBundleManagement := range []bool{false, true} {
+		t.Run(fmt.Sprintf("skip observability bundle management %t", skipObservabilityBundleManagement), func(t *testing.T) {
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test",
+					Namespace:  "org-test",
+					Finalizers: []string{monitoring.MonitoringFinalizer},
+				},
+			}
+			bundleApp := &appv1.App{
+				ObjectMeta: commonmonitoring.ObservabilityBundleAppMeta(cluster),
+				Spec:       appv1.AppSpec{Version: "1.7.0"},
+			}
+
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, bundleApp).Build()
+			monitoringConfig := monitoring.Config{MonitoringAgent: commonmonitoring.MonitoringAgentAlloy, SkipObservabilityBundleManagement: skipObservabilityBundleManagement}
+			r := newClusterMonitoringReconciler(k8sClient, monitoringConfig)
+
+			if _, err := r.reconcile(context.Background(), cluster); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// The bundle configuration is written to a configmap referenced by the bundle app
+			configMap := &v1.ConfigMap{}
+			err := k8sClient.Get(context.Background(), types.NamespacedName{Name: "test-observability-platform-configuration", Namespace: "org-test"}, configMap)
+			if !skipObservabilityBundleManagement && err != nil {
+				t.Errorf("expected the observability-bundle to be configured, got %v", err)
+			}
+			if skipObservabilityBundleManagement && !apierrors.IsNotFound(err) {
+				t.Errorf("expected the observability-bundle not to be configured, got %v", err)
+			}
+
+			current := &appv1.App{}
+			if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(bundleApp), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if configured := len(current.Spec.ExtraConfigs) > 0; configured == skipObservabilityBundleManagement {
+				t.Errorf("expected the observability-bundle app configured %t, got extra configs %v", !skipObservabilityBundleManagement, current.Spec.ExtraConfigs)
+			}
+		})
+	}
+}
+
+func TestReconcileMonitoringAgentOverride(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name                       string
+		monitoringAgent            string
+		override                   string
+		remoteWriteAuthMethod      string
+		observabilityBundleVersion string
+		expectedMonitoringAgent    string
+		expectedError              bool
+	}{
+		{
+			name:                       "no override",
+			monitoringAgent:            commonmonitoring.MonitoringAgentAlloy,
+			observabilityBundleVersion: "1.7.0",
+			expectedMonitoringAgent:    commonmonitoring.MonitoringAgentAlloy,
+		},
+		{
+			name:                       "cluster pinned to prometheus-agent",
+			monitoringAgent:            commonmonitoring.MonitoringAgentAlloy,
+			override:                   commonmonitoring.MonitoringAgentPrometheus,
+			observabilityBundleVersion: "1.7.0",
+			expectedMonitoringAgent:    commonmonitoring.MonitoringAgentPrometheus,
+		},
+		{
+			name:                       "cluster pinned to alloy",
+			monitoringAgent:            commonmonitoring.MonitoringAgentPrometheus,
+			override:                   commonmonitoring.MonitoringAgentAlloy,
+			observabilityBundleVersion: "1.7.0",
+			expectedMonitoringAgent:    commonmonitoring.MonitoringAgentAlloy,
+		},
+		{
+			name:                       "cluster pinned to alloy with an observability-bundle not supporting it",
+			monitoringAgent:            commonmonitoring.MonitoringAgentPrometheus,
+			override:                   commonmonitoring.MonitoringAgentAlloy,
+			observabilityBundleVersion: "1.5.0",
+			expectedMonitoringAgent:    commonmonitoring.MonitoringAgentPrometheus,
+		},
+		{
+			name:                       "cluster pinned to prometheus-agent with the tls remote write authentication",
+			monitoringAgent:            commonmonitoring.MonitoringAgentAlloy,
+			override:                   commonmonitoring.MonitoringAgentPrometheus,
+			remoteWriteAuthMethod:      monitoring.RemoteWriteAuthMethodTLS,
+			observabilityBundleVersion: "1.7.0",
+			expectedError:              true,
+		},
+		{
+			name:                       "cluster falling back to prometheus-agent with the tls remote write authentication",
+			monitoringAgent:            commonmonitoring.MonitoringAgentAlloy,
+			remoteWriteAuthMethod:      monitoring.RemoteWriteAuthMethodTLS,
+			observabilityBundleVersion: "1.5.0",
+			expectedError:              true,
+		},
+		{
+			name:                       "unsupported monitoring agent",
+			monitoringAgent:            commonmonitoring.MonitoringAgentAlloy,
+			override:                   "otel",
+			observabilityBundleVersion: "1.7.0",
+			expectedError:              true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test",
+					Namespace:  "org-test",
+					Finalizers: []string{monitoring.MonitoringFinalizer},
+				},
+			}
+			if tt.override != "" {
+				cluster.SetAnnotations(map[string]string{commonmonitoring.MonitoringAgentOverrideAnnotation: tt.override})
+			}
+			bundleApp := &appv1.App{
+				ObjectMeta: commonmonitoring.ObservabilityBundleAppMeta(cluster),
+				Spec:       appv1.AppSpec{Version: tt.observabilityBundleVersion},
+			}
+
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, bundleApp).Build()
+			monitoringConfig := monitoring.Config{MonitoringAgent: tt.monitoringAgent, RemoteWriteAuthMethod: tt.remoteWriteAuthMethod}
+			r := newClusterMonitoringReconciler(k8sClient, monitoringConfig)
+
+			if _, err := r.reconcile(context.Background(), cluster); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			current := &clusterv1.Cluster{}
+			if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			annotations := current.GetAnnotations()
+
+			// Reconciliation errors are recorded on the cluster rather than returned
+			if tt.expectedError {
+				if annotations[monitoring.ReconcileErrorAnnotation] == "" {
+					t.Errorf("expected the reconciliation error to be recorded")
+				}
+				return
+			}
+			if annotations[monitoring.MonitoringAgentAnnotation] != tt.expectedMonitoringAgent {
+				t.Errorf("expected monitoring agent %q, got %q", tt.expectedMonitoringAgent, annotations[monitoring.MonitoringAgentAnnotation])
+			}
+		})
+	}
+}
+
+func TestReconcileMonitoringAgentFallback(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	recorder := newEventRecorder()
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "fallback",
+			Namespace:  "org-test",
+			Finalizers: []string{monitoring.MonitoringFinalizer},
+		},
+	}
+	bundleApp := &appv1.App{
+		ObjectMeta: commonmonitoring.ObservabilityBundleAppMeta(cluster),
+		Spec:       appv1.AppSpec{Version: "1.5.0"},
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, bundleApp).Build()
+	monitoringConfig := monitoring.Config{MonitoringAgent: commonmonitoring.MonitoringAgentAlloy}
+	r := newClusterMonitoringReconciler(k8sClient, monitoringConfig)
+
+	// The cluster previously ran alloy
+	metrics.ClusterMonitoringAgent.WithLabelValues(cluster.Name, cluster.Namespace, commonmonitoring.MonitoringAgentAlloy).Set(1)
+
+	if _, err := r.reconcile(context.Background(), cluster); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := testutil.ToFloat64(metrics.ClusterMonitoringAgent.WithLabelValues(cluster.Name, cluster.Namespace, commonmonitoring.MonitoringAgentPrometheus)); got != 1 {
+		t.Errorf("expected the prometheus-agent to be the active agent, got %v", got)
+	}
+	if metrics.ClusterMonitoringAgent.DeleteLabelValues(cluster.Name, cluster.Namespace, commonmonitoring.MonitoringAgentAlloy) {
+		t.Errorf("expected the alloy agent to not be reported anymore")
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, "MonitoringAgentFallback") {
+			t.Errorf("expected a fallback event, got %q", event)
+		}
+	default:
+		t.Errorf("expected a fallback event")
+	}
+
+	// The event is not emitted again while the cluster keeps running prometheus-agent
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), cluster); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.reconcile(context.Background(), cluster); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case event := <-recorder.Events:
+		t.Errorf("expected no event, got %q", event)
+	default:
+	}
+}
+
+func TestReconcileMonitoringAgentSwitch(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Namespace:   "org-test",
+			Finalizers:  []string{monitoring.MonitoringFinalizer},
+			Annotations: map[string]string{commonmonitoring.MonitoringAgentOverrideAnnotation: commonmonitoring.MonitoringAgentAlloy},
+		},
+		Spec: clusterv1.ClusterSpec{
+			InfrastructureRef: &v1.ObjectReference{Kind: common.AWSClusterKind},
+		},
+	}
+	bundleApp := &appv1.App{
+		ObjectMeta: commonmonitoring.ObservabilityBundleAppMeta(cluster),
+		Spec:       appv1.AppSpec{Version: "1.7.0"},
+	}
+	mimirAuthSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mimir-basic-auth", Namespace: "mimir"},
+		Data:       map[string][]byte{"credentials": []byte("password")},
+	}
+	// The cluster was previously monitored by prometheus-agent
+	prometheusAgentConfigMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: prometheusagent.GetPrometheusAgentRemoteWriteConfigName(cluster), Namespace: cluster.Namespace},
+	}
+	prometheusAgentSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: prometheusagent.GetPrometheusAgentRemoteWriteSecretName(cluster), Namespace: cluster.Namespace},
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, bundleApp, mimirAuthSecret, prometheusAgentConfigMap, prometheusAgentSecret).Build()
+	monitoringConfig := monitoring.Config{
+		Enabled:             true,
+		MonitoringAgent:     commonmonitoring.MonitoringAgentPrometheus,
+		MimirNamespace:      "mimir",
+		MimirAuthSecretName: "mimir-basic-auth",
+	}
+	r := newClusterMonitoringReconciler(k8sClient, monitoringConfig)
+
+	if _, err := r.reconcile(context.Background(), cluster); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(alloy.ConfigMap(cluster)), &v1.ConfigMap{}); err != nil {
+		t.Errorf("expected the alloy configuration to be created, got %v", err)
+	}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(prometheusAgentConfigMap), &v1.ConfigMap{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the prometheus agent configmap to be deleted, got %v", err)
+	}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(prometheusAgentSecret), &v1.Secret{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the prometheus agent secret to be deleted, got %v", err)
+	}
+}
+
+var (
+	eventRecorderOnce sync.Once
+	eventRecorder     *kuberecord.FakeRecorder
+)
+
+// newEventRecorder returns the recorder of the events emitted with the cluster-api record package, without the events of the previous tests.
+// The record package can only be initialized once so all the tests share the same recorder.
+func newEventRecorder() *kuberecord.FakeRecorder {
+	eventRecorderOnce.Do(func() {
+		eventRecorder = kuberecord.NewFakeRecorder(100)
+		record.InitFromRecorder(eventRecorder)
+	})
+
+	for {
+		select {
+		case <-eventRecorder.Events:
+		default:
+			return eventRecorder
+		}
+	}
+}
+
+func TestReconcileUnmonitoredGracePeriod(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name                 string
+		gracePeriod          time.Duration
+		annotations          map[string]string
+		expectedMonitored    bool
+		expectedDisabledTime bool
+		expectedRequeue      bool
+	}{
+		{
+			name:        "teardown without grace period",
+			annotations: map[string]string{monitoring.MonitoringEnabledAnnotation: "true"},
+		},
+		{
+			name:                 "teardown delayed when monitoring gets disabled",
+			gracePeriod:          time.Hour,
+			annotations:          map[string]string{monitoring.MonitoringEnabledAnnotation: "true"},
+			expectedMonitored:    true,
+			expectedDisabledTime: true,
+			expectedRequeue:      true,
+		},
+		{
+			name:        "teardown delayed while within the grace period",
+			gracePeriod: time.Hour,
+			annotations: map[string]string{
+				monitoring.MonitoringEnabledAnnotation:      "true",
+				monitoring.MonitoringDisabledTimeAnnotation: time.Now().Add(-30 * time.Minute).UTC().Format(time.RFC3339),
+			},
+			expectedMonitored:    true,
+			expectedDisabledTime: true,
+			expectedRequeue:      true,
+		},
+		{
+			name:        "teardown once the grace period is over",
+			gracePeriod: time.Hour,
+			annotations: map[string]string{
+				monitoring.MonitoringEnabledAnnotation:      "true",
+				monitoring.MonitoringDisabledTimeAnnotation: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+			},
+			expectedDisabledTime: true,
+		},
+		{
+			name:        "teardown without grace period when monitoring was not enabled",
+			gracePeriod: time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Namespace:   "org-test",
+					Finalizers:  []string{monitoring.MonitoringFinalizer},
+					Labels:      map[string]string{monitoring.MonitoringLabel: "false"},
+					Annotations: tt.annotations,
+				},
+				Spec: clusterv1.ClusterSpec{
+					InfrastructureRef: &v1.ObjectReference{Kind: common.AWSClusterKind},
+				},
+			}
+			bundleApp := &appv1.App{
+				ObjectMeta: commonmonitoring.ObservabilityBundleAppMeta(cluster),
+				Spec:       appv1.AppSpec{Version: "1.7.0"},
+			}
+
+			mimirAuthSecret := &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "mimir-basic-auth", Namespace: "mimir"},
+				Data:       map[string][]byte{"credentials": []byte("password")},
+			}
+			// The alloy configuration of the cluster exists until the teardown
+			alloyConfigMap := alloy.ConfigMap(cluster)
+
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, bundleApp, mimirAuthSecret, alloyConfigMap).Build()
+			monitoringConfig := monitoring.Config{
+				Enabled:                true,
+				MonitoringAgent:        commonmonitoring.MonitoringAgentAlloy,
+				UnmonitoredGracePeriod: tt.gracePeriod,
+				MimirNamespace:         "mimir",
+				MimirAuthSecretName:    "mimir-basic-auth",
+			}
+			r := newClusterMonitoringReconciler(k8sClient, monitoringConfig)
+
+			result, err := r.reconcile(context.Background(), cluster)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if requeue := result.RequeueAfter > 0; requeue != tt.expectedRequeue {
+				t.Errorf("expected requeue %t, got %s", tt.expectedRequeue, result.RequeueAfter)
+			}
+			if result.RequeueAfter > tt.gracePeriod {
+				t.Errorf("expected to requeue within the grace period %s, got %s", tt.gracePeriod, result.RequeueAfter)
+			}
+
+			current := &clusterv1.Cluster{}
+			if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			annotations := current.GetAnnotations()
+			if _, ok := annotations[monitoring.MonitoringDisabledTimeAnnotation]; ok != tt.expectedDisabledTime {
+				t.Errorf("expected the disabled time annotation %t, got %v", tt.expectedDisabledTime, annotations)
+			}
+			if annotations[monitoring.MonitoringEnabledAnnotation] != fmt.Sprint(tt.expectedMonitored) {
+				t.Errorf("expected monitoring enabled %t, got %q", tt.expectedMonitored, annotations[monitoring.MonitoringEnabledAnnotation])
+			}
+
+			err = k8sClient.Get(context.Background(), client.ObjectKeyFromObject(alloyConfigMap), &v1.ConfigMap{})
+			if exists := err == nil; exists != tt.expectedMonitored {
+				t.Errorf("expected the alloy configuration to exist %t, got %v", tt.expectedMonitored, err)
+			}
+
+			// The monitoring agent stays enabled in the bundle until the teardown
+			configMap := &v1.ConfigMap{}
+			err = k8sClient.Get(context.Background(), types.NamespacedName{Name: "test-observability-platform-configuration", Namespace: "org-test"}, configMap)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if enabled := strings.Contains(configMap.Data["values"], "enabled: true"); enabled != tt.expectedMonitored {
+				t.Errorf("expected the monitoring agent enabled %t in the bundle, got:\n%s", tt.expectedMonitored, configMap.Data["values"])
+			}
+		})
+	}
+}
+
+func TestTrackMonitoringDisabledTimeReenabled(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "org-test",
+			Annotations: map[string]string{
+				monitoring.MonitoringEnabledAnnotation:      "true",
+				monitoring.MonitoringDisabledTimeAnnotation: time.Now().Add(-30 * time.Minute).UTC().Format(time.RFC3339),
+			},
+		},
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+	r := ClusterMonitoringReconciler{
+		Client:           k8sClient,
+		MonitoringConfig: monitoring.Config{Enabled: true, UnmonitoredGracePeriod: time.Hour},
+	}
+
+	remaining, err := r.trackMonitoringDisabledTime(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if remaining != 0 {
+		t.Errorf("expected no teardown to be pending, got %s", remaining)
+	}
+
+	current := &clusterv1.Cluster{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := current.GetAnnotations()[monitoring.MonitoringDisabledTimeAnnotation]; ok {
+		t.Errorf("expected the disabled time annotation to be removed, got %v", current.GetAnnotations())
+	}
+}
+
+func TestSetPendingScaling(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "org-test",
+		},
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+	r := ClusterMonitoringReconciler{Client: k8sClient}
+
+	current := &clusterv1.Cluster{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.setPendingScaling(context.Background(), current, sharding.PendingScaling{Shards: 3, Reconciles: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"shards":3,"reconciles":1}`
+	if value := current.GetAnnotations()[commonmonitoring.PendingScalingAnnotation]; value != expected {
+		t.Errorf("expected pending scaling %s, got %q", expected, value)
+	}
+
+	if err := r.setPendingScaling(context.Background(), current, sharding.PendingScaling{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := current.GetAnnotations()[commonmonitoring.PendingScalingAnnotation]; ok {
+		t.Errorf("expected the pending scaling annotation to be removed, got %v", current.GetAnnotations())
+	}
+}
+
+func TestReconcileObservabilityBundleNotFound(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name               string
+		bundleVersion      string
+		attempts           string
+		maxAttempts        int
+		expectedResult     ctrl.Result
+		expectedAttempts   string
+		expectedReconciled bool
+		expectedError      bool
+	}{
+		{
+			name:             "bundle not found yet",
+			maxAttempts:      3,
+			expectedResult:   ctrl.Result{RequeueAfter: time.Minute},
+			expectedAttempts: "1",
+		},
+		{
+			name:           "bundle not found without attempts limit",
+			expectedResult: ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			name:             "bundle not found without attempts limit keeps previous attempts",
+			attempts:         "10",
+			expectedResult:   ctrl.Result{RequeueAfter: time.Minute},
+			expectedAttempts: "10",
+		},
+		{
+			name:             "bundle not found after the maximum attempts",
+			attempts:         "2",
+			maxAttempts:      3,
+			expectedResult:   ctrl.Result{},
+			expectedAttempts: "3",
+		},
+		{
+			name:           "bundle version cannot be read",
+			bundleVersion:  "not-a-version",
+			expectedResult: ctrl.Result{RequeueAfter: 5 * time.Minute},
+			expectedError:  true,
+		},
+		{
+			name:               "bundle found after previous attempts",
+			bundleVersion:      "1.7.0",
+			attempts:           "2",
+			expectedResult:     ctrl.Result{},
+			expectedReconciled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Namespace:   "org-test",
+					Finalizers:  []string{monitoring.MonitoringFinalizer},
+					Annotations: map[string]string{},
+				},
+			}
+			if tt.attempts != "" {
+				cluster.Annotations[monitoring.ObservabilityBundleNotFoundAttemptsAnnotation] = tt.attempts
+			}
+			objects := []client.Object{cluster}
+			if tt.bundleVersion != "" {
+				objects = append(objects, &appv1.App{
+					ObjectMeta: commonmonitoring.ObservabilityBundleAppMeta(cluster),
+					Spec:       appv1.AppSpec{Version: tt.bundleVersion},
+				})
+			}
+
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+			monitoringConfig := monitoring.Config{
+				MonitoringAgent:                         commonmonitoring.MonitoringAgentAlloy,
+				ObservabilityBundleNotFoundRequeueAfter: time.Minute,
+				ObservabilityBundleNotFoundMaxAttempts:  tt.maxAttempts,
+			}
+			r := newClusterMonitoringReconciler(k8sClient, monitoringConfig)
+
+			result, err := r.reconcile(context.Background(), cluster)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expectedResult {
+				t.Errorf("expected result %+v, got %+v", tt.expectedResult, result)
+			}
+
+			current := &clusterv1.Cluster{}
+			if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			annotations := current.GetAnnotations()
+
+			if got := annotations[monitoring.ObservabilityBundleNotFoundAttemptsAnnotation]; got != tt.expectedAttempts {
+				t.Errorf("expected attempts annotation %q, got %q", tt.expectedAttempts, got)
+			}
+			if _, ok := annotations[monitoring.ReconcileErrorAnnotation]; ok != tt.expectedError {
+				t.Errorf("expected error annotation %t, got %v", tt.expectedError, annotations)
+			}
+			if _, ok := annotations[monitoring.LastReconcileTimeAnnotation]; ok != tt.expectedReconciled {
+				t.Errorf("expected the cluster to be reconciled %t, got %v", tt.expectedReconciled, annotations)
+			}
+		})
+	}
+}
+
+func TestReconcilePaused(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name              string
+		finalizers        []string
+		deletionTimestamp *metav1.Time
+	}{
+		{
+			name: "new cluster gets no finalizer",
+		},
+		{
+			name:              "deleted cluster keeps its finalizer",
+			finalizers:        []string{monitoring.MonitoringFinalizer},
+			deletionTimestamp: &metav1.Time{Time: time.Now()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test",
+					Namespace:         "org-test",
+					Annotations:       map[string]string{pausedAnnotation: "true"},
+					Finalizers:        tt.finalizers,
+					DeletionTimestamp: tt.deletionTimestamp,
+				},
+			}
+
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+			monitoringConfig := monitoring.Config{Enabled: true, MonitoringAgent: commonmonitoring.MonitoringAgentAlloy}
+			r := newClusterMonitoringReconciler(k8sClient, monitoringConfig)
+
+			if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cluster)}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			current := &clusterv1.Cluster{}
+			if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(current.Finalizers) != len(tt.finalizers) {
+				t.Errorf("expected finalizers %v, got %v", tt.finalizers, current.Finalizers)
+			}
+			if _, ok := current.GetAnnotations()[monitoring.LastReconcileTimeAnnotation]; ok {
+				t.Errorf("expected the cluster to be left untouched, got annotations %v", current.GetAnnotations())
+			}
+		})
+	}
+}
+
+// fakeHeartbeatRepository records the calls made to the heartbeat of the management cluster.
+type fakeHeartbeatRepository struct {
+	createErr error
+	created   int
+	deleted   int
+}
+
+func (f *fakeHeartbeatRepository) CreateOrUpdate(ctx context.Context) error {
+	f.created++
+	return f.createErr
+}
+
+func (f *fakeHeartbeatRepository) Delete(ctx context.Context) error {
+	f.deleted++
+	return nil
+}
+
+func TestReconcileManagementClusterNotMatchingSelector(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "management",
+			Namespace:  "org-giantswarm",
+			Labels:     map[string]string{"observability.giantswarm.io/managed": "false"},
+			Finalizers: []string{monitoring.MonitoringFinalizer},
+		},
+	}
+
+	selector, err := labels.Parse("observability.giantswarm.io/managed=true")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+	monitoringConfig := monitoring.Config{Enabled: true, MonitoringAgent: commonmonitoring.MonitoringAgentAlloy}
+	r := newClusterMonitoringReconciler(k8sClient, monitoringConfig)
+	r.ClusterLabelSelector = selector
+	// The heartbeat fails so the reconciliation stops before configuring mimir.
+	heartbeatRepository := &fakeHeartbeatRepository{createErr: fmt.Errorf("heartbeat unavailable")}
+	r.HeartbeatRepository = heartbeatRepository
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cluster)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The management cluster is reconciled even though it does not match the selector and its monitoring is never torn down.
+	if heartbeatRepository.created != 1 {
+		t.Errorf("expected the heartbeat to be configured once, got %d", heartbeatRepository.created)
+	}
+	if heartbeatRepository.deleted != 0 {
+		t.Errorf("expected the heartbeat not to be deleted, got %d deletions", heartbeatRepository.deleted)
+	}
+
+	current := &clusterv1.Cluster{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(current.Finalizers) != 1 {
+		t.Errorf("expected the finalizer to be kept, got %v", current.Finalizers)
+	}
+}
+
+func TestReconcileClusterNotMatchingSelector(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Namespace:  "org-test",
+			Labels:     map[string]string{"observability.giantswarm.io/managed": "false"},
+			Finalizers: []string{monitoring.MonitoringFinalizer},
+		},
+	}
+	bundleApp := &appv1.App{
+		ObjectMeta: commonmonitoring.ObservabilityBundleAppMeta(cluster),
+		Spec:       appv1.AppSpec{Version: "1.7.0"},
+	}
+
+	selector, err := labels.Parse("observability.giantswarm.io/managed=true")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, bundleApp).Build()
+	monitoringConfig := monitoring.Config{Enabled: true, MonitoringAgent: commonmonitoring.MonitoringAgentAlloy}
+	r := newClusterMonitoringReconciler(k8sClient, monitoringConfig)
+	r.ClusterLabelSelector = selector
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cluster)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The cluster left the selector, so its monitoring is removed along with the finalizer.
+	current := &clusterv1.Cluster{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(current.Finalizers) != 0 {
+		t.Errorf("expected the finalizer to be removed, got %v", current.Finalizers)
+	}
+	if _, ok := current.GetAnnotations()[monitoring.LastReconcileTimeAnnotation]; ok {
+		t.Errorf("expected the cluster not to be reconciled, got annotations %v", current.GetAnnotations())
+	}
+}
@@ -1,9 +1,17 @@
 package controller
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
 
 	grafanaAPI "github.com/grafana/grafana-openapi-client-go/client"
 	"github.com/pkg/errors"
@@ -11,10 +19,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -22,6 +32,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/giantswarm/observability-operator/api/v1alpha1"
 	"github.com/giantswarm/observability-operator/pkg/config"
 	"github.com/giantswarm/observability-operator/pkg/grafana"
 	grafanaclient "github.com/giantswarm/observability-operator/pkg/grafana/client"
@@ -34,6 +45,28 @@ type DashboardReconciler struct {
 	client.Client
 	Scheme     *runtime.Scheme
 	GrafanaAPI *grafanaAPI.GrafanaHTTPAPI
+
+	// DashboardPermissionsEnabled enables the configuration of dashboard permissions based on the organization RBAC.
+	DashboardPermissionsEnabled bool
+	// DashboardMaxSize is the maximum size in bytes of a dashboard pushed to Grafana. The size is not limited when it is 0.
+	DashboardMaxSize int
+	// DashboardKeySuffix is the suffix of the configmap keys holding dashboards, the other keys are ignored. All keys hold dashboards when it is empty.
+	DashboardKeySuffix string
+	// DashboardDefaultRefresh is the refresh interval set on dashboards which do not define one.
+	DashboardDefaultRefresh string
+	// DashboardDefaultTimeFrom is the start of the time range set on dashboards which do not define one.
+	DashboardDefaultTimeFrom string
+	// DashboardAllowedOrganizations maps namespaces to the organizations their dashboards may be pushed to.
+	// Dashboards are not restricted when it is empty, otherwise dashboards from unlisted namespaces are rejected.
+	DashboardAllowedOrganizations map[string][]string
+	// ManagedOrganizations are the display names of the organizations the operator is allowed to manage. All organizations are managed when it is empty.
+	ManagedOrganizations []string
+	// DashboardTenantVariable is the name of the template variable populated with the tenant IDs of the organization. Variables are left unchanged when it is empty.
+	DashboardTenantVariable string
+	// MaxConcurrentReconciles is the maximum number of dashboard configmaps reconciled concurrently.
+	MaxConcurrentReconciles int
+	// Finalizer is the finalizer added to the dashboard configmaps, so several instances of the operator can manage the same configmaps. Defaults to DashboardFinalizer.
+	Finalizer string
 }
 
 const (
@@ -41,6 +74,15 @@ const (
 	DashboardSelectorLabelName  = "app.giantswarm.io/kind"
 	DashboardSelectorLabelValue = "dashboard"
 	grafanaOrganizationLabel    = "observability.giantswarm.io/organization"
+	// grafanaOrganizationIDAnnotation targets the organization by its Grafana ID, for organizations whose name is awkward in annotations.
+	// It takes precedence over the organization annotation or label.
+	grafanaOrganizationIDAnnotation = "observability.giantswarm.io/organization-id"
+
+	// skipSyncAnnotation excludes a dashboard configmap from the synchronization with Grafana when set to "true".
+	skipSyncAnnotation = "observability.giantswarm.io/skip-sync"
+
+	// syncedDashboardsAnnotation records the hash of the content and the Grafana version of the dashboards of the configmap which were successfully pushed to Grafana.
+	syncedDashboardsAnnotation = "observability.giantswarm.io/synced-dashboards"
 )
 
 func SetupDashboardReconciler(mgr manager.Manager, conf config.Config) error {
@@ -50,9 +92,19 @@ func SetupDashboardReconciler(mgr manager.Manager, conf config.Config) error {
 	}
 
 	r := &DashboardReconciler{
-		Client:     mgr.GetClient(),
-		Scheme:     mgr.GetScheme(),
-		GrafanaAPI: grafanaAPI,
+		Client:                        mgr.GetClient(),
+		Scheme:                        mgr.GetScheme(),
+		GrafanaAPI:                    grafanaAPI,
+		DashboardPermissionsEnabled:   conf.DashboardPermissionsEnabled,
+		DashboardMaxSize:              conf.DashboardMaxSize,
+		DashboardKeySuffix:            conf.DashboardKeySuffix,
+		DashboardDefaultRefresh:       conf.DashboardDefaultRefresh,
+		DashboardDefaultTimeFrom:      conf.DashboardDefaultTimeFrom,
+		DashboardAllowedOrganizations: conf.DashboardAllowedOrganizations,
+		ManagedOrganizations:          conf.GrafanaManagedOrganizations,
+		DashboardTenantVariable:       conf.DashboardTenantVariable,
+		MaxConcurrentReconciles:       conf.MaxConcurrentReconciles.Dashboard,
+		Finalizer:                     conf.DashboardFinalizer,
 	}
 
 	err = r.SetupWithManager(mgr)
@@ -84,6 +136,21 @@ func (r *DashboardReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, errors.WithStack(client.IgnoreNotFound(err))
 	}
 
+	if isPaused(dashboard) {
+		logger.Info("Skipping dashboard, reconciliation is paused", "annotation", pausedAnnotation)
+		return ctrl.Result{}, nil
+	}
+
+	// Dashboards excluded from the synchronization are maintained manually in Grafana.
+	// The synced dashboards are forgotten so the configmap content is pushed again over the manual changes once the synchronization is enabled again.
+	if dashboard.GetAnnotations()[skipSyncAnnotation] == "true" {
+		logger.Info("Skipping dashboard, synchronization is disabled", "annotation", skipSyncAnnotation)
+		if err := r.clearSyncedDashboards(ctx, dashboard); err != nil {
+			return ctrl.Result{}, errors.WithStack(err)
+		}
+		return ctrl.Result{}, r.removeFinalizer(ctx, dashboard)
+	}
+
 	// Handle deleted grafana dashboards
 	if !dashboard.DeletionTimestamp.IsZero() {
 		return ctrl.Result{}, r.reconcileDelete(ctx, dashboard)
@@ -130,6 +197,43 @@ func (r *DashboardReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			}),
 			builder.WithPredicates(predicates.GrafanaPodRecreatedPredicate{}),
 		).
+		// Watch for grafana organization changes to apply their dashboard management mode and tenant variable to their dashboards
+		Watches(
+			&v1alpha1.GrafanaOrganization{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+				var logger = log.FromContext(ctx)
+				var dashboards v1.ConfigMapList
+
+				grafanaOrganization, ok := obj.(*v1alpha1.GrafanaOrganization)
+				if !ok {
+					return []reconcile.Request{}
+				}
+
+				err := mgr.GetClient().List(ctx, &dashboards, client.MatchingLabels{DashboardSelectorLabelName: DashboardSelectorLabelValue})
+				if err != nil {
+					logger.Error(err, "failed to list grafana dashboard configmaps")
+					return []reconcile.Request{}
+				}
+
+				// Reconcile the grafana dashboards of the organization
+				requests := []reconcile.Request{}
+				for _, dashboard := range dashboards.Items {
+					if !isDashboardOfOrganization(&dashboard, grafanaOrganization) {
+						continue
+					}
+					requests = append(requests, reconcile.Request{
+						NamespacedName: types.NamespacedName{
+							Name:      dashboard.Name,
+							Namespace: dashboard.Namespace,
+						},
+					})
+				}
+				return requests
+			}),
+			// The status of the organization is updated with its dashboards, only spec changes are relevant
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
@@ -140,21 +244,32 @@ func (r *DashboardReconciler) SetupWithManager(mgr ctrl.Manager) error {
 func (r DashboardReconciler) reconcileCreate(ctx context.Context, dashboard *v1.ConfigMap) (ctrl.Result, error) { // nolint:unparam
 	logger := log.FromContext(ctx)
 
+	// Configmaps whose namespace is not allowed to push to their organization are never given a finalizer,
+	// so they cannot delete the dashboards of the organization either.
+	allowed, err := r.isDashboardNamespaceAllowed(dashboard)
+	if err != nil {
+		return ctrl.Result{}, errors.WithStack(err)
+	}
+	if !allowed {
+		logger.Info("Skipping dashboard, namespace not allowed to push dashboards to the organization")
+		return ctrl.Result{}, r.removeFinalizer(ctx, dashboard)
+	}
+
 	// Add finalizer first if not set to avoid the race condition between init and delete.
-	if !controllerutil.ContainsFinalizer(dashboard, DashboardFinalizer) {
+	if !controllerutil.ContainsFinalizer(dashboard, r.finalizer()) {
 		// We use a patch rather than an update to avoid conflicts when multiple controllers are adding their finalizer to the grafana dashboard
 		// We use the patch from sigs.k8s.io/cluster-api/util/patch to handle the patching without conflicts
-		logger.Info("adding finalizer", "finalizer", DashboardFinalizer)
+		logger.Info("adding finalizer", "finalizer", r.finalizer())
 		patchHelper, err := patch.NewHelper(dashboard, r.Client)
 		if err != nil {
 			return ctrl.Result{}, errors.WithStack(err)
 		}
-		controllerutil.AddFinalizer(dashboard, DashboardFinalizer)
+		controllerutil.AddFinalizer(dashboard, r.finalizer())
 		if err := patchHelper.Patch(ctx, dashboard); err != nil {
-			logger.Error(err, "failed to add finalizer", "finalizer", DashboardFinalizer)
+			logger.Error(err, "failed to add finalizer", "finalizer", r.finalizer())
 			return ctrl.Result{}, errors.WithStack(err)
 		}
-		logger.Info("added finalizer", "finalizer", DashboardFinalizer)
+		logger.Info("added finalizer", "finalizer", r.finalizer())
 		return ctrl.Result{}, nil
 	}
 
@@ -166,10 +281,59 @@ func (r DashboardReconciler) reconcileCreate(ctx context.Context, dashboard *v1.
 	return ctrl.Result{}, nil
 }
 
-func getDashboardUID(dashboard map[string]interface{}) (string, error) {
-	UID, ok := dashboard["uid"].(string)
+var errNoDashboardUID = errors.New("dashboard UID not found in configmap")
+
+// skippedJSONValue discards a JSON value without decoding it.
+type skippedJSONValue struct{}
+
+func (*skippedJSONValue) UnmarshalJSON([]byte) error {
+	return nil
+}
+
+// decodeDashboardUID returns the UID of the dashboard JSON. It streams through the other fields instead of
+// decoding the whole dashboard, which can hold thousands of panels, into memory.
+// Only the dashboards which are skipped or deleted benefit from it, the pushed dashboards are still fully decoded.
+func decodeDashboardUID(dashboardString string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(dashboardString))
+
+	token, err := decoder.Token()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	var uid interface{}
+	switch token {
+	case json.Delim('{'):
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return "", errors.WithStack(err)
+			}
+			if key == "uid" {
+				err = decoder.Decode(&uid)
+			} else {
+				err = decoder.Decode(&skippedJSONValue{})
+			}
+			if err != nil {
+				return "", errors.WithStack(err)
+			}
+		}
+		if _, err = decoder.Token(); err != nil {
+			return "", errors.WithStack(err)
+		}
+	case nil:
+		// null decodes to an empty dashboard
+	default:
+		return "", errors.New("dashboard is not a JSON object")
+	}
+
+	// Like json.Unmarshal, reject anything after the dashboard
+	if _, err = decoder.Token(); err != io.EOF {
+		return "", errors.New("invalid character after the dashboard JSON object")
+	}
+
+	UID, ok := uid.(string)
 	if !ok {
-		return "", errors.New("dashboard UID not found in configmap")
+		return "", errors.WithStack(errNoDashboardUID)
 	}
 	return UID, nil
 }
@@ -188,117 +352,634 @@ func getOrgFromDashboardConfigmap(dashboard *v1.ConfigMap) (string, error) {
 	}
 
 	// Return an error if no label was found
-	return "", errors.New("No organization label found in configmap")
+	return "", errors.WithStack(errNoOrganization)
 }
 
-func (r DashboardReconciler) configureDashboard(ctx context.Context, dashboardCM *v1.ConfigMap) error {
+// errNoOrganization is returned when the organization of a configmap cannot be determined, so the configmap is skipped.
+var errNoOrganization = errors.New("No organization label found in configmap")
+
+// resolveDashboardOrganization returns the name of the organization of the configmap, looking it up in Grafana when the configmap targets it by ID.
+func resolveDashboardOrganization(grafanaAPI *grafanaAPI.GrafanaHTTPAPI, dashboardCM *v1.ConfigMap) (string, error) {
+	value, ok := dashboardCM.GetAnnotations()[grafanaOrganizationIDAnnotation]
+	if !ok {
+		return getOrgFromDashboardConfigmap(dashboardCM)
+	}
+
+	orgID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return "", errors.Wrapf(errNoOrganization, "invalid organization ID %q", value)
+	}
+
+	organization, err := grafana.FindOrgByID(grafanaAPI, orgID)
+	if grafana.IsNotFound(err) {
+		return "", errors.Wrapf(errNoOrganization, "organization ID %d not found", orgID)
+	} else if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return organization.Name, nil
+}
+
+// resolveConfigMapOrganization returns the organization of the configmap and a Grafana client scoped to it.
+// It returns nil when the configmap has no organization, the operator is not allowed to manage it or the namespace of the configmap
+// is not allowed to push to it, so the configmap is skipped.
+func resolveConfigMapOrganization(ctx context.Context, grafanaAPI *grafanaAPI.GrafanaHTTPAPI, managedOrganizations []string, allowedOrganizations map[string][]string, configMap *v1.ConfigMap) (*grafana.Organization, *grafanaAPI.GrafanaHTTPAPI, error) {
 	logger := log.FromContext(ctx)
 
-	dashboardOrg, err := getOrgFromDashboardConfigmap(dashboardCM)
+	configMapOrg, err := resolveDashboardOrganization(grafanaAPI, configMap)
+	if errors.Is(err, errNoOrganization) {
+		logger.Error(err, "Skipping configmap, no organization found")
+		return nil, nil, nil
+	} else if err != nil {
+		logger.Error(err, "failed to resolve the organization of the configmap")
+		return nil, nil, errors.WithStack(err)
+	}
+
+	if !grafana.IsManagedOrganization(managedOrganizations, configMapOrg) {
+		logger.Error(errors.Errorf("organization %q is not in the list of organizations managed by the operator", configMapOrg),
+			"Skipping configmap, organization not managed")
+		return nil, nil, nil
+	}
+
+	if !isOrganizationAllowed(allowedOrganizations, configMap.GetNamespace(), configMapOrg) {
+		logger.Error(errors.Errorf("namespace %q is not allowed to push to organization %q", configMap.GetNamespace(), configMapOrg),
+			"Skipping configmap, organization not allowed")
+		return nil, nil, nil
+	}
+
+	organization, err := grafana.FindOrgByName(grafanaAPI, configMapOrg)
 	if err != nil {
+		logger.Error(err, "failed to find organization", "organization", configMapOrg)
+		return nil, nil, errors.WithStack(err)
+	}
+
+	return organization, grafana.WithOrgID(grafanaAPI, organization.ID), nil
+}
+
+// isDashboardOfOrganization returns true if the configmap targets the organization, by ID or by name.
+func isDashboardOfOrganization(dashboardCM *v1.ConfigMap, grafanaOrganization *v1alpha1.GrafanaOrganization) bool {
+	if value, ok := dashboardCM.GetAnnotations()[grafanaOrganizationIDAnnotation]; ok {
+		return grafanaOrganization.Status.OrgID != 0 && value == strconv.FormatInt(grafanaOrganization.Status.OrgID, 10)
+	}
+
+	dashboardOrg, err := getOrgFromDashboardConfigmap(dashboardCM)
+	return err == nil && dashboardOrg == grafanaOrganization.Spec.DisplayName
+}
+
+// isDashboardKey returns true if the configmap key holds a dashboard.
+func (r DashboardReconciler) isDashboardKey(key string) bool {
+	return strings.HasSuffix(key, r.DashboardKeySuffix)
+}
+
+// isDashboardNamespaceAllowed returns false if the namespace of the configmap is not allowed to push dashboards to its organization.
+// Configmaps without organization are allowed, they are skipped when configuring the dashboards.
+func (r DashboardReconciler) isDashboardNamespaceAllowed(dashboardCM *v1.ConfigMap) (bool, error) {
+	if len(r.DashboardAllowedOrganizations) == 0 {
+		return true, nil
+	}
+
+	dashboardOrg, err := resolveDashboardOrganization(r.GrafanaAPI, dashboardCM)
+	if errors.Is(err, errNoOrganization) {
+		return true, nil
+	} else if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return isOrganizationAllowed(r.DashboardAllowedOrganizations, dashboardCM.GetNamespace(), dashboardOrg), nil
+}
+
+// isOrganizationAllowed returns true if configmaps from the namespace may be pushed to the organization.
+// All organizations are allowed when allowedOrganizations is empty.
+func isOrganizationAllowed(allowedOrganizations map[string][]string, namespace string, organization string) bool {
+	if len(allowedOrganizations) == 0 {
+		return true
+	}
+
+	return slices.Contains(allowedOrganizations[namespace], organization)
+}
+
+func (r DashboardReconciler) configureDashboard(ctx context.Context, dashboardCM *v1.ConfigMap) error {
+	logger := log.FromContext(ctx)
+
+	dashboardOrg, err := resolveDashboardOrganization(r.GrafanaAPI, dashboardCM)
+	if errors.Is(err, errNoOrganization) {
 		logger.Error(err, "Skipping dashboard, no organization found")
 		return nil
+	} else if err != nil {
+		logger.Error(err, "failed to resolve the organization of the dashboard")
+		return errors.WithStack(err)
 	}
 
-	// We always switch back to the shared org
-	defer func() {
-		if _, err = r.GrafanaAPI.SignedInUser.UserSetUsingOrg(grafana.SharedOrg.ID); err != nil {
-			logger.Error(err, "failed to change current org for signed in user")
-		}
-	}()
+	if !grafana.IsManagedOrganization(r.ManagedOrganizations, dashboardOrg) {
+		logger.Error(errors.Errorf("organization %q is not in the list of organizations managed by the operator", dashboardOrg),
+			"Skipping dashboard, organization not managed")
+		return nil
+	}
+
+	if !isOrganizationAllowed(r.DashboardAllowedOrganizations, dashboardCM.GetNamespace(), dashboardOrg) {
+		logger.Error(errors.Errorf("namespace %q is not allowed to push dashboards to organization %q", dashboardCM.GetNamespace(), dashboardOrg),
+			"Skipping dashboard, organization not allowed")
+		return nil
+	}
+
+	managementMode, err := r.getDashboardManagementMode(ctx, dashboardOrg)
+	if err != nil {
+		logger.Error(err, "failed to get the dashboard management mode of the organization", "organization", dashboardOrg)
+		return errors.WithStack(err)
+	}
+	if managementMode == v1alpha1.DashboardManagementModeIgnore {
+		logger.Info("Skipping dashboard, the organization ignores its dashboards", "organization", dashboardOrg)
+		return nil
+	}
 
-	// Switch context to the dashboards-defined org
 	organization, err := grafana.FindOrgByName(r.GrafanaAPI, dashboardOrg)
 	if err != nil {
 		logger.Error(err, "failed to find organization", "organization", dashboardOrg)
 		return errors.WithStack(err)
 	}
-	if _, err = r.GrafanaAPI.SignedInUser.UserSetUsingOrg(organization.ID); err != nil {
-		logger.Error(err, "failed to change current org for signed in user")
+	orgAPI := grafana.WithOrgID(r.GrafanaAPI, organization.ID)
+
+	tenantIDs, err := r.getDashboardTenantIDs(ctx, dashboardOrg)
+	if err != nil {
+		logger.Error(err, "failed to get the tenants of the organization", "organization", dashboardOrg)
 		return errors.WithStack(err)
 	}
 
-	for _, dashboardString := range dashboardCM.Data {
+	previouslySynced := getSyncedDashboards(dashboardCM)
+	synced := make(map[string]syncedDashboard, len(dashboardCM.Data))
+	appliedDashboardUIDs := make([]string, 0, len(dashboardCM.Data))
+	currentDashboardUIDs := make(map[string]bool, len(dashboardCM.Data))
+	var dashboardErrors []error
+	// A dashboard which cannot be parsed cannot be told apart from a removed one, its UID is unknown
+	decodingFailed := false
+	for _, key := range slices.Sorted(maps.Keys(dashboardCM.Data)) {
+		// Keys which do not hold dashboards, e.g. a readme, can be mixed with the dashboards
+		if !r.isDashboardKey(key) {
+			// Dashboards pushed before their key stopped matching the suffix are kept in Grafana until the configmap is deleted
+			if dashboardUID, err := decodeDashboardUID(dashboardCM.Data[key]); err == nil {
+				if previous, ok := previouslySynced[dashboardUID]; ok {
+					currentDashboardUIDs[dashboardUID] = true
+					if _, ok := synced[dashboardUID]; !ok {
+						synced[dashboardUID] = previous
+					}
+				}
+			}
+			continue
+		}
+		dashboardString := dashboardCM.Data[key]
+
+		// The UID is read first so oversized dashboards are skipped without decoding them
+		dashboardUID, err := decodeDashboardUID(dashboardString)
+		if errors.Is(err, errNoDashboardUID) {
+			logger.Error(err, "Skipping dashboard, no UID found")
+			decodingFailed = true
+			continue
+		} else if err != nil {
+			logger.Error(err, "Failed converting dashboard to json")
+			decodingFailed = true
+			continue
+		}
+		currentDashboardUIDs[dashboardUID] = true
+
+		// Oversized dashboards would be rejected by Grafana, retrying does not help until the configmap is changed.
+		if r.DashboardMaxSize > 0 && len(dashboardString) > r.DashboardMaxSize {
+			logger.Error(errors.New("dashboard is too large"), "Skipping dashboard", "Dashboard UID", dashboardUID, "size", len(dashboardString), "maxSize", r.DashboardMaxSize)
+			// Keep track of a previous version of the dashboard so it is still deleted once removed from the configmap
+			if previous, ok := previouslySynced[dashboardUID]; ok {
+				synced[dashboardUID] = previous
+			}
+			continue
+		}
+
 		var dashboard map[string]any
 		err = json.Unmarshal([]byte(dashboardString), &dashboard)
 		if err != nil {
 			logger.Error(err, "Failed converting dashboard to json")
+			if previous, ok := previouslySynced[dashboardUID]; ok {
+				synced[dashboardUID] = previous
+			}
 			continue
 		}
 
-		dashboardUID, err := getDashboardUID(dashboard)
+		r.applyDashboardDefaults(dashboard)
+		setTenantVariable(dashboard, r.DashboardTenantVariable, tenantIDs)
+
+		// The hash covers the defaults and the tenant variable so changing them pushes the dashboards again
+		content, err := json.Marshal(dashboard)
 		if err != nil {
-			logger.Error(err, "Skipping dashboard, no UID found")
+			logger.Error(err, "Failed converting dashboard to json")
 			continue
 		}
-
-		// Create or update dashboard
-		err = grafana.PublishDashboard(r.GrafanaAPI, dashboard)
+		current := syncedDashboard{Hash: hashDashboard(string(content)), Version: previouslySynced[dashboardUID].Version}
+		var upToDate bool
+		if managementMode == v1alpha1.DashboardManagementModeAdopt {
+			// Adopted dashboards are only pushed when they do not exist yet, changes made in Grafana are kept.
+			upToDate, err = grafana.DashboardExists(orgAPI, dashboardUID)
+		} else {
+			upToDate, err = r.isDashboardUpToDate(orgAPI, dashboardUID, current.Hash, previouslySynced)
+		}
 		if err != nil {
-			logger.Error(err, "Failed updating dashboard")
+			logger.Error(err, "Failed getting dashboard", "Dashboard UID", dashboardUID)
+			dashboardErrors = append(dashboardErrors, errors.Wrapf(err, "dashboard %q", dashboardUID))
 			continue
 		}
 
-		logger.Info("updated dashboard", "Dashboard UID", dashboardUID, "Dashboard Org", dashboardOrg)
+		if upToDate {
+			logger.Info("dashboard is up to date", "Dashboard UID", dashboardUID, "Dashboard Org", dashboardOrg)
+		} else {
+			// Create or update dashboard
+			current.Version, err = grafana.PublishDashboard(ctx, orgAPI, organization.ID, dashboard)
+			if err != nil {
+				logger.Error(err, "Failed updating dashboard", "Dashboard UID", dashboardUID)
+				dashboardErrors = append(dashboardErrors, errors.Wrapf(err, "dashboard %q", dashboardUID))
+				continue
+			}
+			logger.Info("updated dashboard", "Dashboard UID", dashboardUID, "Dashboard Org", dashboardOrg)
+		}
+
+		synced[dashboardUID] = current
+		appliedDashboardUIDs = append(appliedDashboardUIDs, dashboardUID)
+
+		if r.DashboardPermissionsEnabled {
+			err = r.configureDashboardPermissions(ctx, orgAPI, dashboardUID, dashboardOrg)
+			if err != nil {
+				logger.Error(err, "Failed configuring dashboard permissions", "Dashboard UID", dashboardUID)
+				dashboardErrors = append(dashboardErrors, errors.Wrapf(err, "dashboard %q permissions", dashboardUID))
+				continue
+			}
+		}
+	}
+
+	// The dashboards are only deleted once all of them are parsed, so a typo does not delete a live dashboard
+	if decodingFailed {
+		logger.Info("skipping the deletion of the dashboards removed from the configmap as some dashboards could not be parsed")
+		for dashboardUID, previous := range previouslySynced {
+			currentDashboardUIDs[dashboardUID] = true
+			if _, ok := synced[dashboardUID]; !ok {
+				synced[dashboardUID] = previous
+			}
+		}
+	}
+
+	// Delete the dashboards which were removed from the configmap since they were pushed
+	for _, dashboardUID := range slices.Sorted(maps.Keys(previouslySynced)) {
+		if currentDashboardUIDs[dashboardUID] {
+			continue
+		}
+
+		err = grafana.DeleteDashboard(ctx, orgAPI, organization.ID, dashboardUID)
+		if err != nil && !grafana.IsNotFound(err) {
+			logger.Error(err, "Failed deleting dashboard", "Dashboard UID", dashboardUID)
+			dashboardErrors = append(dashboardErrors, errors.Wrapf(err, "dashboard %q", dashboardUID))
+			// Keep track of the dashboard so its deletion is retried
+			synced[dashboardUID] = previouslySynced[dashboardUID]
+			continue
+		}
+
+		logger.Info("deleted dashboard removed from the configmap", "Dashboard UID", dashboardUID, "Dashboard Org", dashboardOrg)
+	}
+
+	err = r.updateOrganizationsDashboards(ctx, dashboardCM, dashboardOrg, appliedDashboardUIDs)
+	if err != nil {
+		logger.Error(err, "failed to update managed dashboards in the grafanaOrganization status")
+		return errors.WithStack(err)
+	}
+
+	err = r.updateSyncedDashboards(ctx, dashboardCM, synced)
+	if err != nil {
+		logger.Error(err, "failed to record the synced dashboards in the configmap")
+		return errors.WithStack(err)
+	}
+
+	// The dashboards which failed are retried while the ones which succeeded are skipped as long as they do not change.
+	return kerrors.NewAggregate(dashboardErrors)
+}
+
+// applyDashboardDefaults sets the default refresh interval and time range on the dashboard when it does not define them.
+func (r DashboardReconciler) applyDashboardDefaults(dashboard map[string]any) {
+	if r.DashboardDefaultRefresh != "" {
+		if refresh, ok := dashboard["refresh"]; !ok || refresh == "" {
+			dashboard["refresh"] = r.DashboardDefaultRefresh
+		}
+	}
+
+	if r.DashboardDefaultTimeFrom != "" {
+		if _, ok := dashboard["time"]; !ok {
+			dashboard["time"] = map[string]any{
+				"from": r.DashboardDefaultTimeFrom,
+				"to":   "now",
+			}
+		}
+	}
+}
+
+// getDashboardTenantIDs returns the tenant IDs of the organization when the tenant variable is enabled.
+func (r DashboardReconciler) getDashboardTenantIDs(ctx context.Context, dashboardOrg string) ([]string, error) {
+	if r.DashboardTenantVariable == "" {
+		return nil, nil
+	}
+
+	if dashboardOrg == grafana.SharedOrg.Name {
+		return grafana.SharedOrg.TenantIDs, nil
+	}
+
+	grafanaOrganization, err := r.findGrafanaOrganization(ctx, dashboardOrg)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Dashboards in organizations which are not managed by a GrafanaOrganization CR keep their variables.
+	if grafanaOrganization == nil {
+		return nil, nil
+	}
+
+	return newOrganization(grafanaOrganization).TenantIDs, nil
+}
+
+// setTenantVariable sets the options of the template variable with the given name to the tenant IDs.
+// The variable is turned into a custom variable and its current value is reset when it is not one of the tenant IDs.
+// Dashboards which do not declare the variable are left unchanged.
+func setTenantVariable(dashboard map[string]any, name string, tenantIDs []string) {
+	if name == "" || len(tenantIDs) == 0 {
+		return
+	}
+
+	templating, ok := dashboard["templating"].(map[string]any)
+	if !ok {
+		return
+	}
+	variables, ok := templating["list"].([]any)
+	if !ok {
+		return
+	}
+
+	for _, v := range variables {
+		variable, ok := v.(map[string]any)
+		if !ok || variable["name"] != name {
+			continue
+		}
+
+		current := tenantIDs[0]
+		if currentValue, ok := variable["current"].(map[string]any); ok {
+			if value, ok := currentValue["value"].(string); ok && slices.Contains(tenantIDs, value) {
+				current = value
+			}
+		}
+
+		options := make([]any, len(tenantIDs))
+		for i, tenantID := range tenantIDs {
+			options[i] = map[string]any{
+				"text":     tenantID,
+				"value":    tenantID,
+				"selected": tenantID == current,
+			}
+		}
+
+		variable["type"] = "custom"
+		variable["query"] = strings.Join(tenantIDs, ",")
+		variable["options"] = options
+		variable["current"] = map[string]any{
+			"text":  current,
+			"value": current,
+		}
+	}
+}
+
+// hashDashboard returns the hash of the dashboard content.
+func hashDashboard(dashboard string) string {
+	hash := sha256.Sum256([]byte(dashboard))
+	return hex.EncodeToString(hash[:])
+}
+
+// syncedDashboard is a dashboard of a configmap which was pushed to Grafana.
+type syncedDashboard struct {
+	// Hash is the hash of the pushed content.
+	Hash string `json:"hash"`
+	// Version is the version of the dashboard in Grafana once pushed.
+	Version int64 `json:"version,omitempty"`
+}
+
+// getSyncedDashboards returns the dashboards of the configmap which were pushed to Grafana, indexed by UID.
+func getSyncedDashboards(dashboardCM *v1.ConfigMap) map[string]syncedDashboard {
+	synced := map[string]syncedDashboard{}
+	value, ok := dashboardCM.GetAnnotations()[syncedDashboardsAnnotation]
+	if !ok {
+		return synced
+	}
+
+	// An invalid annotation only means all the dashboards are pushed again.
+	_ = json.Unmarshal([]byte(value), &synced)
+	return synced
+}
+
+// isDashboardUpToDate returns true if the dashboard was pushed with the same content and was not changed in Grafana since.
+// Grafana can lose its dashboards when it is restarted, and the dashboards can be edited from its UI, which increments their version.
+func (r DashboardReconciler) isDashboardUpToDate(orgAPI *grafanaAPI.GrafanaHTTPAPI, dashboardUID string, hash string, synced map[string]syncedDashboard) (bool, error) {
+	previous, ok := synced[dashboardUID]
+	if !ok || previous.Hash != hash {
+		return false, nil
+	}
+
+	version, exists, err := grafana.GetDashboardVersion(orgAPI, dashboardUID)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return exists && version == previous.Version, nil
+}
+
+// updateSyncedDashboards records the hashes of the synced dashboards in the configmap annotations.
+func (r DashboardReconciler) updateSyncedDashboards(ctx context.Context, dashboardCM *v1.ConfigMap, synced map[string]syncedDashboard) error {
+	value, err := json.Marshal(synced)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if dashboardCM.GetAnnotations()[syncedDashboardsAnnotation] == string(value) {
+		return nil
+	}
+
+	patch := client.MergeFrom(dashboardCM.DeepCopy())
+	annotations := dashboardCM.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[syncedDashboardsAnnotation] = string(value)
+	dashboardCM.SetAnnotations(annotations)
+
+	return errors.WithStack(r.Client.Patch(ctx, dashboardCM, patch))
+}
+
+// clearSyncedDashboards removes the hashes of the synced dashboards from the configmap annotations.
+func (r DashboardReconciler) clearSyncedDashboards(ctx context.Context, dashboardCM *v1.ConfigMap) error {
+	annotations := dashboardCM.GetAnnotations()
+	if _, ok := annotations[syncedDashboardsAnnotation]; !ok {
+		return nil
+	}
+
+	patch := client.MergeFrom(dashboardCM.DeepCopy())
+	delete(annotations, syncedDashboardsAnnotation)
+	dashboardCM.SetAnnotations(annotations)
+
+	return errors.WithStack(r.Client.Patch(ctx, dashboardCM, patch))
+}
+
+// updateOrganizationsDashboards records the dashboards applied from the configmap in the status of their GrafanaOrganization
+// and removes the ones which were previously recorded from this configmap in any other organization.
+// An empty dashboardOrg removes the configmap dashboards from all organizations.
+func (r DashboardReconciler) updateOrganizationsDashboards(ctx context.Context, dashboardCM *v1.ConfigMap, dashboardOrg string, dashboardUIDs []string) error {
+	logger := log.FromContext(ctx)
+
+	organizations := v1alpha1.GrafanaOrganizationList{}
+	err := r.Client.List(ctx, &organizations)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	configMapName := client.ObjectKeyFromObject(dashboardCM).String()
+	for i := range organizations.Items {
+		grafanaOrganization := &organizations.Items[i]
+
+		dashboards := make([]v1alpha1.Dashboard, 0, len(grafanaOrganization.Status.Dashboards)+len(dashboardUIDs))
+		for _, dashboard := range grafanaOrganization.Status.Dashboards {
+			if dashboard.ConfigMap != configMapName {
+				dashboards = append(dashboards, dashboard)
+			}
+		}
+		if dashboardOrg != "" && grafanaOrganization.Spec.DisplayName == dashboardOrg {
+			for _, uid := range dashboardUIDs {
+				dashboards = append(dashboards, v1alpha1.Dashboard{UID: uid, ConfigMap: configMapName})
+			}
+		}
+		slices.SortFunc(dashboards, func(a, b v1alpha1.Dashboard) int {
+			return cmp.Or(cmp.Compare(a.ConfigMap, b.ConfigMap), cmp.Compare(a.UID, b.UID))
+		})
+
+		if slices.Equal(dashboards, grafanaOrganization.Status.Dashboards) {
+			continue
+		}
+
+		logger.Info("updating dashboards in the grafanaOrganization status", "organization", grafanaOrganization.Name)
+		patch := client.MergeFrom(grafanaOrganization.DeepCopy())
+		grafanaOrganization.Status.Dashboards = dashboards
+		if err := r.Client.Status().Patch(ctx, grafanaOrganization, patch); err != nil {
+			return errors.WithStack(err)
+		}
+		logger.Info("updated dashboards in the grafanaOrganization status", "organization", grafanaOrganization.Name)
 	}
 
 	return nil
 }
 
+// configureDashboardPermissions applies the RBAC configuration of the dashboard's GrafanaOrganization to the dashboard permissions.
+func (r DashboardReconciler) configureDashboardPermissions(ctx context.Context, orgAPI *grafanaAPI.GrafanaHTTPAPI, dashboardUID string, dashboardOrg string) error {
+	grafanaOrganization, err := r.findGrafanaOrganization(ctx, dashboardOrg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Dashboards in organizations which are not managed by a GrafanaOrganization CR (e.g. the shared org) keep their permissions.
+	if grafanaOrganization == nil {
+		return nil
+	}
+
+	return grafana.ConfigureDashboardPermissions(ctx, orgAPI, dashboardUID, newOrganization(grafanaOrganization))
+}
+
+// getDashboardManagementMode returns the dashboard management mode of the organization.
+// Organizations which are not managed by a GrafanaOrganization CR (e.g. the shared org) enforce their dashboards.
+func (r DashboardReconciler) getDashboardManagementMode(ctx context.Context, dashboardOrg string) (string, error) {
+	grafanaOrganization, err := r.findGrafanaOrganization(ctx, dashboardOrg)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if grafanaOrganization == nil || grafanaOrganization.Spec.DashboardManagementMode == "" {
+		return v1alpha1.DashboardManagementModeEnforce, nil
+	}
+
+	return grafanaOrganization.Spec.DashboardManagementMode, nil
+}
+
+// findGrafanaOrganization returns the GrafanaOrganization CR with the given display name, or nil if there is none.
+func (r DashboardReconciler) findGrafanaOrganization(ctx context.Context, displayName string) (*v1alpha1.GrafanaOrganization, error) {
+	organizations := v1alpha1.GrafanaOrganizationList{}
+	err := r.Client.List(ctx, &organizations)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for i := range organizations.Items {
+		if organizations.Items[i].Spec.DisplayName == displayName {
+			return &organizations.Items[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
 // reconcileDelete deletes the grafana dashboard.
 func (r DashboardReconciler) reconcileDelete(ctx context.Context, dashboardCM *v1.ConfigMap) error {
 	logger := log.FromContext(ctx)
 
 	// We do not need to delete anything if there is no finalizer on the grafana dashboard
-	if !controllerutil.ContainsFinalizer(dashboardCM, DashboardFinalizer) {
+	if !controllerutil.ContainsFinalizer(dashboardCM, r.finalizer()) {
 		return nil
 	}
 
-	dashboardOrg, err := getOrgFromDashboardConfigmap(dashboardCM)
-	if err != nil {
+	dashboardOrg, err := resolveDashboardOrganization(r.GrafanaAPI, dashboardCM)
+	if errors.Is(err, errNoOrganization) {
 		logger.Error(err, "Skipping dashboard, no organization found")
 		return nil
+	} else if err != nil {
+		logger.Error(err, "failed to resolve the organization of the dashboard")
+		return errors.WithStack(err)
 	}
 
-	// We always switch back to the shared org
-	defer func() {
-		if _, err = r.GrafanaAPI.SignedInUser.UserSetUsingOrg(grafana.SharedOrg.ID); err != nil {
-			logger.Error(err, "failed to change current org for signed in user")
-		}
-	}()
+	// Leave the dashboards untouched when the operator is not allowed to manage the organization
+	if !grafana.IsManagedOrganization(r.ManagedOrganizations, dashboardOrg) {
+		logger.Info("organization is not managed by the operator, skipping the deletion of the dashboards", "organization", dashboardOrg)
+		return r.removeFinalizer(ctx, dashboardCM)
+	}
+
+	// A configmap must not delete the dashboards of an organization its namespace is not allowed to push to
+	if !isOrganizationAllowed(r.DashboardAllowedOrganizations, dashboardCM.GetNamespace(), dashboardOrg) {
+		logger.Error(errors.Errorf("namespace %q is not allowed to push dashboards to organization %q", dashboardCM.GetNamespace(), dashboardOrg),
+			"Skipping the deletion of the dashboards, organization not allowed")
+		return r.removeFinalizer(ctx, dashboardCM)
+	}
 
-	// Switch context to the dashboards-defined org
 	organization, err := grafana.FindOrgByName(r.GrafanaAPI, dashboardOrg)
 	if err != nil {
 		logger.Error(err, "failed to find organization", "organization", dashboardOrg)
 		return errors.WithStack(err)
 	}
-	if _, err = r.GrafanaAPI.SignedInUser.UserSetUsingOrg(organization.ID); err != nil {
-		logger.Error(err, "failed to change current org for signed in user")
-		return errors.WithStack(err)
-	}
+	orgAPI := grafana.WithOrgID(r.GrafanaAPI, organization.ID)
 
-	for _, dashboardString := range dashboardCM.Data {
-		var dashboard map[string]interface{}
-		err = json.Unmarshal([]byte(dashboardString), &dashboard)
-		if err != nil {
-			logger.Error(err, "Failed converting dashboard to json")
+	// Dashboards removed from the configmap whose deletion failed are still recorded as synced
+	dashboardUIDs := getSyncedDashboards(dashboardCM)
+	for key, dashboardString := range dashboardCM.Data {
+		if !r.isDashboardKey(key) {
 			continue
 		}
-
-		dashboardUID, err := getDashboardUID(dashboard)
-		if err != nil {
+		dashboardUID, err := decodeDashboardUID(dashboardString)
+		if errors.Is(err, errNoDashboardUID) {
 			logger.Error(err, "Skipping dashboard, no UID found")
 			continue
+		} else if err != nil {
+			logger.Error(err, "Failed converting dashboard to json")
+			continue
 		}
+		dashboardUIDs[dashboardUID] = syncedDashboard{}
+	}
 
-		_, err = r.GrafanaAPI.Dashboards.GetDashboardByUID(dashboardUID)
+	for _, dashboardUID := range slices.Sorted(maps.Keys(dashboardUIDs)) {
+		_, err = orgAPI.Dashboards.GetDashboardByUID(dashboardUID)
 		if err != nil {
 			logger.Error(err, "Failed getting dashboard")
 			continue
 		}
 
-		_, err = r.GrafanaAPI.Dashboards.DeleteDashboardByUID(dashboardUID)
+		err = grafana.DeleteDashboard(ctx, orgAPI, organization.ID, dashboardUID)
 		if err != nil {
 			logger.Error(err, "Failed deleting dashboard")
 			continue
@@ -307,20 +988,45 @@ func (r DashboardReconciler) reconcileDelete(ctx context.Context, dashboardCM *v
 		logger.Info("deleted dashboard", "Dashboard UID", dashboardUID, "Dashboard Org", dashboardOrg)
 	}
 
+	err = r.updateOrganizationsDashboards(ctx, dashboardCM, "", nil)
+	if err != nil {
+		logger.Error(err, "failed to remove managed dashboards from the grafanaOrganization status")
+		return errors.WithStack(err)
+	}
+
 	// Finalizer handling needs to come last.
+	return r.removeFinalizer(ctx, dashboardCM)
+}
+
+// finalizer returns the finalizer added to the dashboard configmaps.
+func (r DashboardReconciler) finalizer() string {
+	if r.Finalizer == "" {
+		return DashboardFinalizer
+	}
+	return r.Finalizer
+}
+
+// removeFinalizer removes the finalizer from the dashboard configmap, if any, leaving its dashboards untouched in Grafana.
+func (r DashboardReconciler) removeFinalizer(ctx context.Context, dashboardCM *v1.ConfigMap) error {
+	logger := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(dashboardCM, r.finalizer()) {
+		return nil
+	}
+
 	// We use the patch from sigs.k8s.io/cluster-api/util/patch to handle the patching without conflicts
-	logger.Info("removing finalizer", "finalizer", DashboardFinalizer)
+	logger.Info("removing finalizer", "finalizer", r.finalizer())
 	patchHelper, err := patch.NewHelper(dashboardCM, r.Client)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	controllerutil.RemoveFinalizer(dashboardCM, DashboardFinalizer)
+	controllerutil.RemoveFinalizer(dashboardCM, r.finalizer())
 	if err := patchHelper.Patch(ctx, dashboardCM); err != nil {
-		logger.Error(err, "failed to remove finalizer, requeuing", "finalizer", DashboardFinalizer)
+		logger.Error(err, "failed to remove finalizer, requeuing", "finalizer", r.finalizer())
 		return errors.WithStack(err)
 	}
-	logger.Info("removed finalizer", "finalizer", DashboardFinalizer)
+	logger.Info("removed finalizer", "finalizer", r.finalizer())
 
 	return nil
 }
@@ -1,7 +1,28 @@
 package controller
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	grafanaAPI "github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/client/dashboards"
+	"github.com/grafana/grafana-openapi-client-go/client/orgs"
+	"github.com/grafana/grafana-openapi-client-go/models"
 	. "github.com/onsi/ginkgo/v2"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/giantswarm/observability-operator/api/v1alpha1"
 )
 
 var _ = Describe("Dashboard Controller", func() {
@@ -12,3 +33,1427 @@ var _ = Describe("Dashboard Controller", func() {
 		})
 	})
 })
+
+func TestUpdateOrganizationsDashboards(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	organization := &v1alpha1.GrafanaOrganization{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec:       v1alpha1.GrafanaOrganizationSpec{DisplayName: "Test"},
+	}
+	otherOrganization := &v1alpha1.GrafanaOrganization{
+		ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		Spec:       v1alpha1.GrafanaOrganizationSpec{DisplayName: "Other"},
+	}
+
+	r := DashboardReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(organization, otherOrganization).
+			WithStatusSubresource(organization, otherOrganization).
+			Build(),
+	}
+
+	dashboards := func(name string) []v1alpha1.Dashboard {
+		grafanaOrganization := &v1alpha1.GrafanaOrganization{}
+		if err := r.Client.Get(context.Background(), client.ObjectKey{Name: name}, grafanaOrganization); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return grafanaOrganization.Status.Dashboards
+	}
+
+	first := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "first", Namespace: "default"}}
+	second := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "second", Namespace: "default"}}
+
+	steps := []struct {
+		name          string
+		configMap     *v1.ConfigMap
+		dashboardOrg  string
+		dashboardUIDs []string
+		expected      []v1alpha1.Dashboard
+		expectedOther []v1alpha1.Dashboard
+	}{
+		{
+			name:          "dashboards are added",
+			configMap:     first,
+			dashboardOrg:  "Test",
+			dashboardUIDs: []string{"b", "a"},
+			expected: []v1alpha1.Dashboard{
+				{UID: "a", ConfigMap: "default/first"},
+				{UID: "b", ConfigMap: "default/first"},
+			},
+		},
+		{
+			name:          "dashboards from another configmap are added",
+			configMap:     second,
+			dashboardOrg:  "Test",
+			dashboardUIDs: []string{"c"},
+			expected: []v1alpha1.Dashboard{
+				{UID: "a", ConfigMap: "default/first"},
+				{UID: "b", ConfigMap: "default/first"},
+				{UID: "c", ConfigMap: "default/second"},
+			},
+		},
+		{
+			name:          "dashboards removed from a configmap are removed",
+			configMap:     first,
+			dashboardOrg:  "Test",
+			dashboardUIDs: []string{"a"},
+			expected: []v1alpha1.Dashboard{
+				{UID: "a", ConfigMap: "default/first"},
+				{UID: "c", ConfigMap: "default/second"},
+			},
+		},
+		{
+			name:          "dashboards moved to another organization are moved",
+			configMap:     second,
+			dashboardOrg:  "Other",
+			dashboardUIDs: []string{"c"},
+			expected: []v1alpha1.Dashboard{
+				{UID: "a", ConfigMap: "default/first"},
+			},
+			expectedOther: []v1alpha1.Dashboard{
+				{UID: "c", ConfigMap: "default/second"},
+			},
+		},
+		{
+			name:      "dashboards of a deleted configmap are removed",
+			configMap: first,
+			expectedOther: []v1alpha1.Dashboard{
+				{UID: "c", ConfigMap: "default/second"},
+			},
+		},
+	}
+
+	for _, step := range steps {
+		err := r.updateOrganizationsDashboards(context.Background(), step.configMap, step.dashboardOrg, step.dashboardUIDs)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+
+		if got := dashboards("test"); !slices.Equal(got, step.expected) {
+			t.Errorf("%s: expected dashboards %v, got %v", step.name, step.expected, got)
+		}
+		if got := dashboards("other"); !slices.Equal(got, step.expectedOther) {
+			t.Errorf("%s: expected other dashboards %v, got %v", step.name, step.expectedOther, got)
+		}
+	}
+}
+
+type fakeOrgs struct {
+	orgs.ClientService
+
+	names map[int64]string
+	// organizations are the organizations returned by the search
+	organizations []*models.OrgDTO
+	deleted       []int64
+}
+
+func (f *fakeOrgs) SearchOrgs(params *orgs.SearchOrgsParams, opts ...orgs.ClientOption) (*orgs.SearchOrgsOK, error) {
+	return &orgs.SearchOrgsOK{Payload: f.organizations}, nil
+}
+
+func (f *fakeOrgs) DeleteOrgByID(orgID int64, opts ...orgs.ClientOption) (*orgs.DeleteOrgByIDOK, error) {
+	f.deleted = append(f.deleted, orgID)
+	return &orgs.DeleteOrgByIDOK{}, nil
+}
+
+func (f *fakeOrgs) GetOrgByName(name string, opts ...orgs.ClientOption) (*orgs.GetOrgByNameOK, error) {
+	return &orgs.GetOrgByNameOK{Payload: &models.OrgDetailsDTO{ID: 2, Name: name}}, nil
+}
+
+func (f *fakeOrgs) GetOrgByID(orgID int64, opts ...orgs.ClientOption) (*orgs.GetOrgByIDOK, error) {
+	if _, ok := f.names[orgID]; f.names != nil && !ok {
+		return nil, errors.New("[GET /orgs/{org_id}][404] getOrgByIdNotFound (status 404)")
+	}
+	return &orgs.GetOrgByIDOK{Payload: &models.OrgDetailsDTO{ID: orgID, Name: f.names[orgID]}}, nil
+}
+
+type fakeDashboards struct {
+	dashboards.ClientService
+
+	failing   map[string]bool
+	existing  map[string]bool
+	published []string
+	deleted   []string
+	// contents are the last published content of the dashboards, indexed by UID
+	contents map[string]map[string]any
+	// versions are the Grafana versions of the dashboards, incremented whenever they are saved
+	versions map[string]int64
+}
+
+func (f *fakeDashboards) DeleteDashboardByUID(uid string, opts ...dashboards.ClientOption) (*dashboards.DeleteDashboardByUIDOK, error) {
+	f.deleted = append(f.deleted, uid)
+	delete(f.existing, uid)
+	return &dashboards.DeleteDashboardByUIDOK{}, nil
+}
+
+func (f *fakeDashboards) PostDashboard(body *models.SaveDashboardCommand, opts ...dashboards.ClientOption) (*dashboards.PostDashboardOK, error) {
+	uid, _ := body.Dashboard.(map[string]any)["uid"].(string)
+	if f.failing[uid] {
+		return nil, errors.New("grafana is unavailable")
+	}
+	f.published = append(f.published, uid)
+	f.existing[uid] = true
+	if f.contents == nil {
+		f.contents = map[string]map[string]any{}
+	}
+	f.contents[uid] = body.Dashboard.(map[string]any)
+	if f.versions == nil {
+		f.versions = map[string]int64{}
+	}
+	f.versions[uid]++
+	version := f.versions[uid]
+	return &dashboards.PostDashboardOK{Payload: &models.PostDashboardOKBody{Version: &version}}, nil
+}
+
+func (f *fakeDashboards) GetDashboardByUID(uid string, opts ...dashboards.ClientOption) (*dashboards.GetDashboardByUIDOK, error) {
+	if !f.existing[uid] {
+		return nil, errors.New("[GET /dashboards/uid/{uid}][404] getDashboardByUidNotFound (status 404)")
+	}
+	return &dashboards.GetDashboardByUIDOK{Payload: &models.DashboardFullWithMeta{Meta: &models.DashboardMeta{Version: f.versions[uid]}}}, nil
+}
+
+func TestConfigureDashboardPartialFailure(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	organization := &v1alpha1.GrafanaOrganization{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec:       v1alpha1.GrafanaOrganizationSpec{DisplayName: "Test"},
+	}
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dashboards",
+			Namespace:   "default",
+			Annotations: map[string]string{grafanaOrganizationLabel: "Test"},
+		},
+		Data: map[string]string{
+			"first.json":  `{"uid": "first", "title": "First"}`,
+			"second.json": `{"uid": "second", "title": "Second"}`,
+		},
+	}
+
+	fakeDashboards := &fakeDashboards{failing: map[string]bool{"second": true}, existing: map[string]bool{}}
+	r := DashboardReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(organization, configMap).
+			WithStatusSubresource(organization).
+			Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:       &fakeOrgs{},
+			Dashboards: fakeDashboards,
+		},
+	}
+
+	getConfigMap := func() *v1.ConfigMap {
+		current := &v1.ConfigMap{}
+		if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(configMap), current); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return current
+	}
+
+	// The failing dashboard does not prevent the others from being pushed.
+	err := r.configureDashboard(context.Background(), getConfigMap())
+	if err == nil || !strings.Contains(err.Error(), `dashboard "second"`) {
+		t.Fatalf("expected an error for the failing dashboard, got %v", err)
+	}
+	if !slices.Equal(fakeDashboards.published, []string{"first"}) {
+		t.Errorf("expected the first dashboard to be published, got %v", fakeDashboards.published)
+	}
+	synced := getSyncedDashboards(getConfigMap())
+	if _, ok := synced["first"]; !ok || len(synced) != 1 {
+		t.Errorf("expected only the first dashboard to be recorded as synced, got %v", synced)
+	}
+
+	grafanaOrganization := &v1alpha1.GrafanaOrganization{}
+	if err := r.Client.Get(context.Background(), client.ObjectKey{Name: "test"}, grafanaOrganization); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedDashboards := []v1alpha1.Dashboard{{UID: "first", ConfigMap: "default/dashboards"}}
+	if !slices.Equal(grafanaOrganization.Status.Dashboards, expectedDashboards) {
+		t.Errorf("expected dashboards %v in the organization status, got %v", expectedDashboards, grafanaOrganization.Status.Dashboards)
+	}
+
+	// On retry, only the dashboard which failed is pushed.
+	fakeDashboards.failing = nil
+	fakeDashboards.published = nil
+	err = r.configureDashboard(context.Background(), getConfigMap())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(fakeDashboards.published, []string{"second"}) {
+		t.Errorf("expected only the second dashboard to be published, got %v", fakeDashboards.published)
+	}
+	if synced := getSyncedDashboards(getConfigMap()); len(synced) != 2 {
+		t.Errorf("expected both dashboards to be recorded as synced, got %v", synced)
+	}
+
+	// Dashboards lost by Grafana are pushed again.
+	delete(fakeDashboards.existing, "first")
+	fakeDashboards.published = nil
+	err = r.configureDashboard(context.Background(), getConfigMap())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(fakeDashboards.published, []string{"first"}) {
+		t.Errorf("expected the missing dashboard to be published again, got %v", fakeDashboards.published)
+	}
+}
+
+func TestConfigureDashboardRemovedFromConfigMap(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	organization := &v1alpha1.GrafanaOrganization{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec:       v1alpha1.GrafanaOrganizationSpec{DisplayName: "Test"},
+	}
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dashboards",
+			Namespace:   "default",
+			Annotations: map[string]string{grafanaOrganizationLabel: "Test"},
+		},
+		Data: map[string]string{
+			"first.json":  `{"uid": "first", "title": "First"}`,
+			"second.json": `{"uid": "second", "title": "Second"}`,
+			"third.json":  `{"uid": "third", "title": "Third"}`,
+		},
+	}
+
+	fakeDashboards := &fakeDashboards{existing: map[string]bool{}}
+	r := DashboardReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(organization, configMap).
+			WithStatusSubresource(organization).
+			Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:       &fakeOrgs{},
+			Dashboards: fakeDashboards,
+		},
+	}
+
+	getConfigMap := func() *v1.ConfigMap {
+		current := &v1.ConfigMap{}
+		if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(configMap), current); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return current
+	}
+
+	if err := r.configureDashboard(context.Background(), getConfigMap()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fakeDashboards.deleted) != 0 {
+		t.Fatalf("expected no dashboard to be deleted, got %v", fakeDashboards.deleted)
+	}
+
+	current := getConfigMap()
+	delete(current.Data, "second.json")
+	if err := r.Client.Update(context.Background(), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := r.configureDashboard(context.Background(), getConfigMap()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(fakeDashboards.deleted, []string{"second"}) {
+		t.Errorf("expected the dropped dashboard to be deleted, got %v", fakeDashboards.deleted)
+	}
+	if synced := getSyncedDashboards(getConfigMap()); len(synced) != 2 {
+		t.Errorf("expected the dropped dashboard not to be recorded as synced anymore, got %v", synced)
+	}
+}
+
+func TestConfigureDashboardInvalidJSON(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	organization := &v1alpha1.GrafanaOrganization{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec:       v1alpha1.GrafanaOrganizationSpec{DisplayName: "Test"},
+	}
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dashboards",
+			Namespace:   "default",
+			Annotations: map[string]string{grafanaOrganizationLabel: "Test"},
+		},
+		Data: map[string]string{
+			"first.json":  `{"uid": "first", "title": "First"}`,
+			"second.json": `{"uid": "second", "title": "Second"}`,
+		},
+	}
+
+	fakeDashboards := &fakeDashboards{existing: map[string]bool{}}
+	r := DashboardReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(organization, configMap).
+			WithStatusSubresource(organization).
+			Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:       &fakeOrgs{},
+			Dashboards: fakeDashboards,
+		},
+	}
+
+	getConfigMap := func() *v1.ConfigMap {
+		current := &v1.ConfigMap{}
+		if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(configMap), current); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return current
+	}
+
+	if err := r.configureDashboard(context.Background(), getConfigMap()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A typo in a synced dashboard must not delete it from Grafana
+	current := getConfigMap()
+	current.Data["second.json"] = `{"uid": "second", "title": `
+	if err := r.Client.Update(context.Background(), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := r.configureDashboard(context.Background(), getConfigMap()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fakeDashboards.deleted) != 0 {
+		t.Errorf("expected no dashboard to be deleted, got %v", fakeDashboards.deleted)
+	}
+	if synced := getSyncedDashboards(getConfigMap()); len(synced) != 2 {
+		t.Errorf("expected the broken dashboard to still be recorded as synced, got %v", synced)
+	}
+}
+
+func TestConfigureDashboardKeySuffix(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name              string
+		keySuffix         string
+		synced            string
+		expectedPublished []string
+		expectedDeleted   []string
+	}{
+		{
+			name:              "only keys with the suffix hold dashboards",
+			keySuffix:         ".json",
+			expectedPublished: []string{"dashboard"},
+			expectedDeleted:   []string{"dashboard"},
+		},
+		{
+			name:              "all keys hold dashboards without suffix",
+			expectedPublished: []string{"dashboard", "readme"},
+			expectedDeleted:   []string{"dashboard", "readme"},
+		},
+		{
+			// the readme was pushed before the suffix was set, it is neither updated nor deleted until the configmap is deleted
+			name:              "dashboards pushed from keys without the suffix are kept",
+			keySuffix:         ".json",
+			synced:            `{"readme": {"hash": "previous"}}`,
+			expectedPublished: []string{"dashboard"},
+			expectedDeleted:   []string{"dashboard", "readme"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			annotations := map[string]string{grafanaOrganizationLabel: "Test"}
+			if tt.synced != "" {
+				annotations[syncedDashboardsAnnotation] = tt.synced
+			}
+			configMap := &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "dashboards",
+					Namespace:   "default",
+					Annotations: annotations,
+					Finalizers:  []string{DashboardFinalizer},
+				},
+				Data: map[string]string{
+					"dashboard.json": `{"uid": "dashboard", "title": "Dashboard"}`,
+					"notes.md":       "# Dashboards of the team",
+					"readme.txt":     `{"uid": "readme", "title": "Readme"}`,
+				},
+			}
+
+			fakeDashboards := &fakeDashboards{existing: map[string]bool{"readme": tt.synced != ""}}
+			r := DashboardReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(configMap).
+					Build(),
+				GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+					Orgs:       &fakeOrgs{},
+					Dashboards: fakeDashboards,
+				},
+				DashboardKeySuffix: tt.keySuffix,
+			}
+
+			current := configMap.DeepCopy()
+			if err := r.configureDashboard(context.Background(), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(fakeDashboards.published, tt.expectedPublished) {
+				t.Errorf("expected published dashboards %v, got %v", tt.expectedPublished, fakeDashboards.published)
+			}
+			if len(fakeDashboards.deleted) != 0 {
+				t.Errorf("expected no dashboard to be deleted while the configmap exists, got %v", fakeDashboards.deleted)
+			}
+
+			if err := r.reconcileDelete(context.Background(), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(fakeDashboards.deleted, tt.expectedDeleted) {
+				t.Errorf("expected deleted dashboards %v, got %v", tt.expectedDeleted, fakeDashboards.deleted)
+			}
+		})
+	}
+}
+
+func TestConfigureDashboardManagementMode(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name                string
+		mode                string
+		expectedPublished   []string
+		expectedRepublished []string
+	}{
+		{
+			name:                "default enforces the dashboards",
+			expectedPublished:   []string{"existing", "new"},
+			expectedRepublished: []string{"new"},
+		},
+		{
+			name:                "enforce overwrites the existing dashboards",
+			mode:                v1alpha1.DashboardManagementModeEnforce,
+			expectedPublished:   []string{"existing", "new"},
+			expectedRepublished: []string{"new"},
+		},
+		{
+			name:              "adopt only creates the missing dashboards",
+			mode:              v1alpha1.DashboardManagementModeAdopt,
+			expectedPublished: []string{"new"},
+		},
+		{
+			name: "ignore does not push the dashboards",
+			mode: v1alpha1.DashboardManagementModeIgnore,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			organization := &v1alpha1.GrafanaOrganization{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: v1alpha1.GrafanaOrganizationSpec{
+					DisplayName:             "Test",
+					DashboardManagementMode: tt.mode,
+				},
+			}
+			configMap := &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "dashboards",
+					Namespace:   "default",
+					Annotations: map[string]string{grafanaOrganizationLabel: "Test"},
+				},
+				Data: map[string]string{
+					"existing.json": `{"uid": "existing", "title": "Existing"}`,
+					"new.json":      `{"uid": "new", "title": "New"}`,
+				},
+			}
+
+			// The existing dashboard was created in Grafana before the operator managed it
+			fakeDashboards := &fakeDashboards{existing: map[string]bool{"existing": true}, versions: map[string]int64{"existing": 3}}
+			r := DashboardReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(organization, configMap).
+					WithStatusSubresource(organization).
+					Build(),
+				GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+					Orgs:       &fakeOrgs{},
+					Dashboards: fakeDashboards,
+				},
+			}
+
+			if err := r.configureDashboard(context.Background(), configMap); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(fakeDashboards.published, tt.expectedPublished) {
+				t.Errorf("expected published dashboards %v, got %v", tt.expectedPublished, fakeDashboards.published)
+			}
+
+			// The new dashboard is edited in Grafana, which increments its version
+			fakeDashboards.versions["new"]++
+			fakeDashboards.published = nil
+			current := &v1.ConfigMap{}
+			if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(configMap), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := r.configureDashboard(context.Background(), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(fakeDashboards.published, tt.expectedRepublished) {
+				t.Errorf("expected republished dashboards %v, got %v", tt.expectedRepublished, fakeDashboards.published)
+			}
+		})
+	}
+}
+
+func TestConfigureDashboardOrganizationID(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name               string
+		annotations        map[string]string
+		expectedPublished  []string
+		expectedDashboards []v1alpha1.Dashboard
+	}{
+		{
+			name:               "organization resolved by ID",
+			annotations:        map[string]string{grafanaOrganizationIDAnnotation: "3"},
+			expectedPublished:  []string{"first"},
+			expectedDashboards: []v1alpha1.Dashboard{{UID: "first", ConfigMap: "default/dashboards"}},
+		},
+		{
+			name:               "organization ID takes precedence over the name",
+			annotations:        map[string]string{grafanaOrganizationIDAnnotation: "3", grafanaOrganizationLabel: "Other"},
+			expectedPublished:  []string{"first"},
+			expectedDashboards: []v1alpha1.Dashboard{{UID: "first", ConfigMap: "default/dashboards"}},
+		},
+		{
+			name:        "unknown organization ID",
+			annotations: map[string]string{grafanaOrganizationIDAnnotation: "4"},
+		},
+		{
+			name:        "invalid organization ID",
+			annotations: map[string]string{grafanaOrganizationIDAnnotation: "team-a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			organization := &v1alpha1.GrafanaOrganization{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+				Spec:       v1alpha1.GrafanaOrganizationSpec{DisplayName: "Team A: Ops & SRE"},
+				Status:     v1alpha1.GrafanaOrganizationStatus{OrgID: 3},
+			}
+			configMap := &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "dashboards",
+					Namespace:   "default",
+					Annotations: tt.annotations,
+				},
+				Data: map[string]string{
+					"first.json": `{"uid": "first", "title": "First"}`,
+				},
+			}
+
+			fakeDashboards := &fakeDashboards{existing: map[string]bool{}}
+			r := DashboardReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(organization, configMap).
+					WithStatusSubresource(organization).
+					Build(),
+				GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+					Orgs:       &fakeOrgs{names: map[int64]string{1: "Shared Org", 3: "Team A: Ops & SRE"}},
+					Dashboards: fakeDashboards,
+				},
+			}
+
+			if err := r.configureDashboard(context.Background(), configMap); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(fakeDashboards.published, tt.expectedPublished) {
+				t.Errorf("expected published dashboards %v, got %v", tt.expectedPublished, fakeDashboards.published)
+			}
+
+			current := &v1alpha1.GrafanaOrganization{}
+			if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(organization), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(current.Status.Dashboards, tt.expectedDashboards) {
+				t.Errorf("expected organization dashboards %v, got %v", tt.expectedDashboards, current.Status.Dashboards)
+			}
+		})
+	}
+}
+
+func TestIsDashboardOfOrganization(t *testing.T) {
+	organization := &v1alpha1.GrafanaOrganization{
+		Spec:   v1alpha1.GrafanaOrganizationSpec{DisplayName: "Team A"},
+		Status: v1alpha1.GrafanaOrganizationStatus{OrgID: 3},
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    bool
+	}{
+		{
+			name:        "matching name",
+			annotations: map[string]string{grafanaOrganizationLabel: "Team A"},
+			expected:    true,
+		},
+		{
+			name:        "matching ID",
+			annotations: map[string]string{grafanaOrganizationIDAnnotation: "3"},
+			expected:    true,
+		},
+		{
+			name:        "other ID with matching name",
+			annotations: map[string]string{grafanaOrganizationIDAnnotation: "4", grafanaOrganizationLabel: "Team A"},
+			expected:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configMap := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			if got := isDashboardOfOrganization(configMap, organization); got != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestConfigureDashboardMaxSize(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	largePanels := `[` + strings.TrimSuffix(strings.Repeat(`{"title": "panel"},`, 100), ",") + `]`
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dashboards",
+			Namespace:   "default",
+			Annotations: map[string]string{grafanaOrganizationLabel: "Test"},
+		},
+		Data: map[string]string{
+			"small.json":    `{"uid": "small", "title": "Small"}`,
+			"large.json":    `{"uid": "large", "title": "Large", "panels": ` + largePanels + `}`,
+			"oversize.json": `{"uid": "oversize", "title": "Oversize", "panels": ` + largePanels + `, "description": "` + strings.Repeat("x", 1024) + `"}`,
+		},
+	}
+
+	fakeDashboards := &fakeDashboards{existing: map[string]bool{}}
+	r := DashboardReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(configMap).
+			Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:       &fakeOrgs{},
+			Dashboards: fakeDashboards,
+		},
+		DashboardMaxSize: 2500,
+	}
+
+	if err := r.configureDashboard(context.Background(), configMap); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(fakeDashboards.published, []string{"large", "small"}) {
+		t.Errorf("expected only the dashboards below the maximum size to be published, got %v", fakeDashboards.published)
+	}
+}
+
+func TestApplyDashboardDefaults(t *testing.T) {
+	r := DashboardReconciler{
+		DashboardDefaultRefresh:  "1m",
+		DashboardDefaultTimeFrom: "now-6h",
+	}
+
+	tests := []struct {
+		name      string
+		dashboard map[string]any
+		expected  map[string]any
+	}{
+		{
+			name:      "dashboard without refresh and time range gets the defaults",
+			dashboard: map[string]any{"uid": "test"},
+			expected: map[string]any{
+				"uid":     "test",
+				"refresh": "1m",
+				"time":    map[string]any{"from": "now-6h", "to": "now"},
+			},
+		},
+		{
+			name: "explicit refresh and time range are left unchanged",
+			dashboard: map[string]any{
+				"uid":     "test",
+				"refresh": "5m",
+				"time":    map[string]any{"from": "now-1h", "to": "now"},
+			},
+			expected: map[string]any{
+				"uid":     "test",
+				"refresh": "5m",
+				"time":    map[string]any{"from": "now-1h", "to": "now"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.applyDashboardDefaults(tt.dashboard)
+
+			if !reflect.DeepEqual(tt.dashboard, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, tt.dashboard)
+			}
+		})
+	}
+
+	t.Run("dashboards are left unchanged without defaults", func(t *testing.T) {
+		dashboard := map[string]any{"uid": "test"}
+		DashboardReconciler{}.applyDashboardDefaults(dashboard)
+
+		if !reflect.DeepEqual(dashboard, map[string]any{"uid": "test"}) {
+			t.Errorf("expected the dashboard to be unchanged, got %v", dashboard)
+		}
+	})
+}
+
+func TestConfigureDashboardAllowedOrganizations(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	allowedOrganizations := map[string][]string{
+		"team-a": {"Team A"},
+		"team-b": {"Team B"},
+	}
+
+	tests := []struct {
+		name              string
+		namespace         string
+		organization      string
+		expectedPublished []string
+	}{
+		{
+			name:              "namespace allowed to target the organization",
+			namespace:         "team-a",
+			organization:      "Team A",
+			expectedPublished: []string{"dashboard"},
+		},
+		{
+			name:         "namespace not allowed to target the organization",
+			namespace:    "team-a",
+			organization: "Team B",
+		},
+		{
+			name:         "namespace not listed",
+			namespace:    "team-c",
+			organization: "Team A",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configMap := &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "dashboards",
+					Namespace:   tt.namespace,
+					Annotations: map[string]string{grafanaOrganizationLabel: tt.organization},
+				},
+				Data: map[string]string{
+					"dashboard.json": `{"uid": "dashboard", "title": "Dashboard"}`,
+				},
+			}
+
+			fakeDashboards := &fakeDashboards{existing: map[string]bool{}}
+			r := DashboardReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(configMap).
+					Build(),
+				GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+					Orgs:       &fakeOrgs{},
+					Dashboards: fakeDashboards,
+				},
+				DashboardAllowedOrganizations: allowedOrganizations,
+			}
+
+			if err := r.configureDashboard(context.Background(), configMap); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(fakeDashboards.published, tt.expectedPublished) {
+				t.Errorf("expected published dashboards %v, got %v", tt.expectedPublished, fakeDashboards.published)
+			}
+		})
+	}
+}
+
+func TestReconcileDashboardAllowedOrganizations(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	allowedOrganizations := map[string][]string{
+		"team-a": {"Team A"},
+	}
+
+	t.Run("no finalizer is added to a configmap targeting a disallowed organization", func(t *testing.T) {
+		configMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "dashboards",
+				Namespace:   "team-a",
+				Annotations: map[string]string{grafanaOrganizationLabel: "Team B"},
+			},
+			Data: map[string]string{
+				"dashboard.json": `{"uid": "dashboard", "title": "Dashboard"}`,
+			},
+		}
+
+		r := DashboardReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(configMap).
+				Build(),
+			GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+				Orgs:       &fakeOrgs{},
+				Dashboards: &fakeDashboards{existing: map[string]bool{}},
+			},
+			DashboardAllowedOrganizations: allowedOrganizations,
+		}
+		request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(configMap)}
+
+		if _, err := r.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		current := &v1.ConfigMap{}
+		if err := r.Client.Get(context.Background(), request.NamespacedName, current); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(current.Finalizers) != 0 {
+			t.Errorf("expected no finalizer, got %v", current.Finalizers)
+		}
+	})
+
+	t.Run("deleting a configmap targeting a disallowed organization leaves its dashboards", func(t *testing.T) {
+		configMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "dashboards",
+				Namespace:   "team-a",
+				Annotations: map[string]string{grafanaOrganizationLabel: "Team B"},
+				Finalizers:  []string{DashboardFinalizer},
+			},
+			Data: map[string]string{
+				"dashboard.json": `{"uid": "dashboard", "title": "Dashboard"}`,
+			},
+		}
+
+		fakeDashboards := &fakeDashboards{existing: map[string]bool{"dashboard": true}}
+		r := DashboardReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(configMap).
+				Build(),
+			GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+				Orgs:       &fakeOrgs{},
+				Dashboards: fakeDashboards,
+			},
+			DashboardAllowedOrganizations: allowedOrganizations,
+		}
+		if err := r.Client.Delete(context.Background(), configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(configMap)}
+
+		if _, err := r.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(fakeDashboards.deleted) != 0 {
+			t.Errorf("expected no dashboard to be deleted, got %v", fakeDashboards.deleted)
+		}
+		err := r.Client.Get(context.Background(), request.NamespacedName, &v1.ConfigMap{})
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("expected the finalizer to be removed, got %v", err)
+		}
+	})
+}
+
+func TestConfigureDashboardManagedOrganizations(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name              string
+		organization      string
+		expectedPublished []string
+	}{
+		{
+			name:              "organization managed",
+			organization:      "Team A",
+			expectedPublished: []string{"dashboard"},
+		},
+		{
+			name:         "organization not managed",
+			organization: "Team B",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configMap := &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "dashboards",
+					Namespace:   "default",
+					Annotations: map[string]string{grafanaOrganizationLabel: tt.organization},
+				},
+				Data: map[string]string{
+					"dashboard.json": `{"uid": "dashboard", "title": "Dashboard"}`,
+				},
+			}
+
+			fakeDashboards := &fakeDashboards{existing: map[string]bool{}}
+			r := DashboardReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(configMap).
+					Build(),
+				GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+					Orgs:       &fakeOrgs{},
+					Dashboards: fakeDashboards,
+				},
+				ManagedOrganizations: []string{"Team A"},
+			}
+
+			if err := r.configureDashboard(context.Background(), configMap); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(fakeDashboards.published, tt.expectedPublished) {
+				t.Errorf("expected published dashboards %v, got %v", tt.expectedPublished, fakeDashboards.published)
+			}
+		})
+	}
+}
+
+func TestConfigureDashboardTenantVariable(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	grafanaOrganization := &v1alpha1.GrafanaOrganization{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+		Spec: v1alpha1.GrafanaOrganizationSpec{
+			DisplayName: "Team A",
+			RBAC:        &v1alpha1.RBAC{Admins: []string{"admins"}},
+			Tenants:     []v1alpha1.TenantID{"alpha", "beta"},
+		},
+	}
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dashboards",
+			Namespace:   "default",
+			Annotations: map[string]string{grafanaOrganizationLabel: "Team A"},
+		},
+		Data: map[string]string{
+			"tenant.json": `{"uid": "tenant", "title": "Tenant", "templating": {"list": [
+				{"name": "tenant", "type": "custom", "query": "placeholder", "current": {"text": "beta", "value": "beta"}},
+				{"name": "cluster", "type": "query", "query": "label_values(cluster)"}
+			]}}`,
+			"other.json": `{"uid": "other", "title": "Other"}`,
+		},
+	}
+
+	fakeDashboards := &fakeDashboards{existing: map[string]bool{}}
+	r := DashboardReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(configMap, grafanaOrganization).
+			WithStatusSubresource(grafanaOrganization).
+			Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:       &fakeOrgs{},
+			Dashboards: fakeDashboards,
+		},
+		DashboardTenantVariable: "tenant",
+	}
+
+	if err := r.configureDashboard(context.Background(), configMap); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	variables := fakeDashboards.contents["tenant"]["templating"].(map[string]any)["list"].([]any)
+	tenant := variables[0].(map[string]any)
+	if tenant["query"] != "alpha,beta" {
+		t.Errorf("expected query %q, got %v", "alpha,beta", tenant["query"])
+	}
+	var options []string
+	for _, option := range tenant["options"].([]any) {
+		options = append(options, option.(map[string]any)["value"].(string))
+	}
+	if !slices.Equal(options, []string{"alpha", "beta"}) {
+		t.Errorf("expected options %v, got %v", []string{"alpha", "beta"}, options)
+	}
+	if current := tenant["current"].(map[string]any)["value"]; current != "beta" {
+		t.Errorf("expected the current value %q to be kept, got %v", "beta", current)
+	}
+
+	if cluster := variables[1].(map[string]any); cluster["query"] != "label_values(cluster)" {
+		t.Errorf("expected other variables to be left unchanged, got %v", cluster)
+	}
+	if _, ok := fakeDashboards.contents["other"]["templating"]; ok {
+		t.Errorf("expected dashboards without the variable to be left unchanged")
+	}
+}
+
+func TestReconcileDashboardSkipSync(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name       string
+		finalizers []string
+		synced     string
+	}{
+		{
+			name: "new configmap gets no finalizer",
+		},
+		{
+			name:       "finalizer and synced dashboards of a previously synced configmap are removed",
+			finalizers: []string{DashboardFinalizer},
+			synced:     `{"dashboard":"hash"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			annotations := map[string]string{
+				grafanaOrganizationLabel: "Test",
+				skipSyncAnnotation:       "true",
+			}
+			if tt.synced != "" {
+				annotations[syncedDashboardsAnnotation] = tt.synced
+			}
+			configMap := &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "dashboards",
+					Namespace:   "default",
+					Annotations: annotations,
+					Finalizers:  tt.finalizers,
+				},
+				Data: map[string]string{
+					"dashboard.json": `{"uid": "dashboard", "title": "Dashboard"}`,
+				},
+			}
+
+			fakeDashboards := &fakeDashboards{existing: map[string]bool{"dashboard": true}}
+			r := DashboardReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(configMap).
+					Build(),
+				GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+					Orgs:       &fakeOrgs{},
+					Dashboards: fakeDashboards,
+				},
+			}
+
+			// Reconcile twice as the first reconciliation only adds the finalizer when the configmap is synced
+			for range 2 {
+				if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(configMap)}); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			current := &v1.ConfigMap{}
+			if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(configMap), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(current.Finalizers) != 0 {
+				t.Errorf("expected no finalizer, got %v", current.Finalizers)
+			}
+			if _, ok := current.GetAnnotations()[syncedDashboardsAnnotation]; ok {
+				t.Errorf("expected the synced dashboards annotation to be removed, got %v", current.GetAnnotations())
+			}
+			if len(fakeDashboards.published) != 0 || len(fakeDashboards.deleted) != 0 {
+				t.Errorf("expected the dashboards to be left untouched, got published %v and deleted %v", fakeDashboards.published, fakeDashboards.deleted)
+			}
+		})
+	}
+}
+
+func TestReconcileDashboardPaused(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name              string
+		finalizers        []string
+		deletionTimestamp *metav1.Time
+	}{
+		{
+			name: "new configmap gets no finalizer",
+		},
+		{
+			name:              "deleted configmap keeps its finalizer",
+			finalizers:        []string{DashboardFinalizer},
+			deletionTimestamp: &metav1.Time{Time: time.Now()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configMap := &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "dashboards",
+					Namespace: "default",
+					Annotations: map[string]string{
+						grafanaOrganizationLabel: "Test",
+						pausedAnnotation:         "true",
+					},
+					Finalizers:        tt.finalizers,
+					DeletionTimestamp: tt.deletionTimestamp,
+				},
+				Data: map[string]string{
+					"dashboard.json": `{"uid": "dashboard", "title": "Dashboard"}`,
+				},
+			}
+
+			fakeDashboards := &fakeDashboards{existing: map[string]bool{"dashboard": true}}
+			r := DashboardReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(configMap).
+					Build(),
+				GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+					Orgs:       &fakeOrgs{},
+					Dashboards: fakeDashboards,
+				},
+			}
+
+			if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(configMap)}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			current := &v1.ConfigMap{}
+			if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(configMap), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(current.Finalizers, tt.finalizers) {
+				t.Errorf("expected finalizers %v, got %v", tt.finalizers, current.Finalizers)
+			}
+			if len(fakeDashboards.published) != 0 || len(fakeDashboards.deleted) != 0 {
+				t.Errorf("expected the dashboards to be left untouched, got published %v and deleted %v", fakeDashboards.published, fakeDashboards.deleted)
+			}
+		})
+	}
+}
+
+func TestDecodeDashboardUID(t *testing.T) {
+	tests := []struct {
+		name          string
+		dashboard     string
+		expectedUID   string
+		expectedNoUID bool
+		expectedErr   bool
+	}{
+		{
+			name:        "uid",
+			dashboard:   `{"title": "Dashboard", "panels": [{"uid": "panel"}], "uid": "dashboard"}`,
+			expectedUID: "dashboard",
+		},
+		{
+			name:        "last uid wins",
+			dashboard:   `{"uid": "first", "uid": "last"}`,
+			expectedUID: "last",
+		},
+		{
+			name:          "no uid",
+			dashboard:     `{"title": "Dashboard", "panels": [{"uid": "panel"}]}`,
+			expectedNoUID: true,
+		},
+		{
+			name:          "uid is case sensitive",
+			dashboard:     `{"UID": "dashboard"}`,
+			expectedNoUID: true,
+		},
+		{
+			name:          "uid is not a string",
+			dashboard:     `{"uid": 1}`,
+			expectedNoUID: true,
+		},
+		{
+			name:          "null",
+			dashboard:     `null`,
+			expectedNoUID: true,
+		},
+		{
+			name:        "invalid json after the uid",
+			dashboard:   `{"uid": "dashboard", "panels": [}`,
+			expectedErr: true,
+		},
+		{
+			name:        "trailing data",
+			dashboard:   `{"uid": "dashboard"} {}`,
+			expectedErr: true,
+		},
+		{
+			name:        "not an object",
+			dashboard:   `["dashboard"]`,
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uid, err := decodeDashboardUID(tt.dashboard)
+
+			// The result must match decoding the whole dashboard
+			var dashboard map[string]any
+			if unmarshalErr := json.Unmarshal([]byte(tt.dashboard), &dashboard); (unmarshalErr != nil) != tt.expectedErr {
+				t.Fatalf("unexpected json.Unmarshal error: %v", unmarshalErr)
+			}
+
+			switch {
+			case tt.expectedErr:
+				if err == nil || errors.Is(err, errNoDashboardUID) {
+					t.Errorf("expected a decoding error, got %v", err)
+				}
+			case tt.expectedNoUID:
+				if !errors.Is(err, errNoDashboardUID) {
+					t.Errorf("expected error %v, got %v", errNoDashboardUID, err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if uid != tt.expectedUID || dashboard["uid"] != tt.expectedUID {
+					t.Errorf("expected uid %q, got %q", tt.expectedUID, uid)
+				}
+			}
+		})
+	}
+}
+
+func largeDashboard() string {
+	panel := `{"type": "timeseries", "title": "panel", "datasource": {"type": "prometheus", "uid": "mimir"}, "targets": [{"expr": "sum(rate(http_requests_total[5m])) by (job)", "refId": "A"}], "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0}}`
+	return `{"title": "Large", "panels": [` + strings.TrimSuffix(strings.Repeat(panel+",", 1000), ",") + `], "uid": "large"}`
+}
+
+// BenchmarkConfigureDashboard compares the dashboards which are skipped for their size, of which only the UID is
+// streamed, with the dashboards which are pushed, which are still fully decoded to apply the defaults.
+func BenchmarkConfigureDashboard(b *testing.B) {
+	scheme := newTestScheme(b)
+	dashboard := largeDashboard()
+
+	tests := []struct {
+		name    string
+		maxSize int
+	}{
+		{
+			name:    "oversized",
+			maxSize: len(dashboard) - 1,
+		},
+		{
+			name: "pushed",
+		},
+	}
+
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				b.StopTimer()
+				configMap := &v1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "dashboards",
+						Namespace:   "default",
+						Annotations: map[string]string{grafanaOrganizationLabel: "Test"},
+					},
+					Data: map[string]string{"large.json": dashboard},
+				}
+				r := DashboardReconciler{
+					Client: fake.NewClientBuilder().
+						WithScheme(scheme).
+						WithObjects(configMap).
+						Build(),
+					GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+						Orgs:       &fakeOrgs{},
+						Dashboards: &fakeDashboards{existing: map[string]bool{}},
+					},
+					DashboardMaxSize: tt.maxSize,
+				}
+				b.StartTimer()
+
+				if err := r.configureDashboard(context.Background(), configMap); err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileDashboardCustomFinalizer(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	const customFinalizer = "observability.giantswarm.io/grafanadashboard-migration"
+
+	// The configmap is also managed by another instance of the operator using the default finalizer
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dashboards",
+			Namespace:   "default",
+			Annotations: map[string]string{grafanaOrganizationLabel: "Test"},
+			Finalizers:  []string{DashboardFinalizer},
+		},
+		Data: map[string]string{
+			"dashboard.json": `{"uid": "dashboard", "title": "Dashboard"}`,
+		},
+	}
+
+	fakeDashboards := &fakeDashboards{existing: map[string]bool{"dashboard": true}}
+	r := DashboardReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(configMap).
+			Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:       &fakeOrgs{},
+			Dashboards: fakeDashboards,
+		},
+		Finalizer: customFinalizer,
+	}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(configMap)}
+
+	t.Run("add", func(t *testing.T) {
+		if _, err := r.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		current := &v1.ConfigMap{}
+		if err := r.Client.Get(context.Background(), request.NamespacedName, current); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []string{DashboardFinalizer, customFinalizer}
+		if !slices.Equal(current.Finalizers, expected) {
+			t.Errorf("expected finalizers %v, got %v", expected, current.Finalizers)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		current := &v1.ConfigMap{}
+		if err := r.Client.Get(context.Background(), request.NamespacedName, current); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := r.Client.Delete(context.Background(), current); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := r.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := r.Client.Get(context.Background(), request.NamespacedName, current); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(current.Finalizers, []string{DashboardFinalizer}) {
+			t.Errorf("expected only the finalizer of the other instance to be kept, got %v", current.Finalizers)
+		}
+		if !slices.Equal(fakeDashboards.deleted, []string{"dashboard"}) {
+			t.Errorf("expected the dashboard to be deleted, got %v", fakeDashboards.deleted)
+		}
+	})
+}
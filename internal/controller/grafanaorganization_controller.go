@@ -4,17 +4,25 @@ import (
 	"cmp"
 	"context"
 	"fmt"
+	"regexp"
 	"slices"
+	"strings"
+	"time"
 
 	grafanaAPI "github.com/grafana/grafana-openapi-client-go/client"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -23,9 +31,20 @@ import (
 
 	"github.com/giantswarm/observability-operator/api/v1alpha1"
 	"github.com/giantswarm/observability-operator/internal/controller/predicates"
+	"github.com/giantswarm/observability-operator/pkg/alertmanager"
 	"github.com/giantswarm/observability-operator/pkg/config"
 	"github.com/giantswarm/observability-operator/pkg/grafana"
 	grafanaclient "github.com/giantswarm/observability-operator/pkg/grafana/client"
+	"github.com/giantswarm/observability-operator/pkg/metrics"
+)
+
+const (
+	// grafanaOrganizationNameLabel is set on the resources created for a GrafanaOrganization to find them back on deletion.
+	grafanaOrganizationNameLabel = "observability.giantswarm.io/grafana-organization"
+	// serviceAccountNameLabel is set on the service account secrets to find back the service account to delete when it is removed from the spec.
+	serviceAccountNameLabel = "observability.giantswarm.io/service-account"
+	// serviceAccountTokenKey is the key of the service account token in the secret created for it.
+	serviceAccountTokenKey = "token"
 )
 
 // GrafanaOrganizationReconciler reconciles a GrafanaOrganization object
@@ -33,6 +52,31 @@ type GrafanaOrganizationReconciler struct {
 	client.Client
 	Scheme     *runtime.Scheme
 	GrafanaAPI *grafanaAPI.GrafanaHTTPAPI
+
+	// TenantLimit is the number of tenants above which a warning is emitted for an organization. There is no limit when it is 0.
+	TenantLimit int
+	// TenantIDMaxLength is the maximum length of the tenant IDs, stricter than the CRD limit. The CRD limit applies when it is 0.
+	TenantIDMaxLength int
+	// TenantIDPrefix is the prefix required on the tenant IDs. Tenant IDs are not required a prefix when it is empty.
+	TenantIDPrefix string
+	// ForbiddenTenantIDs are the tenant IDs which cannot be used by the organization.
+	ForbiddenTenantIDs []string
+	// AdminPattern is the pattern the admin org attribute values must fully match, e.g. the format of the organizations of the auth provider. Admins are not required a pattern when it is nil.
+	AdminPattern *regexp.Regexp
+	// ServiceAccountSecretNamespace is the namespace of the secrets holding the service account tokens.
+	ServiceAccountSecretNamespace string
+	// ManagedOrganizations are the display names of the organizations the operator is allowed to manage. All organizations are managed when it is empty.
+	ManagedOrganizations []string
+	// ResyncPeriod is the period after which the organization is reconciled again to repair drifted datasources. The organization is not resynced when it is 0.
+	ResyncPeriod time.Duration
+	// MaxConcurrentReconciles is the maximum number of organizations reconciled concurrently.
+	MaxConcurrentReconciles int
+	// AlertmanagerEnabled enables the configuration of the organization tenants Alertmanager.
+	AlertmanagerEnabled bool
+	// AlertmanagerService configures the Alertmanager of the organization tenants.
+	AlertmanagerService alertmanager.Service
+	// OrgIDHeader is the name of the header the datasources send the tenants in.
+	OrgIDHeader string
 }
 
 func SetupGrafanaOrganizationReconciler(mgr manager.Manager, conf config.Config) error {
@@ -42,9 +86,30 @@ func SetupGrafanaOrganizationReconciler(mgr manager.Manager, conf config.Config)
 	}
 
 	r := &GrafanaOrganizationReconciler{
-		Client:     mgr.GetClient(),
-		Scheme:     mgr.GetScheme(),
-		GrafanaAPI: grafanaAPI,
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		GrafanaAPI:  grafanaAPI,
+		TenantLimit: conf.GrafanaOrganizationTenantLimit,
+
+		ServiceAccountSecretNamespace: conf.GrafanaServiceAccountSecretNamespace,
+		ManagedOrganizations:          conf.GrafanaManagedOrganizations,
+		TenantIDMaxLength:             conf.GrafanaOrganizationTenantIDMaxLength,
+		TenantIDPrefix:                conf.GrafanaOrganizationTenantIDPrefix,
+		ForbiddenTenantIDs:            conf.GrafanaOrganizationForbiddenTenantIDs,
+		ResyncPeriod:                  conf.GrafanaOrganizationResyncPeriod,
+		MaxConcurrentReconciles:       conf.MaxConcurrentReconciles.GrafanaOrganization,
+		AlertmanagerEnabled:           conf.Monitoring.AlertmanagerEnabled,
+		AlertmanagerService:           alertmanager.New(conf),
+		OrgIDHeader:                   conf.Monitoring.OrgIDHeaderName(),
+	}
+	if conf.GrafanaOrganizationAdminPattern != "" {
+		r.AdminPattern, err = regexp.Compile(fmt.Sprintf("^(?:%s)$", conf.GrafanaOrganizationAdminPattern))
+		if err != nil {
+			return fmt.Errorf("invalid grafana organization admin pattern: %w", err)
+		}
+	}
+	if r.ServiceAccountSecretNamespace == "" {
+		r.ServiceAccountSecretNamespace = conf.OperatorNamespace
 	}
 
 	err = r.SetupWithManager(mgr)
@@ -52,6 +117,19 @@ func SetupGrafanaOrganizationReconciler(mgr manager.Manager, conf config.Config)
 		return err
 	}
 
+	if conf.GrafanaOrganizationJanitorEnabled {
+		err = mgr.Add(&GrafanaOrganizationJanitor{
+			Client:               mgr.GetClient(),
+			GrafanaAPI:           grafanaAPI,
+			Interval:             conf.GrafanaOrganizationJanitorInterval,
+			DryRun:               conf.GrafanaOrganizationJanitorDryRun,
+			ManagedOrganizations: conf.GrafanaManagedOrganizations,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -76,6 +154,11 @@ func (r *GrafanaOrganizationReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, errors.WithStack(client.IgnoreNotFound(err))
 	}
 
+	if isPaused(grafanaOrganization) {
+		logger.Info("Skipping grafana organization, reconciliation is paused", "annotation", pausedAnnotation)
+		return ctrl.Result{}, nil
+	}
+
 	// Handle deleted grafana organizations
 	if !grafanaOrganization.DeletionTimestamp.IsZero() {
 		return ctrl.Result{}, r.reconcileDelete(ctx, grafanaOrganization)
@@ -130,6 +213,7 @@ func (r *GrafanaOrganizationReconciler) SetupWithManager(mgr ctrl.Manager) error
 			}),
 			builder.WithPredicates(predicates.GrafanaPodRecreatedPredicate{}),
 		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
@@ -137,9 +221,9 @@ func (r *GrafanaOrganizationReconciler) SetupWithManager(mgr ctrl.Manager) error
 // reconcileCreate ensures the Grafana organization described in grafanaOrganization CR is created in Grafana.
 // This function is also responsible for:
 // - Adding the finalizer to the CR
-// - Updating the CR status field
+// - Updating the CR status field and conditions
 // - Renaming the Grafana Main Org.
-func (r GrafanaOrganizationReconciler) reconcileCreate(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization) (ctrl.Result, error) { // nolint:unparam
+func (r GrafanaOrganizationReconciler) reconcileCreate(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
 	// Add finalizer first if not set to avoid the race condition between init and delete.
@@ -160,33 +244,158 @@ func (r GrafanaOrganizationReconciler) reconcileCreate(ctx context.Context, graf
 		return ctrl.Result{}, nil
 	}
 
+	// Refuse to manage a Grafana organization the operator is not allowed to manage
+	if !grafana.IsManagedOrganization(r.ManagedOrganizations, grafanaOrganization.Spec.DisplayName) {
+		err := errors.Errorf("organization %q is not in the list of organizations managed by the operator", grafanaOrganization.Spec.DisplayName)
+		return ctrl.Result{}, r.setConditionsInvalid(ctx, grafanaOrganization, v1alpha1.OrganizationNotManagedReason, err)
+	}
+
+	// Refuse tenant IDs which do not follow the naming convention of the installation
+	if err := r.validateTenantIDs(grafanaOrganization); err != nil {
+		return ctrl.Result{}, r.setConditionsInvalid(ctx, grafanaOrganization, v1alpha1.InvalidTenantIDReason, err)
+	}
+
+	// Refuse admins which would not grant the admin role to anybody, e.g. because of a typo
+	if err := r.validateAdmins(grafanaOrganization); err != nil {
+		return ctrl.Result{}, r.setConditionsInvalid(ctx, grafanaOrganization, v1alpha1.InvalidRBACReason, err)
+	}
+
+	// Refuse to manage a Grafana organization already managed by another CR
+	if err := r.validateDisplayName(ctx, grafanaOrganization); err != nil {
+		return ctrl.Result{}, r.setConditionsFailed(ctx, grafanaOrganization, v1alpha1.DisplayNameConflictReason, err)
+	}
+
+	// Refuse to configure an invalid alerting, or the alerting of tenants the organization may not configure
+	if grafanaOrganization.Spec.Alerting != nil {
+		organizationList := v1alpha1.GrafanaOrganizationList{}
+		if err := r.Client.List(ctx, &organizationList); err != nil {
+			return ctrl.Result{}, r.setConditionsFailed(ctx, grafanaOrganization, v1alpha1.AlertingConfigurationFailedReason, err, v1alpha1.AlertingConfiguredCondition)
+		}
+		if err := validateAlerting(grafanaOrganization, organizationList.Items); err != nil {
+			return ctrl.Result{}, r.setConditionsInvalid(ctx, grafanaOrganization, v1alpha1.InvalidAlertingConfigurationReason, err, v1alpha1.AlertingConfiguredCondition)
+		}
+	}
+
+	// Refuse datasources which cannot be configured, e.g. overrides of a datasource type the organization does not have
+	if err := grafana.ValidateDatasources(newOrganization(grafanaOrganization)); err != nil {
+		return ctrl.Result{}, r.setConditionsInvalid(ctx, grafanaOrganization, v1alpha1.InvalidDatasourcesConfigurationReason, err, v1alpha1.DatasourcesConfiguredCondition)
+	}
+
+	// Record the number of tenants of the organization
+	r.recordTenants(ctx, grafanaOrganization)
+
 	// Configure the shared organization in Grafana
 	if err := r.configureSharedOrg(ctx); err != nil {
-		return ctrl.Result{}, errors.WithStack(err)
+		return ctrl.Result{}, r.setConditionsFailed(ctx, grafanaOrganization, v1alpha1.OrganizationConfigurationFailedReason, err)
 	}
 
 	// Configure the organization in Grafana
 	if err := r.configureOrganization(ctx, grafanaOrganization); err != nil {
-		return ctrl.Result{}, errors.WithStack(err)
+		return ctrl.Result{}, r.setConditionsFailed(ctx, grafanaOrganization, v1alpha1.OrganizationConfigurationFailedReason, err)
+	}
+
+	// Configure the organization preferences in Grafana
+	if err := r.configurePreferences(ctx, grafanaOrganization); err != nil {
+		return ctrl.Result{}, r.setConditionsFailed(ctx, grafanaOrganization, v1alpha1.OrganizationConfigurationFailedReason, err)
 	}
 
 	// Update the datasources in the CR's status
 	if err := r.configureDatasources(ctx, grafanaOrganization); err != nil {
-		return ctrl.Result{}, errors.WithStack(err)
+		return ctrl.Result{}, r.setConditionsFailed(ctx, grafanaOrganization, v1alpha1.DatasourcesConfigurationFailedReason, err, v1alpha1.DatasourcesConfiguredCondition)
+	}
+
+	// Provision the service accounts of the organization
+	if err := r.configureServiceAccounts(ctx, grafanaOrganization); err != nil {
+		return ctrl.Result{}, r.setConditionsFailed(ctx, grafanaOrganization, v1alpha1.ServiceAccountsConfigurationFailedReason, err)
+	}
+
+	// Configure the Alertmanager of the organization tenants
+	if err := r.configureAlerting(ctx, grafanaOrganization); err != nil {
+		return ctrl.Result{}, r.setConditionsFailed(ctx, grafanaOrganization, v1alpha1.AlertingConfigurationFailedReason, err, v1alpha1.AlertingConfiguredCondition)
 	}
 
 	// Configure Grafana RBAC
 	if err := r.configureGrafanaSSO(ctx); err != nil {
-		return ctrl.Result{}, errors.WithStack(err)
+		return ctrl.Result{}, r.setConditionsFailed(ctx, grafanaOrganization, v1alpha1.RBACConfigurationFailedReason, err, v1alpha1.RBACConfiguredCondition)
+	}
+
+	// Mark the organization as ready
+	succeededConditions := []string{v1alpha1.RBACConfiguredCondition, v1alpha1.ReadyCondition}
+	if grafanaOrganization.Spec.Alerting != nil && r.AlertmanagerEnabled {
+		succeededConditions = append(succeededConditions, v1alpha1.AlertingConfiguredCondition)
+	}
+	if setConditionsSucceeded(grafanaOrganization, succeededConditions...) {
+		if err := r.Status().Update(ctx, grafanaOrganization); err != nil {
+			logger.Error(err, "failed to update the grafanaOrganization status conditions")
+			return ctrl.Result{}, errors.WithStack(err)
+		}
+	}
+
+	// Resync the organization periodically as manual changes in Grafana, e.g. to the datasources, do not trigger a reconciliation
+	return ctrl.Result{RequeueAfter: r.ResyncPeriod}, nil
+}
+
+// setConditionsFailed sets the given conditions and the Ready condition to false with the reason and the error message.
+// The conditions are persisted in the status and the error is returned so the reconciliation is retried.
+func (r GrafanaOrganizationReconciler) setConditionsFailed(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization, reason string, err error, conditionTypes ...string) error {
+	logger := log.FromContext(ctx)
+
+	if updateErr := r.updateFailedConditions(ctx, grafanaOrganization, reason, err, conditionTypes...); updateErr != nil {
+		logger.Error(updateErr, "failed to update the grafanaOrganization status conditions")
 	}
 
-	return ctrl.Result{}, nil
+	return errors.WithStack(err)
+}
+
+// setConditionsInvalid sets the given conditions and the Ready condition to false because the spec of the organization is invalid.
+// The reconciliation is not retried as it cannot succeed until the spec is fixed, which triggers a new reconciliation.
+func (r GrafanaOrganizationReconciler) setConditionsInvalid(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization, reason string, err error, conditionTypes ...string) error {
+	logger := log.FromContext(ctx)
+
+	logger.Error(err, "invalid grafanaOrganization spec", "reason", reason)
+	if updateErr := r.updateFailedConditions(ctx, grafanaOrganization, reason, err, conditionTypes...); updateErr != nil {
+		logger.Error(updateErr, "failed to update the grafanaOrganization status conditions")
+		return errors.WithStack(updateErr)
+	}
+
+	return nil
+}
+
+// updateFailedConditions sets the given conditions and the Ready condition to false with the reason and the error message, and persists them in the status.
+func (r GrafanaOrganizationReconciler) updateFailedConditions(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization, reason string, err error, conditionTypes ...string) error {
+	for _, conditionType := range append(conditionTypes, v1alpha1.ReadyCondition) {
+		meta.SetStatusCondition(&grafanaOrganization.Status.Conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             metav1.ConditionFalse,
+			Reason:             reason,
+			Message:            err.Error(),
+			ObservedGeneration: grafanaOrganization.Generation,
+		})
+	}
+
+	return errors.WithStack(r.Status().Update(ctx, grafanaOrganization))
+}
+
+// setConditionsSucceeded sets the given conditions to true and returns whether any of them changed.
+// The conditions are persisted with the next status update.
+func setConditionsSucceeded(grafanaOrganization *v1alpha1.GrafanaOrganization, conditionTypes ...string) bool {
+	changed := false
+	for _, conditionType := range conditionTypes {
+		changed = meta.SetStatusCondition(&grafanaOrganization.Status.Conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             metav1.ConditionTrue,
+			Reason:             v1alpha1.ReconciliationSucceededReason,
+			ObservedGeneration: grafanaOrganization.Generation,
+		}) || changed
+	}
+	return changed
 }
 
 func (r GrafanaOrganizationReconciler) configureSharedOrg(ctx context.Context) error {
 	logger := log.FromContext(ctx)
 
 	sharedOrg := grafana.SharedOrg
+	sharedOrg.OrgIDHeader = r.OrgIDHeader
 
 	logger.Info("configuring shared organization")
 	if err := grafana.UpsertOrganization(ctx, r.GrafanaAPI, &sharedOrg); err != nil {
@@ -203,12 +412,115 @@ func (r GrafanaOrganizationReconciler) configureSharedOrg(ctx context.Context) e
 	return nil
 }
 
+// validateDisplayName ensures no other GrafanaOrganization CR manages the Grafana organization with the same display name.
+// When several CRs share a display name, the oldest one keeps managing the organization and the others are rejected.
+func (r GrafanaOrganizationReconciler) validateDisplayName(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization) error {
+	organizationList := v1alpha1.GrafanaOrganizationList{}
+	err := r.Client.List(ctx, &organizationList)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, other := range organizationList.Items {
+		if other.Name == grafanaOrganization.Name || other.Spec.DisplayName != grafanaOrganization.Spec.DisplayName {
+			continue
+		}
+
+		if isOlderOrganization(&other, grafanaOrganization) {
+			return errors.Errorf("display name %q is already used by GrafanaOrganization %q", grafanaOrganization.Spec.DisplayName, other.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateTenantIDs ensures the tenant IDs of the organization follow the constraints configured on top of the CRD validation.
+func (r GrafanaOrganizationReconciler) validateTenantIDs(grafanaOrganization *v1alpha1.GrafanaOrganization) error {
+	for _, tenant := range grafanaOrganization.Spec.Tenants {
+		tenantID := string(tenant)
+
+		if r.TenantIDMaxLength > 0 && len(tenantID) > r.TenantIDMaxLength {
+			return errors.Errorf("tenant ID %q exceeds the maximum length of %d characters", tenantID, r.TenantIDMaxLength)
+		}
+
+		if !strings.HasPrefix(tenantID, r.TenantIDPrefix) {
+			return errors.Errorf("tenant ID %q does not have the required prefix %q", tenantID, r.TenantIDPrefix)
+		}
+
+		if slices.Contains(r.ForbiddenTenantIDs, tenantID) {
+			return errors.Errorf("tenant ID %q is forbidden", tenantID)
+		}
+	}
+
+	return nil
+}
+
+// validateAdmins ensures the admins of the organization are non-empty, without surrounding whitespaces, and match the configured pattern.
+func (r GrafanaOrganizationReconciler) validateAdmins(grafanaOrganization *v1alpha1.GrafanaOrganization) error {
+	if grafanaOrganization.Spec.RBAC == nil {
+		return nil
+	}
+
+	for _, admin := range grafanaOrganization.Spec.RBAC.Admins {
+		if strings.TrimSpace(admin) == "" {
+			return errors.New("admin must not be empty")
+		}
+
+		if strings.TrimSpace(admin) != admin {
+			return errors.Errorf("admin %q must not have leading or trailing whitespaces", admin)
+		}
+
+		if r.AdminPattern != nil && !r.AdminPattern.MatchString(admin) {
+			return errors.Errorf("admin %q does not match the required pattern %q", admin, r.AdminPattern)
+		}
+	}
+
+	return nil
+}
+
+// recordTenants updates the tenants metric of the organization and warns when the organization exceeds the tenant limit.
+func (r GrafanaOrganizationReconciler) recordTenants(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization) {
+	logger := log.FromContext(ctx)
+
+	// The metric is labeled by the CR name as the display name can be changed.
+	tenants := len(grafanaOrganization.Spec.Tenants)
+	metrics.GrafanaOrganizationTenants.WithLabelValues(grafanaOrganization.Name).Set(float64(tenants))
+
+	if r.TenantLimit > 0 && tenants > r.TenantLimit {
+		logger.Info("organization exceeds the tenant limit", "tenants", tenants, "limit", r.TenantLimit)
+		record.Warnf(grafanaOrganization, "TenantLimitExceeded", "Organization has %d tenants, exceeding the limit of %d", tenants, r.TenantLimit)
+	}
+}
+
+// isOlderOrganization returns true if a was created before b, using the name to break ties.
+func isOlderOrganization(a, b *v1alpha1.GrafanaOrganization) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
 func newOrganization(grafanaOrganization *v1alpha1.GrafanaOrganization) grafana.Organization {
 	tenantIDs := make([]string, len(grafanaOrganization.Spec.Tenants))
 	for i, tenant := range grafanaOrganization.Spec.Tenants {
 		tenantIDs[i] = string(tenant)
 	}
 
+	federatedReadTenantIDs := make([]string, len(grafanaOrganization.Spec.FederatedReadTenants))
+	for i, tenant := range grafanaOrganization.Spec.FederatedReadTenants {
+		federatedReadTenantIDs[i] = string(tenant)
+	}
+
+	extraDatasources := make([]string, len(grafanaOrganization.Spec.ExtraDatasources))
+	for i, datasourceType := range grafanaOrganization.Spec.ExtraDatasources {
+		extraDatasources[i] = string(datasourceType)
+	}
+
+	datasourceJSONDataOverrides := make(map[string][]byte, len(grafanaOrganization.Spec.DatasourceJSONDataOverrides))
+	for datasourceType, override := range grafanaOrganization.Spec.DatasourceJSONDataOverrides {
+		datasourceJSONDataOverrides[datasourceType] = override.Raw
+	}
+
 	return grafana.Organization{
 		ID:        grafanaOrganization.Status.OrgID,
 		Name:      grafanaOrganization.Spec.DisplayName,
@@ -216,6 +528,13 @@ func newOrganization(grafanaOrganization *v1alpha1.GrafanaOrganization) grafana.
 		Admins:    grafanaOrganization.Spec.RBAC.Admins,
 		Editors:   grafanaOrganization.Spec.RBAC.Editors,
 		Viewers:   grafanaOrganization.Spec.RBAC.Viewers,
+
+		HomeDashboardUID: grafanaOrganization.Spec.DefaultHomeDashboardUID,
+		Theme:            grafanaOrganization.Spec.DefaultTheme,
+
+		ExtraDatasources:            extraDatasources,
+		DatasourceJSONDataOverrides: datasourceJSONDataOverrides,
+		FederatedReadTenantIDs:      federatedReadTenantIDs,
 	}
 }
 
@@ -223,7 +542,20 @@ func (r GrafanaOrganizationReconciler) configureOrganization(ctx context.Context
 	logger := log.FromContext(ctx)
 	// Create or update organization in Grafana
 	var organization = newOrganization(grafanaOrganization)
-	err := grafana.UpsertOrganization(ctx, r.GrafanaAPI, &organization)
+
+	// The organizations of the other GrafanaOrganizations must not be adopted
+	organizationList := v1alpha1.GrafanaOrganizationList{}
+	err := r.Client.List(ctx, &organizationList)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, other := range organizationList.Items {
+		if other.Name != grafanaOrganization.Name && other.Status.OrgID > 0 {
+			organization.OwnedOrgIDs = append(organization.OwnedOrgIDs, other.Status.OrgID)
+		}
+	}
+
+	err = grafana.UpsertOrganization(ctx, r.GrafanaAPI, &organization)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -243,6 +575,21 @@ func (r GrafanaOrganizationReconciler) configureOrganization(ctx context.Context
 	return nil
 }
 
+func (r GrafanaOrganizationReconciler) configurePreferences(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization) error {
+	logger := log.FromContext(ctx)
+
+	logger.Info("configuring organization preferences")
+
+	var organization = newOrganization(grafanaOrganization)
+	if err := grafana.ConfigureOrganizationPreferences(ctx, r.GrafanaAPI, organization); err != nil {
+		return errors.WithStack(err)
+	}
+
+	logger.Info("configured organization preferences")
+
+	return nil
+}
+
 func (r GrafanaOrganizationReconciler) configureDatasources(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization) error {
 	logger := log.FromContext(ctx)
 
@@ -250,6 +597,7 @@ func (r GrafanaOrganizationReconciler) configureDatasources(ctx context.Context,
 
 	// Create or update organization in Grafana
 	var organization = newOrganization(grafanaOrganization)
+	organization.OrgIDHeader = r.OrgIDHeader
 	datasources, err := grafana.ConfigureDefaultDatasources(ctx, r.GrafanaAPI, organization)
 	if err != nil {
 		return errors.WithStack(err)
@@ -265,6 +613,7 @@ func (r GrafanaOrganizationReconciler) configureDatasources(ctx context.Context,
 
 	logger.Info("updating datasources in the grafanaOrganization status")
 	grafanaOrganization.Status.DataSources = configuredDatasources
+	setConditionsSucceeded(grafanaOrganization, v1alpha1.DatasourcesConfiguredCondition)
 	if err := r.Status().Update(ctx, grafanaOrganization); err != nil {
 		logger.Error(err, "failed to update the the grafanaOrganization status with datasources information")
 		return errors.WithStack(err)
@@ -275,6 +624,104 @@ func (r GrafanaOrganizationReconciler) configureDatasources(ctx context.Context,
 	return nil
 }
 
+// configureServiceAccounts provisions the service accounts of the organization in Grafana and stores their token in a secret.
+// Tokens are only created when their secret does not exist so they are not rotated on every reconciliation.
+// Service accounts removed from the spec are deleted from Grafana together with their secret.
+func (r GrafanaOrganizationReconciler) configureServiceAccounts(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization) error {
+	logger := log.FromContext(ctx)
+
+	var organization = newOrganization(grafanaOrganization)
+	desiredSecrets := make(map[string]bool, len(grafanaOrganization.Spec.ServiceAccounts))
+	for _, serviceAccount := range grafanaOrganization.Spec.ServiceAccounts {
+		secretName := serviceAccountSecretName(grafanaOrganization, serviceAccount)
+		desiredSecrets[secretName] = true
+
+		secret := &v1.Secret{}
+		err := r.Client.Get(ctx, types.NamespacedName{
+			Name:      secretName,
+			Namespace: r.ServiceAccountSecretNamespace,
+		}, secret)
+		if err == nil {
+			// Keep the existing token and only make sure the role is up to date
+			err = grafana.ConfigureServiceAccount(ctx, r.GrafanaAPI, organization, serviceAccount.Name, serviceAccount.Role)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			continue
+		} else if !apierrors.IsNotFound(err) {
+			return errors.WithStack(err)
+		}
+
+		logger.Info("provisioning service account", "serviceAccount", serviceAccount.Name)
+		token, err := grafana.CreateServiceAccountToken(ctx, r.GrafanaAPI, organization, serviceAccount.Name, serviceAccount.Role)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		secret = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: r.ServiceAccountSecretNamespace,
+				Labels: map[string]string{
+					grafanaOrganizationNameLabel: grafanaOrganization.Name,
+					serviceAccountNameLabel:      serviceAccount.Name,
+				},
+			},
+			Data: map[string][]byte{
+				serviceAccountTokenKey: []byte(token),
+			},
+		}
+		if err := r.Client.Create(ctx, secret); err != nil {
+			return errors.WithStack(err)
+		}
+		logger.Info("provisioned service account", "serviceAccount", serviceAccount.Name, "secret", secret.Name)
+	}
+
+	// Delete the service accounts which are no longer in the spec, found back through the secret holding their token
+	secrets := &v1.SecretList{}
+	err := r.Client.List(ctx, secrets,
+		client.InNamespace(r.ServiceAccountSecretNamespace),
+		client.MatchingLabels{grafanaOrganizationNameLabel: grafanaOrganization.Name})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if desiredSecrets[secret.Name] {
+			continue
+		}
+
+		serviceAccountName, ok := secret.Labels[serviceAccountNameLabel]
+		if !ok {
+			continue
+		}
+
+		// The secret of a previous Grafana organization is deleted as well, its service account went away with the organization
+		if !slices.ContainsFunc(grafanaOrganization.Spec.ServiceAccounts, func(serviceAccount v1alpha1.ServiceAccount) bool {
+			return serviceAccount.Name == serviceAccountName
+		}) {
+			logger.Info("deleting service account", "serviceAccount", serviceAccountName)
+			err = grafana.DeleteServiceAccount(ctx, r.GrafanaAPI, organization, serviceAccountName)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if err := r.Client.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return errors.WithStack(err)
+		}
+		logger.Info("deleted service account secret", "serviceAccount", serviceAccountName, "secret", secret.Name)
+	}
+
+	return nil
+}
+
+// serviceAccountSecretName returns the name of the secret holding the token of the service account.
+// The Grafana organization ID is used rather than the GrafanaOrganization name so that two organizations cannot end up with the same secret name.
+func serviceAccountSecretName(grafanaOrganization *v1alpha1.GrafanaOrganization, serviceAccount v1alpha1.ServiceAccount) string {
+	return fmt.Sprintf("grafana-org-%d-%s-token", grafanaOrganization.Status.OrgID, serviceAccount.Name)
+}
+
 // reconcileDelete deletes the grafana organization.
 func (r GrafanaOrganizationReconciler) reconcileDelete(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization) error {
 	logger := log.FromContext(ctx)
@@ -284,6 +731,12 @@ func (r GrafanaOrganizationReconciler) reconcileDelete(ctx context.Context, graf
 		return nil
 	}
 
+	// Leave the Grafana organization untouched when the operator is not allowed to manage it
+	if !grafana.IsManagedOrganization(r.ManagedOrganizations, grafanaOrganization.Spec.DisplayName) {
+		logger.Info("organization is not managed by the operator, skipping its deletion in Grafana", "organization", grafanaOrganization.Spec.DisplayName)
+		return r.removeFinalizer(ctx, grafanaOrganization)
+	}
+
 	// Delete organization in Grafana
 	var organization = newOrganization(grafanaOrganization)
 
@@ -306,7 +759,34 @@ func (r GrafanaOrganizationReconciler) reconcileDelete(ctx context.Context, graf
 		return errors.WithStack(err)
 	}
 
+	metrics.GrafanaOrganizationTenants.DeleteLabelValues(grafanaOrganization.Name)
+
+	// Remove the Alertmanager configuration of the organization tenants
+	if r.AlertmanagerEnabled {
+		for _, tenant := range grafanaOrganization.Status.AlertingTenants {
+			err = r.AlertmanagerService.DeleteTenantConfiguration(ctx, string(tenant))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	// The service accounts are deleted together with the organization in Grafana
+	err = r.Client.DeleteAllOf(ctx, &v1.Secret{},
+		client.InNamespace(r.ServiceAccountSecretNamespace),
+		client.MatchingLabels{grafanaOrganizationNameLabel: grafanaOrganization.Name})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
 	// Finalizer handling needs to come last.
+	return r.removeFinalizer(ctx, grafanaOrganization)
+}
+
+// removeFinalizer removes the finalizer of the grafana organization.
+func (r GrafanaOrganizationReconciler) removeFinalizer(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization) error {
+	logger := log.FromContext(ctx)
+
 	// We use the patch from sigs.k8s.io/cluster-api/util/patch to handle the patching without conflicts
 	logger.Info("removing finalizer", "finalizer", v1alpha1.GrafanaOrganizationFinalizer)
 	patchHelper, err := patch.NewHelper(grafanaOrganization, r.Client)
@@ -347,3 +827,140 @@ func (r *GrafanaOrganizationReconciler) configureGrafanaSSO(ctx context.Context)
 
 	return nil
 }
+
+// validateAlerting ensures the alerting routing tree only references defined contact points and that the organization may configure the alerting of its tenants.
+// The platform tenant is configured by the AlertmanagerReconciler, and a tenant cannot be configured by two organizations as they would overwrite each other.
+func validateAlerting(grafanaOrganization *v1alpha1.GrafanaOrganization, organizations []v1alpha1.GrafanaOrganization) error {
+	if err := newTenantConfig(grafanaOrganization.Spec.Alerting).Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if slices.Contains(grafanaOrganization.Spec.Tenants, v1alpha1.TenantID(alertmanager.PlatformTenant)) {
+		return errors.Errorf("the alerting of the platform tenant %q cannot be configured by an organization", alertmanager.PlatformTenant)
+	}
+
+	for _, other := range organizations {
+		if other.Name == grafanaOrganization.Name {
+			continue
+		}
+
+		for _, tenant := range grafanaOrganization.Spec.Tenants {
+			if slices.Contains(other.Status.AlertingTenants, tenant) {
+				return errors.Errorf("the alerting of tenant %q is already configured by GrafanaOrganization %q", tenant, other.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// configureAlerting configures the Alertmanager of each tenant of the organization with its contact points and notification policies.
+// The configured tenants are recorded in the status, so the configuration of the tenants which are removed from the spec,
+// or of all of them when the alerting is removed, is deleted.
+func (r GrafanaOrganizationReconciler) configureAlerting(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization) error {
+	logger := log.FromContext(ctx)
+
+	if !r.AlertmanagerEnabled {
+		if grafanaOrganization.Spec.Alerting != nil {
+			logger.Info("alertmanager is disabled, skipping the alerting configuration")
+		}
+		return nil
+	}
+
+	var tenants []v1alpha1.TenantID
+	if grafanaOrganization.Spec.Alerting != nil {
+		tenants = grafanaOrganization.Spec.Tenants
+	}
+	if len(tenants) == 0 && len(grafanaOrganization.Status.AlertingTenants) == 0 {
+		return nil
+	}
+
+	logger.Info("configuring alerting")
+
+	// Record the tenants before configuring them, so they are cleaned up even when the reconciliation fails halfway
+	recordedTenants := slices.Clone(grafanaOrganization.Status.AlertingTenants)
+	for _, tenant := range tenants {
+		if !slices.Contains(recordedTenants, tenant) {
+			recordedTenants = append(recordedTenants, tenant)
+		}
+	}
+	if err := r.updateAlertingTenants(ctx, grafanaOrganization, recordedTenants); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if grafanaOrganization.Spec.Alerting != nil {
+		tenantConfig := newTenantConfig(grafanaOrganization.Spec.Alerting)
+		for _, tenant := range tenants {
+			if err := r.AlertmanagerService.ConfigureTenant(ctx, string(tenant), tenantConfig); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	for _, tenant := range recordedTenants {
+		if slices.Contains(tenants, tenant) {
+			continue
+		}
+
+		logger.Info("deleting the alerting configuration of the tenant", "tenant", tenant)
+		if err := r.AlertmanagerService.DeleteTenantConfiguration(ctx, string(tenant)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if err := r.updateAlertingTenants(ctx, grafanaOrganization, tenants); err != nil {
+		return errors.WithStack(err)
+	}
+
+	logger.Info("configured alerting")
+
+	return nil
+}
+
+// updateAlertingTenants records the tenants whose alerting is configured by the organization in its status.
+func (r GrafanaOrganizationReconciler) updateAlertingTenants(ctx context.Context, grafanaOrganization *v1alpha1.GrafanaOrganization, tenants []v1alpha1.TenantID) error {
+	if slices.Equal(grafanaOrganization.Status.AlertingTenants, tenants) {
+		return nil
+	}
+
+	grafanaOrganization.Status.AlertingTenants = slices.Clone(tenants)
+	if err := r.Status().Update(ctx, grafanaOrganization); err != nil {
+		log.FromContext(ctx).Error(err, "failed to update the alerting tenants in the grafanaOrganization status")
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// newTenantConfig returns the Alertmanager configuration of the organization tenants.
+func newTenantConfig(alerting *v1alpha1.Alerting) alertmanager.TenantConfig {
+	receivers := make([]alertmanager.Receiver, len(alerting.ContactPoints))
+	for i, contactPoint := range alerting.ContactPoints {
+		receivers[i] = alertmanager.Receiver{Name: contactPoint.Name}
+		for _, webhook := range contactPoint.Webhooks {
+			receivers[i].WebhookConfigs = append(receivers[i].WebhookConfigs, alertmanager.WebhookConfig{
+				URL:          webhook.URL,
+				SendResolved: webhook.SendResolved == nil || *webhook.SendResolved,
+			})
+		}
+	}
+
+	routes := make([]alertmanager.Route, len(alerting.NotificationPolicy.Routes))
+	for i, route := range alerting.NotificationPolicy.Routes {
+		routes[i] = alertmanager.Route{
+			Receiver: route.ContactPoint,
+			GroupBy:  route.GroupBy,
+			Matchers: route.Matchers,
+			Continue: route.Continue,
+		}
+	}
+
+	return alertmanager.TenantConfig{
+		Route: alertmanager.Route{
+			Receiver: alerting.NotificationPolicy.ContactPoint,
+			GroupBy:  alerting.NotificationPolicy.GroupBy,
+			Routes:   routes,
+		},
+		Receivers: receivers,
+	}
+}
@@ -1,7 +1,41 @@
 package controller
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	grafanaAPI "github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/client/datasources"
+	"github.com/grafana/grafana-openapi-client-go/client/org_preferences"
+	"github.com/grafana/grafana-openapi-client-go/client/service_accounts"
+	"github.com/grafana/grafana-openapi-client-go/client/sso_settings"
+	"github.com/grafana/grafana-openapi-client-go/models"
 	. "github.com/onsi/ginkgo/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/giantswarm/observability-operator/api/v1alpha1"
+	"github.com/giantswarm/observability-operator/pkg/alertmanager"
+	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
+	"github.com/giantswarm/observability-operator/pkg/config"
+	"github.com/giantswarm/observability-operator/pkg/metrics"
+	"github.com/giantswarm/observability-operator/pkg/monitoring"
 )
 
 var _ = Describe("Grafana Organization Controller", func() {
@@ -14,3 +48,1082 @@ var _ = Describe("Grafana Organization Controller", func() {
 		})
 	})
 })
+
+type fakeOrgPreferences struct {
+	org_preferences.ClientService
+
+	current *models.Preferences
+	patches []*models.PatchPrefsCmd
+}
+
+func (f *fakeOrgPreferences) GetOrgPreferences(opts ...org_preferences.ClientOption) (*org_preferences.GetOrgPreferencesOK, error) {
+	return &org_preferences.GetOrgPreferencesOK{Payload: f.current}, nil
+}
+
+func (f *fakeOrgPreferences) PatchOrgPreferences(body *models.PatchPrefsCmd, opts ...org_preferences.ClientOption) (*org_preferences.PatchOrgPreferencesOK, error) {
+	f.patches = append(f.patches, body)
+	return &org_preferences.PatchOrgPreferencesOK{}, nil
+}
+
+func TestConfigurePreferences(t *testing.T) {
+	tests := []struct {
+		name            string
+		spec            v1alpha1.GrafanaOrganizationSpec
+		current         *models.Preferences
+		expectedPatches []*models.PatchPrefsCmd
+	}{
+		{
+			name: "preferences are set when provided",
+			spec: v1alpha1.GrafanaOrganizationSpec{
+				DefaultHomeDashboardUID: "home",
+				DefaultTheme:            "dark",
+			},
+			current:         &models.Preferences{},
+			expectedPatches: []*models.PatchPrefsCmd{{HomeDashboardUID: "home", Theme: "dark"}},
+		},
+		{
+			name: "up to date preferences are not updated",
+			spec: v1alpha1.GrafanaOrganizationSpec{
+				DefaultTheme: "light",
+			},
+			current: &models.Preferences{HomeDashboardUID: "custom", Theme: "light"},
+		},
+		{
+			name:    "preferences are left untouched when not provided",
+			current: &models.Preferences{Theme: "light"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preferences := &fakeOrgPreferences{current: tt.current}
+			r := GrafanaOrganizationReconciler{
+				GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+					OrgPreferences: preferences,
+				},
+			}
+
+			tt.spec.DisplayName = "test"
+			tt.spec.RBAC = &v1alpha1.RBAC{Admins: []string{"admins"}}
+			grafanaOrganization := &v1alpha1.GrafanaOrganization{
+				Spec:   tt.spec,
+				Status: v1alpha1.GrafanaOrganizationStatus{OrgID: 2},
+			}
+
+			if err := r.configurePreferences(context.Background(), grafanaOrganization); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(preferences.patches) != len(tt.expectedPatches) {
+				t.Fatalf("expected %d patches, got %d", len(tt.expectedPatches), len(preferences.patches))
+			}
+			for i := range tt.expectedPatches {
+				if !reflect.DeepEqual(preferences.patches[i], tt.expectedPatches[i]) {
+					t.Errorf("expected patch %+v, got %+v", tt.expectedPatches[i], preferences.patches[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateDisplayName(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	now := time.Now()
+	newGrafanaOrganization := func(name string, displayName string, createdAt time.Time) *v1alpha1.GrafanaOrganization {
+		return &v1alpha1.GrafanaOrganization{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(createdAt)},
+			Spec:       v1alpha1.GrafanaOrganizationSpec{DisplayName: displayName},
+		}
+	}
+
+	existing := newGrafanaOrganization("existing", "Existing", now.Add(-time.Hour))
+
+	tests := []struct {
+		name          string
+		organization  *v1alpha1.GrafanaOrganization
+		expectedError string
+	}{
+		{
+			name:         "existing organization is not rejected by itself",
+			organization: existing,
+		},
+		{
+			name:          "newer organization with a duplicate display name is rejected",
+			organization:  newGrafanaOrganization("duplicate", "Existing", now),
+			expectedError: `display name "Existing" is already used by GrafanaOrganization "existing"`,
+		},
+		{
+			name:         "organization renamed to a free display name is accepted",
+			organization: newGrafanaOrganization("renamed", "Renamed", now),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []client.Object{existing}
+			if tt.organization != existing {
+				objects = append(objects, tt.organization)
+			}
+
+			r := GrafanaOrganizationReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			}
+
+			err := r.validateDisplayName(context.Background(), tt.organization)
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestValidateTenantIDs(t *testing.T) {
+	r := GrafanaOrganizationReconciler{
+		TenantIDMaxLength:  10,
+		TenantIDPrefix:     "gs",
+		ForbiddenTenantIDs: []string{"gsreserved"},
+	}
+
+	tests := []struct {
+		name          string
+		tenants       []v1alpha1.TenantID
+		expectedError bool
+	}{
+		{
+			name:    "valid tenant IDs",
+			tenants: []v1alpha1.TenantID{"gsteam", "gsother"},
+		},
+		{
+			name:          "missing prefix",
+			tenants:       []v1alpha1.TenantID{"gsteam", "team"},
+			expectedError: true,
+		},
+		{
+			name:          "too long",
+			tenants:       []v1alpha1.TenantID{"gsverylongteam"},
+			expectedError: true,
+		},
+		{
+			name:          "forbidden",
+			tenants:       []v1alpha1.TenantID{"gsreserved"},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grafanaOrganization := &v1alpha1.GrafanaOrganization{
+				Spec: v1alpha1.GrafanaOrganizationSpec{Tenants: tt.tenants},
+			}
+
+			err := r.validateTenantIDs(grafanaOrganization)
+			if tt.expectedError != (err != nil) {
+				t.Errorf("expected error %t, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestValidateAdmins(t *testing.T) {
+	tests := []struct {
+		name          string
+		adminPattern  *regexp.Regexp
+		admins        []string
+		expectedError bool
+	}{
+		{
+			name:   "valid admins",
+			admins: []string{"giantswarm-admins", "customer:admins"},
+		},
+		{
+			name:          "empty admin",
+			admins:        []string{"giantswarm-admins", ""},
+			expectedError: true,
+		},
+		{
+			name:          "whitespace only admin",
+			admins:        []string{"  "},
+			expectedError: true,
+		},
+		{
+			name:          "admin with surrounding whitespaces",
+			admins:        []string{" giantswarm-admins"},
+			expectedError: true,
+		},
+		{
+			name:         "admins matching the pattern",
+			adminPattern: regexp.MustCompile("^(?:[a-z-]+:[a-z-]+)$"),
+			admins:       []string{"giantswarm:admins"},
+		},
+		{
+			name:          "admin not matching the pattern",
+			adminPattern:  regexp.MustCompile("^(?:[a-z-]+:[a-z-]+)$"),
+			admins:        []string{"giantswarm:admins", "giantswarm-admins"},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := GrafanaOrganizationReconciler{AdminPattern: tt.adminPattern}
+			grafanaOrganization := &v1alpha1.GrafanaOrganization{
+				Spec: v1alpha1.GrafanaOrganizationSpec{RBAC: &v1alpha1.RBAC{Admins: tt.admins}},
+			}
+
+			err := r.validateAdmins(grafanaOrganization)
+			if tt.expectedError != (err != nil) {
+				t.Errorf("expected error %t, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestRecordTenants(t *testing.T) {
+	metrics.GrafanaOrganizationTenants.Reset()
+	r := GrafanaOrganizationReconciler{TenantLimit: 2}
+
+	grafanaOrganization := &v1alpha1.GrafanaOrganization{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenants"},
+		Spec: v1alpha1.GrafanaOrganizationSpec{
+			DisplayName: "Tenants",
+			Tenants:     []v1alpha1.TenantID{"first", "second", "third"},
+		},
+	}
+
+	r.recordTenants(context.Background(), grafanaOrganization)
+	if got := testutil.ToFloat64(metrics.GrafanaOrganizationTenants.WithLabelValues("tenants")); got != 3 {
+		t.Errorf("expected 3 tenants, got %v", got)
+	}
+
+	// Renaming the organization keeps a single series.
+	grafanaOrganization.Spec.DisplayName = "Renamed"
+	grafanaOrganization.Spec.Tenants = grafanaOrganization.Spec.Tenants[:1]
+	r.recordTenants(context.Background(), grafanaOrganization)
+	if got := testutil.ToFloat64(metrics.GrafanaOrganizationTenants.WithLabelValues("tenants")); got != 1 {
+		t.Errorf("expected 1 tenant, got %v", got)
+	}
+	if got := testutil.CollectAndCount(metrics.GrafanaOrganizationTenants); got != 1 {
+		t.Errorf("expected a single series, got %d", got)
+	}
+}
+
+type fakeServiceAccounts struct {
+	service_accounts.ClientService
+
+	existing []*models.ServiceAccountDTO
+	created  []*models.CreateServiceAccountForm
+	tokens   []int64
+	updated  []string
+	deleted  []int64
+}
+
+func (f *fakeServiceAccounts) SearchOrgServiceAccountsWithPaging(params *service_accounts.SearchOrgServiceAccountsWithPagingParams, opts ...service_accounts.ClientOption) (*service_accounts.SearchOrgServiceAccountsWithPagingOK, error) {
+	return &service_accounts.SearchOrgServiceAccountsWithPagingOK{
+		Payload: &models.SearchOrgServiceAccountsResult{ServiceAccounts: f.existing},
+	}, nil
+}
+
+func (f *fakeServiceAccounts) CreateServiceAccount(params *service_accounts.CreateServiceAccountParams, opts ...service_accounts.ClientOption) (*service_accounts.CreateServiceAccountCreated, error) {
+	f.created = append(f.created, params.Body)
+	return &service_accounts.CreateServiceAccountCreated{
+		Payload: &models.ServiceAccountDTO{ID: int64(100 + len(f.created)), Name: params.Body.Name},
+	}, nil
+}
+
+func (f *fakeServiceAccounts) CreateToken(params *service_accounts.CreateTokenParams, opts ...service_accounts.ClientOption) (*service_accounts.CreateTokenOK, error) {
+	f.tokens = append(f.tokens, params.ServiceAccountID)
+	return &service_accounts.CreateTokenOK{
+		Payload: &models.NewAPIKeyResult{Key: "glsa_token"},
+	}, nil
+}
+
+func (f *fakeServiceAccounts) UpdateServiceAccount(params *service_accounts.UpdateServiceAccountParams, opts ...service_accounts.ClientOption) (*service_accounts.UpdateServiceAccountOK, error) {
+	f.updated = append(f.updated, params.Body.Role)
+	return &service_accounts.UpdateServiceAccountOK{}, nil
+}
+
+func (f *fakeServiceAccounts) DeleteServiceAccount(serviceAccountID int64, opts ...service_accounts.ClientOption) (*service_accounts.DeleteServiceAccountOK, error) {
+	f.deleted = append(f.deleted, serviceAccountID)
+	return &service_accounts.DeleteServiceAccountOK{}, nil
+}
+
+func TestConfigureServiceAccounts(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name            string
+		existing        []*models.ServiceAccountDTO
+		existingSecret  bool
+		removedSecret   bool
+		expectedCreated int
+		expectedTokens  []int64
+		expectedUpdated []string
+		expectedDeleted []int64
+	}{
+		{
+			name:            "service account and token are created",
+			expectedCreated: 1,
+			expectedTokens:  []int64{101},
+		},
+		{
+			name:           "token is created for an existing service account",
+			existing:       []*models.ServiceAccountDTO{{ID: 7, Name: "pusher-old"}, {ID: 8, Name: "pusher", Role: "Editor"}},
+			expectedTokens: []int64{8},
+		},
+		{
+			name:           "nothing is created when the token secret exists",
+			existing:       []*models.ServiceAccountDTO{{ID: 8, Name: "pusher", Role: "Editor"}},
+			existingSecret: true,
+		},
+		{
+			name:            "role of an existing service account is updated",
+			existing:        []*models.ServiceAccountDTO{{ID: 8, Name: "pusher", Role: "Viewer"}},
+			existingSecret:  true,
+			expectedUpdated: []string{"Editor"},
+		},
+		{
+			name:            "service account removed from the spec is deleted with its secret",
+			existing:        []*models.ServiceAccountDTO{{ID: 8, Name: "pusher", Role: "Editor"}, {ID: 9, Name: "old", Role: "Viewer"}},
+			existingSecret:  true,
+			removedSecret:   true,
+			expectedDeleted: []int64{9},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grafanaOrganization := &v1alpha1.GrafanaOrganization{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: v1alpha1.GrafanaOrganizationSpec{
+					DisplayName:     "Test",
+					RBAC:            &v1alpha1.RBAC{Admins: []string{"admins"}},
+					ServiceAccounts: []v1alpha1.ServiceAccount{{Name: "pusher", Role: "Editor"}},
+				},
+				Status: v1alpha1.GrafanaOrganizationStatus{OrgID: 2},
+			}
+
+			secretKey := types.NamespacedName{Name: "grafana-org-2-pusher-token", Namespace: "monitoring"}
+			removedSecretKey := types.NamespacedName{Name: "grafana-org-2-old-token", Namespace: "monitoring"}
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			if tt.existingSecret {
+				builder = builder.WithObjects(&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: secretKey.Name, Namespace: secretKey.Namespace},
+					Data:       map[string][]byte{"token": []byte("existing")},
+				})
+			}
+			if tt.removedSecret {
+				builder = builder.WithObjects(&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      removedSecretKey.Name,
+						Namespace: removedSecretKey.Namespace,
+						Labels: map[string]string{
+							grafanaOrganizationNameLabel: "test",
+							serviceAccountNameLabel:      "old",
+						},
+					},
+					Data: map[string][]byte{"token": []byte("removed")},
+				})
+			}
+
+			serviceAccounts := &fakeServiceAccounts{existing: tt.existing}
+			r := GrafanaOrganizationReconciler{
+				Client: builder.Build(),
+				GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+					ServiceAccounts: serviceAccounts,
+				},
+				ServiceAccountSecretNamespace: "monitoring",
+			}
+
+			if err := r.configureServiceAccounts(context.Background(), grafanaOrganization); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(serviceAccounts.created) != tt.expectedCreated {
+				t.Errorf("expected %d service accounts to be created, got %d", tt.expectedCreated, len(serviceAccounts.created))
+			}
+			if !reflect.DeepEqual(serviceAccounts.tokens, tt.expectedTokens) {
+				t.Errorf("expected tokens to be created for %v, got %v", tt.expectedTokens, serviceAccounts.tokens)
+			}
+			if !reflect.DeepEqual(serviceAccounts.updated, tt.expectedUpdated) {
+				t.Errorf("expected roles to be updated to %v, got %v", tt.expectedUpdated, serviceAccounts.updated)
+			}
+			if !reflect.DeepEqual(serviceAccounts.deleted, tt.expectedDeleted) {
+				t.Errorf("expected service accounts %v to be deleted, got %v", tt.expectedDeleted, serviceAccounts.deleted)
+			}
+			if tt.removedSecret {
+				err := r.Client.Get(context.Background(), removedSecretKey, &v1.Secret{})
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("expected the secret of the removed service account to be deleted, got %v", err)
+				}
+			}
+
+			secret := &v1.Secret{}
+			if err := r.Client.Get(context.Background(), secretKey, secret); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectedToken := "glsa_token"
+			if tt.existingSecret {
+				expectedToken = "existing"
+			}
+			if string(secret.Data["token"]) != expectedToken {
+				t.Errorf("expected token %q, got %q", expectedToken, secret.Data["token"])
+			}
+		})
+	}
+}
+
+type fakeDatasources struct {
+	datasources.ClientService
+
+	created int64
+	updated []string
+	// current holds the datasources as Grafana lists them
+	current models.DataSourceList
+}
+
+func (f *fakeDatasources) GetDataSources(opts ...datasources.ClientOption) (*datasources.GetDataSourcesOK, error) {
+	return &datasources.GetDataSourcesOK{Payload: f.current}, nil
+}
+
+func (f *fakeDatasources) AddDataSource(body *models.AddDataSourceCommand, opts ...datasources.ClientOption) (*datasources.AddDataSourceOK, error) {
+	f.created++
+	id := f.created
+	f.current = append(f.current, &models.DataSourceListItemDTO{
+		ID:        id,
+		Name:      body.Name,
+		Type:      body.Type,
+		URL:       body.URL,
+		IsDefault: body.IsDefault,
+		Access:    body.Access,
+		JSONData:  body.JSONData,
+	})
+	return &datasources.AddDataSourceOK{Payload: &models.AddDataSourceOKBody{ID: &id}}, nil
+}
+
+func (f *fakeDatasources) UpdateDataSourceByID(id string, body *models.UpdateDataSourceCommand, opts ...datasources.ClientOption) (*datasources.UpdateDataSourceByIDOK, error) {
+	f.updated = append(f.updated, body.Name)
+	return &datasources.UpdateDataSourceByIDOK{}, nil
+}
+
+type fakeSsoSettings struct {
+	sso_settings.ClientService
+
+	err error
+}
+
+func (f *fakeSsoSettings) GetProviderSettings(key string, opts ...sso_settings.ClientOption) (*sso_settings.GetProviderSettingsOK, error) {
+	return &sso_settings.GetProviderSettingsOK{
+		Payload: &models.GetProviderSettingsOKBody{Provider: key, Settings: map[string]interface{}{}},
+	}, nil
+}
+
+func (f *fakeSsoSettings) UpdateProviderSettings(key string, body *models.UpdateProviderSettingsParamsBody, opts ...sso_settings.ClientOption) (*sso_settings.UpdateProviderSettingsNoContent, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &sso_settings.UpdateProviderSettingsNoContent{}, nil
+}
+
+func TestReconcileCreateConditions(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	grafanaOrganization := &v1alpha1.GrafanaOrganization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Finalizers: []string{v1alpha1.GrafanaOrganizationFinalizer},
+		},
+		Spec: v1alpha1.GrafanaOrganizationSpec{
+			DisplayName: "Test",
+			RBAC:        &v1alpha1.RBAC{Admins: []string{"admins"}},
+			Tenants:     []v1alpha1.TenantID{"test"},
+		},
+		Status: v1alpha1.GrafanaOrganizationStatus{OrgID: 2},
+	}
+
+	ssoSettings := &fakeSsoSettings{}
+	r := GrafanaOrganizationReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(grafanaOrganization).
+			WithStatusSubresource(grafanaOrganization).
+			Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:           &fakeOrgs{names: map[int64]string{1: "Shared Org", 2: "Test"}},
+			Datasources:    &fakeDatasources{},
+			OrgPreferences: &fakeOrgPreferences{current: &models.Preferences{}},
+			SsoSettings:    ssoSettings,
+		},
+	}
+
+	assertConditions := func(expected map[string]metav1.ConditionStatus, expectedReason string) {
+		t.Helper()
+
+		current := &v1alpha1.GrafanaOrganization{}
+		if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(grafanaOrganization), current); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for conditionType, status := range expected {
+			condition := meta.FindStatusCondition(current.Status.Conditions, conditionType)
+			if condition == nil {
+				t.Fatalf("expected condition %s to be set", conditionType)
+			}
+			if condition.Status != status {
+				t.Errorf("expected condition %s to be %s, got %s", conditionType, status, condition.Status)
+			}
+			if status == metav1.ConditionFalse && condition.Reason != expectedReason {
+				t.Errorf("expected condition %s reason %s, got %s", conditionType, expectedReason, condition.Reason)
+			}
+		}
+	}
+
+	// All conditions are true when the organization is reconciled.
+	current := grafanaOrganization.DeepCopy()
+	if _, err := r.reconcileCreate(context.Background(), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertConditions(map[string]metav1.ConditionStatus{
+		v1alpha1.ReadyCondition:                 metav1.ConditionTrue,
+		v1alpha1.DatasourcesConfiguredCondition: metav1.ConditionTrue,
+		v1alpha1.RBACConfiguredCondition:        metav1.ConditionTrue,
+	}, "")
+
+	// The RBAC and Ready conditions turn false when Grafana fails to configure the role mapping.
+	ssoSettings.err = errors.New("grafana is unavailable")
+	if _, err := r.reconcileCreate(context.Background(), current); err == nil {
+		t.Fatalf("expected an error")
+	}
+	assertConditions(map[string]metav1.ConditionStatus{
+		v1alpha1.ReadyCondition:                 metav1.ConditionFalse,
+		v1alpha1.DatasourcesConfiguredCondition: metav1.ConditionTrue,
+		v1alpha1.RBACConfiguredCondition:        metav1.ConditionFalse,
+	}, v1alpha1.RBACConfigurationFailedReason)
+}
+
+func TestReconcileCreateInvalidSpec(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name           string
+		tenants        []v1alpha1.TenantID
+		admins         []string
+		expectedReason string
+	}{
+		{
+			name:           "tenant ID not following the naming convention",
+			tenants:        []v1alpha1.TenantID{"other"},
+			admins:         []string{"admins"},
+			expectedReason: v1alpha1.InvalidTenantIDReason,
+		},
+		{
+			name:           "admin with surrounding whitespaces",
+			tenants:        []v1alpha1.TenantID{"gstest"},
+			admins:         []string{" admins"},
+			expectedReason: v1alpha1.InvalidRBACReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grafanaOrganization := &v1alpha1.GrafanaOrganization{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test",
+					Finalizers: []string{v1alpha1.GrafanaOrganizationFinalizer},
+				},
+				Spec: v1alpha1.GrafanaOrganizationSpec{
+					DisplayName: "Test",
+					RBAC:        &v1alpha1.RBAC{Admins: tt.admins},
+					Tenants:     tt.tenants,
+				},
+			}
+
+			r := GrafanaOrganizationReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(grafanaOrganization).
+					WithStatusSubresource(grafanaOrganization).
+					Build(),
+				TenantIDPrefix: "gs",
+			}
+
+			// A spec typo is not retried until the spec changes, the condition is enough
+			if _, err := r.reconcileCreate(context.Background(), grafanaOrganization.DeepCopy()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			current := &v1alpha1.GrafanaOrganization{}
+			if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(grafanaOrganization), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			condition := meta.FindStatusCondition(current.Status.Conditions, v1alpha1.ReadyCondition)
+			if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != tt.expectedReason {
+				t.Errorf("expected condition %s to be false with reason %s, got %+v", v1alpha1.ReadyCondition, tt.expectedReason, condition)
+			}
+		})
+	}
+}
+
+func TestReconcileCreateInvalidDatasources(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name      string
+		overrides map[string]runtime.RawExtension
+	}{
+		{
+			name:      "override of a datasource type the organization does not have",
+			overrides: map[string]runtime.RawExtension{"graphite": {Raw: []byte(`{"graphiteVersion": "1.2"}`)}},
+		},
+		{
+			name:      "override of the tenant header",
+			overrides: map[string]runtime.RawExtension{"prometheus": {Raw: []byte(`{"httpHeaderName1": "X-Other"}`)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grafanaOrganization := &v1alpha1.GrafanaOrganization{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test",
+					Finalizers: []string{v1alpha1.GrafanaOrganizationFinalizer},
+				},
+				Spec: v1alpha1.GrafanaOrganizationSpec{
+					DisplayName:                 "Test",
+					RBAC:                        &v1alpha1.RBAC{Admins: []string{"admins"}},
+					Tenants:                     []v1alpha1.TenantID{"test"},
+					DatasourceJSONDataOverrides: tt.overrides,
+				},
+				Status: v1alpha1.GrafanaOrganizationStatus{OrgID: 2},
+			}
+
+			datasources := &fakeDatasources{}
+			r := GrafanaOrganizationReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(grafanaOrganization).
+					WithStatusSubresource(grafanaOrganization).
+					Build(),
+				GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+					Orgs:           &fakeOrgs{names: map[int64]string{1: "Shared Org", 2: "Test"}},
+					Datasources:    datasources,
+					OrgPreferences: &fakeOrgPreferences{current: &models.Preferences{}},
+					SsoSettings:    &fakeSsoSettings{},
+				},
+			}
+
+			// An invalid spec is not retried until it changes, the condition is enough
+			if _, err := r.reconcileCreate(context.Background(), grafanaOrganization.DeepCopy()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			current := &v1alpha1.GrafanaOrganization{}
+			if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(grafanaOrganization), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, conditionType := range []string{v1alpha1.ReadyCondition, v1alpha1.DatasourcesConfiguredCondition} {
+				condition := meta.FindStatusCondition(current.Status.Conditions, conditionType)
+				if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != v1alpha1.InvalidDatasourcesConfigurationReason {
+					t.Errorf("expected condition %s to be false with reason %s, got %+v", conditionType, v1alpha1.InvalidDatasourcesConfigurationReason, condition)
+				}
+			}
+			if datasources.created != 0 || len(datasources.updated) != 0 {
+				t.Errorf("expected no datasource change, got %d created and %v updated", datasources.created, datasources.updated)
+			}
+		})
+	}
+}
+
+func TestReconcileCreateManagedOrganizations(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name                 string
+		managedOrganizations []string
+		expectedReason       string
+	}{
+		{
+			name: "all organizations managed",
+		},
+		{
+			name:                 "organization managed",
+			managedOrganizations: []string{"Test"},
+		},
+		{
+			name:                 "organization not managed",
+			managedOrganizations: []string{"Other"},
+			expectedReason:       v1alpha1.OrganizationNotManagedReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grafanaOrganization := &v1alpha1.GrafanaOrganization{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test",
+					Finalizers: []string{v1alpha1.GrafanaOrganizationFinalizer},
+				},
+				Spec: v1alpha1.GrafanaOrganizationSpec{
+					DisplayName: "Test",
+					RBAC:        &v1alpha1.RBAC{Admins: []string{"admins"}},
+					Tenants:     []v1alpha1.TenantID{"test"},
+				},
+				Status: v1alpha1.GrafanaOrganizationStatus{OrgID: 2},
+			}
+
+			r := GrafanaOrganizationReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(grafanaOrganization).
+					WithStatusSubresource(grafanaOrganization).
+					Build(),
+				GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+					Orgs:           &fakeOrgs{names: map[int64]string{1: "Shared Org", 2: "Test"}},
+					Datasources:    &fakeDatasources{},
+					OrgPreferences: &fakeOrgPreferences{current: &models.Preferences{}},
+					SsoSettings:    &fakeSsoSettings{},
+				},
+				ManagedOrganizations: tt.managedOrganizations,
+			}
+
+			// An organization which is not managed is not retried, the condition is enough
+			_, err := r.reconcileCreate(context.Background(), grafanaOrganization.DeepCopy())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			current := &v1alpha1.GrafanaOrganization{}
+			if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(grafanaOrganization), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			condition := meta.FindStatusCondition(current.Status.Conditions, v1alpha1.ReadyCondition)
+			if condition == nil {
+				t.Fatalf("expected condition %s to be set", v1alpha1.ReadyCondition)
+			}
+			if tt.expectedReason == "" && condition.Status != metav1.ConditionTrue {
+				t.Errorf("expected condition %s to be true, got %s", v1alpha1.ReadyCondition, condition.Status)
+			}
+			if tt.expectedReason != "" && (condition.Status != metav1.ConditionFalse || condition.Reason != tt.expectedReason) {
+				t.Errorf("expected condition %s to be false with reason %s, got %s with reason %s", v1alpha1.ReadyCondition, tt.expectedReason, condition.Status, condition.Reason)
+			}
+		})
+	}
+}
+
+func TestNewTenantConfig(t *testing.T) {
+	sendResolved := false
+	alerting := &v1alpha1.Alerting{
+		ContactPoints: []v1alpha1.ContactPoint{
+			{
+				Name:     "team-slack",
+				Webhooks: []v1alpha1.WebhookConfig{{URL: "https://example.com/slack"}},
+			},
+			{
+				Name:     "team-pager",
+				Webhooks: []v1alpha1.WebhookConfig{{URL: "https://example.com/pager", SendResolved: &sendResolved}},
+			},
+		},
+		NotificationPolicy: v1alpha1.NotificationPolicy{
+			ContactPoint: "team-slack",
+			GroupBy:      []string{"alertname", "cluster_id"},
+			Routes: []v1alpha1.NotificationRoute{
+				{
+					ContactPoint: "team-pager",
+					Matchers:     []string{`severity="page"`},
+					Continue:     true,
+				},
+			},
+		},
+	}
+
+	config, err := newTenantConfig(alerting).Generate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `receivers:
+- name: team-slack
+  webhook_configs:
+  - send_resolved: true
+    url: https://example.com/slack
+- name: team-pager
+  webhook_configs:
+  - send_resolved: false
+    url: https://example.com/pager
+route:
+  group_by:
+  - alertname
+  - cluster_id
+  receiver: team-slack
+  routes:
+  - continue: true
+    matchers:
+    - severity="page"
+    receiver: team-pager
+`
+	if string(config) != expected {
+		t.Errorf("expected config:\n%s\ngot:\n%s", expected, config)
+	}
+
+	// Routes referencing undefined contact points are rejected
+	alerting.NotificationPolicy.Routes[0].ContactPoint = "team-missing"
+	if _, err := newTenantConfig(alerting).Generate(); err == nil {
+		t.Errorf("expected an error for an undefined contact point")
+	}
+}
+
+func TestReconcileCreateRepairsDatasourceDrift(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	grafanaOrganization := &v1alpha1.GrafanaOrganization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Finalizers: []string{v1alpha1.GrafanaOrganizationFinalizer},
+		},
+		Spec: v1alpha1.GrafanaOrganizationSpec{
+			DisplayName: "Test",
+			RBAC:        &v1alpha1.RBAC{Admins: []string{"admins"}},
+			Tenants:     []v1alpha1.TenantID{"test"},
+		},
+		Status: v1alpha1.GrafanaOrganizationStatus{OrgID: 2},
+	}
+
+	grafanaDatasources := &fakeDatasources{}
+	r := GrafanaOrganizationReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(grafanaOrganization).
+			WithStatusSubresource(grafanaOrganization).
+			Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:           &fakeOrgs{names: map[int64]string{1: "Shared Org", 2: "Test"}},
+			Datasources:    grafanaDatasources,
+			OrgPreferences: &fakeOrgPreferences{current: &models.Preferences{}},
+			SsoSettings:    &fakeSsoSettings{},
+		},
+		ResyncPeriod: 10 * time.Minute,
+	}
+
+	current := grafanaOrganization.DeepCopy()
+	result, err := r.reconcileCreate(context.Background(), current)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RequeueAfter != r.ResyncPeriod {
+		t.Errorf("expected the organization to be resynced after %s, got %s", r.ResyncPeriod, result.RequeueAfter)
+	}
+
+	// The fake does not isolate the datasources of each organization, so only the organization datasources are configured from now on.
+	grafanaDatasources = &fakeDatasources{}
+	r.GrafanaAPI.Datasources = grafanaDatasources
+	for range 2 {
+		if err := r.configureDatasources(context.Background(), current); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(grafanaDatasources.updated) != 0 {
+		t.Fatalf("expected no datasource update, got %v", grafanaDatasources.updated)
+	}
+
+	// Someone manually edits a datasource in Grafana
+	grafanaDatasources.current[0].URL = "http://drifted"
+
+	if err := r.configureDatasources(context.Background(), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(grafanaDatasources.updated) != 1 || grafanaDatasources.updated[0] != grafanaDatasources.current[0].Name {
+		t.Errorf("expected the drifted datasource %q to be updated, got %v", grafanaDatasources.current[0].Name, grafanaDatasources.updated)
+	}
+}
+
+func TestReconcileGrafanaOrganizationPaused(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	tests := []struct {
+		name              string
+		finalizers        []string
+		deletionTimestamp *metav1.Time
+	}{
+		{
+			name: "new organization gets no finalizer",
+		},
+		{
+			name:              "deleted organization keeps its finalizer",
+			finalizers:        []string{v1alpha1.GrafanaOrganizationFinalizer},
+			deletionTimestamp: &metav1.Time{Time: time.Now()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grafanaOrganization := &v1alpha1.GrafanaOrganization{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test",
+					Annotations:       map[string]string{pausedAnnotation: "true"},
+					Finalizers:        tt.finalizers,
+					DeletionTimestamp: tt.deletionTimestamp,
+				},
+				Spec: v1alpha1.GrafanaOrganizationSpec{
+					DisplayName: "Test",
+					RBAC:        &v1alpha1.RBAC{Admins: []string{"admins"}},
+					Tenants:     []v1alpha1.TenantID{"test"},
+				},
+				Status: v1alpha1.GrafanaOrganizationStatus{OrgID: 2},
+			}
+
+			// Grafana is not configured as a paused organization must not be written to
+			r := GrafanaOrganizationReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(grafanaOrganization).
+					WithStatusSubresource(grafanaOrganization).
+					Build(),
+			}
+
+			if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(grafanaOrganization)}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			current := &v1alpha1.GrafanaOrganization{}
+			if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(grafanaOrganization), current); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(current.Finalizers, tt.finalizers) {
+				t.Errorf("expected finalizers %v, got %v", tt.finalizers, current.Finalizers)
+			}
+			if len(current.Status.Conditions) != 0 {
+				t.Errorf("expected no status condition to be set, got %v", current.Status.Conditions)
+			}
+		})
+	}
+}
+
+func TestValidateAlerting(t *testing.T) {
+	alerting := &v1alpha1.Alerting{
+		ContactPoints:      []v1alpha1.ContactPoint{{Name: "team"}},
+		NotificationPolicy: v1alpha1.NotificationPolicy{ContactPoint: "team"},
+	}
+
+	tests := []struct {
+		name          string
+		tenants       []v1alpha1.TenantID
+		alerting      *v1alpha1.Alerting
+		other         v1alpha1.GrafanaOrganizationStatus
+		expectedError bool
+	}{
+		{
+			name:     "valid alerting",
+			tenants:  []v1alpha1.TenantID{"atlas"},
+			alerting: alerting,
+			other:    v1alpha1.GrafanaOrganizationStatus{AlertingTenants: []v1alpha1.TenantID{"shield"}},
+		},
+		{
+			name:          "undefined contact point",
+			tenants:       []v1alpha1.TenantID{"atlas"},
+			alerting:      &v1alpha1.Alerting{ContactPoints: []v1alpha1.ContactPoint{{Name: "team"}}, NotificationPolicy: v1alpha1.NotificationPolicy{ContactPoint: "other"}},
+			expectedError: true,
+		},
+		{
+			name:          "platform tenant",
+			tenants:       []v1alpha1.TenantID{"atlas", alertmanager.PlatformTenant},
+			alerting:      alerting,
+			expectedError: true,
+		},
+		{
+			name:          "tenant configured by another organization",
+			tenants:       []v1alpha1.TenantID{"atlas"},
+			alerting:      alerting,
+			other:         v1alpha1.GrafanaOrganizationStatus{AlertingTenants: []v1alpha1.TenantID{"atlas"}},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grafanaOrganization := &v1alpha1.GrafanaOrganization{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec:       v1alpha1.GrafanaOrganizationSpec{Tenants: tt.tenants, Alerting: tt.alerting},
+			}
+			organizations := []v1alpha1.GrafanaOrganization{
+				*grafanaOrganization,
+				{ObjectMeta: metav1.ObjectMeta{Name: "other"}, Status: tt.other},
+			}
+
+			err := validateAlerting(grafanaOrganization, organizations)
+			if tt.expectedError && err == nil {
+				t.Errorf("expected an error")
+			} else if !tt.expectedError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfigureAlertingRemovedTenants(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.Header.Get(commonmonitoring.OrgIDHeader))
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	scheme := newTestScheme(t)
+
+	// The shield tenant was removed from the spec since the last reconciliation.
+	grafanaOrganization := &v1alpha1.GrafanaOrganization{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: v1alpha1.GrafanaOrganizationSpec{
+			DisplayName: "Test",
+			Tenants:     []v1alpha1.TenantID{"atlas"},
+			Alerting: &v1alpha1.Alerting{
+				ContactPoints:      []v1alpha1.ContactPoint{{Name: "team"}},
+				NotificationPolicy: v1alpha1.NotificationPolicy{ContactPoint: "team"},
+			},
+		},
+		Status: v1alpha1.GrafanaOrganizationStatus{AlertingTenants: []v1alpha1.TenantID{"atlas", "shield"}},
+	}
+
+	r := GrafanaOrganizationReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(grafanaOrganization).
+			WithStatusSubresource(grafanaOrganization).
+			Build(),
+		AlertmanagerEnabled: true,
+		AlertmanagerService: alertmanager.New(config.Config{
+			Monitoring: monitoring.Config{AlertmanagerURL: server.URL},
+		}),
+	}
+
+	if err := r.configureAlerting(context.Background(), grafanaOrganization); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"POST atlas", "DELETE shield"}; !slices.Equal(requests, expected) {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+	if expected := []v1alpha1.TenantID{"atlas"}; !slices.Equal(grafanaOrganization.Status.AlertingTenants, expected) {
+		t.Errorf("expected alerting tenants %v, got %v", expected, grafanaOrganization.Status.AlertingTenants)
+	}
+
+	// Removing the alerting deletes the configuration of all the tenants.
+	requests = nil
+	grafanaOrganization.Spec.Alerting = nil
+	if err := r.configureAlerting(context.Background(), grafanaOrganization); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"DELETE atlas"}; !slices.Equal(requests, expected) {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+	if len(grafanaOrganization.Status.AlertingTenants) != 0 {
+		t.Errorf("expected no alerting tenants, got %v", grafanaOrganization.Status.AlertingTenants)
+	}
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	grafanaAPI "github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/observability-operator/api/v1alpha1"
+	"github.com/giantswarm/observability-operator/pkg/grafana"
+)
+
+// GrafanaOrganizationJanitor periodically deletes the Grafana organizations created by the operator which no longer have a GrafanaOrganization,
+// e.g. because the GrafanaOrganization was deleted while the operator was down.
+// Organizations are considered created by the operator when they hold datasources managed by the operator.
+type GrafanaOrganizationJanitor struct {
+	Client     client.Client
+	GrafanaAPI *grafanaAPI.GrafanaHTTPAPI
+
+	// Interval is the interval at which orphaned organizations are looked for.
+	Interval time.Duration
+	// DryRun only logs the orphaned organizations instead of deleting them.
+	DryRun bool
+	// ManagedOrganizations are the display names of the organizations the operator is allowed to manage. All organizations are managed when it is empty.
+	ManagedOrganizations []string
+}
+
+// Start looks for orphaned organizations until the context is cancelled.
+func (j *GrafanaOrganizationJanitor) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("grafana-organization-janitor")
+	ctx = log.IntoContext(ctx, logger)
+
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.cleanup(ctx); err != nil {
+			logger.Error(err, "failed to clean up orphaned grafana organizations")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection ensures only the leader deletes organizations.
+func (j *GrafanaOrganizationJanitor) NeedLeaderElection() bool {
+	return true
+}
+
+func (j *GrafanaOrganizationJanitor) cleanup(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+
+	orphans, err := j.findOrphanOrganizations(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, organization := range orphans {
+		if j.DryRun {
+			logger.Info("found orphaned grafana organization, skipping deletion in dry-run mode", "organization", organization.Name, "orgID", organization.ID)
+			continue
+		}
+
+		logger.Info("deleting orphaned grafana organization", "organization", organization.Name, "orgID", organization.ID)
+		err = grafana.DeleteOrganization(log.IntoContext(ctx, logger.WithValues("organization", organization.Name)), j.GrafanaAPI, organization)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// findOrphanOrganizations returns the organizations created by the operator which do not match any GrafanaOrganization by ID or by name.
+// The shared organization and the organizations the operator is not allowed to manage are never returned.
+func (j *GrafanaOrganizationJanitor) findOrphanOrganizations(ctx context.Context) ([]grafana.Organization, error) {
+	var grafanaOrganizations v1alpha1.GrafanaOrganizationList
+	err := j.Client.List(ctx, &grafanaOrganizations)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	organizations, err := grafana.ListOrganizations(j.GrafanaAPI)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var orphans []grafana.Organization
+	for _, organization := range organizations {
+		if organization.ID == grafana.SharedOrg.ID || !grafana.IsManagedOrganization(j.ManagedOrganizations, organization.Name) {
+			continue
+		}
+
+		// The organization ID is only known once it is stored in the status, so organizations are matched by name as well.
+		matched := slices.ContainsFunc(grafanaOrganizations.Items, func(grafanaOrganization v1alpha1.GrafanaOrganization) bool {
+			return grafanaOrganization.Status.OrgID == organization.ID || grafanaOrganization.Spec.DisplayName == organization.Name
+		})
+		if matched {
+			continue
+		}
+
+		managed, err := grafana.HasManagedDatasources(ctx, j.GrafanaAPI, organization.ID)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if managed {
+			orphans = append(orphans, organization)
+		}
+	}
+
+	return orphans, nil
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	grafanaAPI "github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/giantswarm/observability-operator/api/v1alpha1"
+)
+
+// newOrgDatasourcesServer serves the datasources of the organization requested through the organization header.
+// managed are the organizations holding datasources managed by the operator.
+func newOrgDatasourcesServer(t *testing.T, managed map[string]bool) *url.URL {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		list := models.DataSourceList{
+			&models.DataSourceListItemDTO{ID: 1, Name: "Custom", JSONData: map[string]interface{}{}},
+		}
+		if managed[r.Header.Get(grafanaAPI.OrgIDHeader)] {
+			list = append(list, &models.DataSourceListItemDTO{ID: 2, Name: "Mimir", JSONData: map[string]interface{}{"managedBy": "observability-operator"}})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(list); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return serverURL
+}
+
+func TestGrafanaOrganizationJanitorCleanup(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	grafanaOrganizations := []runtime.Object{
+		&v1alpha1.GrafanaOrganization{
+			ObjectMeta: metav1.ObjectMeta{Name: "renamed"},
+			Spec:       v1alpha1.GrafanaOrganizationSpec{DisplayName: "Renamed"},
+			Status:     v1alpha1.GrafanaOrganizationStatus{OrgID: 2},
+		},
+		&v1alpha1.GrafanaOrganization{
+			ObjectMeta: metav1.ObjectMeta{Name: "pending"},
+			Spec:       v1alpha1.GrafanaOrganizationSpec{DisplayName: "Pending"},
+		},
+	}
+
+	serverURL := newOrgDatasourcesServer(t, map[string]bool{"1": true, "2": true, "3": true, "4": true, "6": true})
+
+	tests := []struct {
+		name                 string
+		dryRun               bool
+		managedOrganizations []string
+		expectedDeleted      []int64
+	}{
+		{
+			name:            "orphaned organizations are deleted",
+			expectedDeleted: []int64{4, 6},
+		},
+		{
+			name:   "dry run",
+			dryRun: true,
+		},
+		{
+			name:                 "organizations the operator is not allowed to manage are kept",
+			managedOrganizations: []string{"Orphan"},
+			expectedDeleted:      []int64{4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := &fakeOrgs{
+				organizations: []*models.OrgDTO{
+					{ID: 1, Name: "Shared Org"},
+					// matched by ID after being renamed in Grafana
+					{ID: 2, Name: "Old name"},
+					// matched by name before its ID is stored in the status
+					{ID: 3, Name: "Pending"},
+					{ID: 4, Name: "Orphan"},
+					// created manually
+					{ID: 5, Name: "Manual"},
+					{ID: 6, Name: "Other orphan"},
+				},
+			}
+			grafanaClient := grafanaAPI.NewHTTPClientWithConfig(nil, &grafanaAPI.TransportConfig{
+				Schemes:  []string{serverURL.Scheme},
+				BasePath: "/api",
+				Host:     serverURL.Host,
+			})
+			grafanaClient.Orgs = orgs
+			j := GrafanaOrganizationJanitor{
+				Client:               fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(grafanaOrganizations...).Build(),
+				GrafanaAPI:           grafanaClient,
+				DryRun:               tt.dryRun,
+				ManagedOrganizations: tt.managedOrganizations,
+			}
+
+			err := j.cleanup(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(orgs.deleted, tt.expectedDeleted) {
+				t.Errorf("expected deleted organizations %v, got %v", tt.expectedDeleted, orgs.deleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	grafanaAPI "github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/giantswarm/observability-operator/pkg/config"
+	"github.com/giantswarm/observability-operator/pkg/grafana"
+	grafanaclient "github.com/giantswarm/observability-operator/pkg/grafana/client"
+)
+
+// LibraryPanelReconciler provisions Grafana library panels from configmaps, so panels reused across dashboards are managed in one place.
+type LibraryPanelReconciler struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	GrafanaAPI *grafanaAPI.GrafanaHTTPAPI
+
+	// ManagedOrganizations are the display names of the organizations the operator is allowed to manage. All organizations are managed when it is empty.
+	ManagedOrganizations []string
+	// AllowedOrganizations maps namespaces to the organizations their library panels may be pushed to, like the dashboards.
+	AllowedOrganizations map[string][]string
+	// MaxConcurrentReconciles is the maximum number of library panel configmaps reconciled concurrently.
+	MaxConcurrentReconciles int
+}
+
+const (
+	LibraryPanelFinalizer          = "observability.giantswarm.io/grafanalibrarypanel"
+	LibraryPanelSelectorLabelValue = "library-panel"
+
+	// syncedLibraryPanelsAnnotation records the UIDs of the library panels of the configmap which were pushed to Grafana.
+	syncedLibraryPanelsAnnotation = "observability.giantswarm.io/synced-library-panels"
+)
+
+func SetupLibraryPanelReconciler(mgr manager.Manager, conf config.Config) error {
+	grafanaAPI, err := grafanaclient.GenerateGrafanaClient(conf.GrafanaURL, conf)
+	if err != nil {
+		return fmt.Errorf("unable to create grafana client: %w", err)
+	}
+
+	r := &LibraryPanelReconciler{
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		GrafanaAPI:              grafanaAPI,
+		ManagedOrganizations:    conf.GrafanaManagedOrganizations,
+		AllowedOrganizations:    conf.DashboardAllowedOrganizations,
+		MaxConcurrentReconciles: conf.MaxConcurrentReconciles.LibraryPanel,
+	}
+
+	return r.SetupWithManager(mgr)
+}
+
+// Reconcile provisions the library panels of the configmap in the Grafana organization of the configmap.
+func (r *LibraryPanelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	logger.Info("Started reconciling Grafana library panel configmaps")
+	defer logger.Info("Finished reconciling Grafana library panel configmaps")
+
+	libraryPanels := &v1.ConfigMap{}
+	err := r.Client.Get(ctx, req.NamespacedName, libraryPanels)
+	if err != nil {
+		return ctrl.Result{}, errors.WithStack(client.IgnoreNotFound(err))
+	}
+
+	if isPaused(libraryPanels) {
+		logger.Info("Skipping library panels, reconciliation is paused", "annotation", pausedAnnotation)
+		return ctrl.Result{}, nil
+	}
+
+	// Handle deleted library panels
+	if !libraryPanels.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.reconcileDelete(ctx, libraryPanels)
+	}
+
+	// Add finalizer first if not set to avoid the race condition between init and delete.
+	if !controllerutil.ContainsFinalizer(libraryPanels, LibraryPanelFinalizer) {
+		logger.Info("adding finalizer", "finalizer", LibraryPanelFinalizer)
+		patchHelper, err := patch.NewHelper(libraryPanels, r.Client)
+		if err != nil {
+			return ctrl.Result{}, errors.WithStack(err)
+		}
+		controllerutil.AddFinalizer(libraryPanels, LibraryPanelFinalizer)
+		if err := patchHelper.Patch(ctx, libraryPanels); err != nil {
+			logger.Error(err, "failed to add finalizer", "finalizer", LibraryPanelFinalizer)
+			return ctrl.Result{}, errors.WithStack(err)
+		}
+		logger.Info("added finalizer", "finalizer", LibraryPanelFinalizer)
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{}, errors.WithStack(r.configureLibraryPanels(ctx, libraryPanels))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LibraryPanelReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	labelSelectorPredicate, err := predicate.LabelSelectorPredicate(metav1.LabelSelector{MatchLabels: map[string]string{DashboardSelectorLabelName: LibraryPanelSelectorLabelValue}})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("librarypanels").
+		For(&v1.ConfigMap{}, builder.WithPredicates(labelSelectorPredicate)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
+		Complete(r)
+}
+
+// configureLibraryPanels creates or updates the library panels of the configmap and deletes the ones removed from it.
+// Panels without a UID are skipped and block the deletions until they are fixed, the other panels are retried when they fail.
+func (r LibraryPanelReconciler) configureLibraryPanels(ctx context.Context, libraryPanelsCM *v1.ConfigMap) error {
+	logger := log.FromContext(ctx)
+
+	organization, orgAPI, err := resolveConfigMapOrganization(ctx, r.GrafanaAPI, r.ManagedOrganizations, r.AllowedOrganizations, libraryPanelsCM)
+	if err != nil || organization == nil {
+		return errors.WithStack(err)
+	}
+
+	previouslySynced := getSyncedUIDs(libraryPanelsCM, syncedLibraryPanelsAnnotation)
+	synced := make([]string, 0, len(libraryPanelsCM.Data))
+	currentLibraryPanelUIDs := make(map[string]bool, len(libraryPanelsCM.Data))
+	var libraryPanelErrors []error
+	// A library panel which cannot be parsed cannot be told apart from a removed one, its UID is unknown
+	parsingFailed := false
+	for _, key := range slices.Sorted(maps.Keys(libraryPanelsCM.Data)) {
+		var libraryPanel map[string]any
+		err = json.Unmarshal([]byte(libraryPanelsCM.Data[key]), &libraryPanel)
+		if err != nil {
+			logger.Error(err, "Failed converting library panel to json", "key", key)
+			parsingFailed = true
+			continue
+		}
+
+		libraryPanelUID, err := getLibraryPanelUID(libraryPanel)
+		if err != nil {
+			logger.Error(err, "Skipping library panel, no UID found", "key", key)
+			parsingFailed = true
+			continue
+		}
+		currentLibraryPanelUIDs[libraryPanelUID] = true
+
+		err = grafana.PublishLibraryPanel(ctx, orgAPI, organization.ID, libraryPanel)
+		if err != nil {
+			logger.Error(err, "Failed updating library panel", "Library panel UID", libraryPanelUID)
+			libraryPanelErrors = append(libraryPanelErrors, errors.Wrapf(err, "library panel %q", libraryPanelUID))
+			// Keep track of a previous version of the library panel so it is still deleted once removed from the configmap
+			if slices.Contains(previouslySynced, libraryPanelUID) {
+				synced = append(synced, libraryPanelUID)
+			}
+			continue
+		}
+		synced = append(synced, libraryPanelUID)
+		logger.Info("updated library panel", "Library panel UID", libraryPanelUID, "Library panel Org", organization.Name)
+	}
+
+	// The library panels are only deleted once all of them are parsed, so a typo does not delete a live library panel
+	if parsingFailed {
+		logger.Info("skipping the deletion of the library panels removed from the configmap as some library panels could not be parsed")
+		for _, libraryPanelUID := range previouslySynced {
+			if !currentLibraryPanelUIDs[libraryPanelUID] {
+				currentLibraryPanelUIDs[libraryPanelUID] = true
+				synced = append(synced, libraryPanelUID)
+			}
+		}
+	}
+
+	// Delete the library panels which were removed from the configmap since they were pushed
+	for _, libraryPanelUID := range previouslySynced {
+		if currentLibraryPanelUIDs[libraryPanelUID] {
+			continue
+		}
+
+		err = r.deleteLibraryPanel(ctx, orgAPI, organization, libraryPanelsCM, libraryPanelUID)
+		if err != nil {
+			libraryPanelErrors = append(libraryPanelErrors, errors.Wrapf(err, "library panel %q", libraryPanelUID))
+			// Keep track of the library panel so its deletion is retried
+			synced = append(synced, libraryPanelUID)
+		}
+	}
+
+	err = updateSyncedUIDs(ctx, r.Client, libraryPanelsCM, syncedLibraryPanelsAnnotation, synced)
+	if err != nil {
+		logger.Error(err, "failed to record the synced library panels in the configmap")
+		return errors.WithStack(err)
+	}
+
+	return kerrors.NewAggregate(libraryPanelErrors)
+}
+
+// deleteLibraryPanel deletes the library panel from Grafana.
+// Grafana refuses to delete library panels still used by dashboards, which retrying does not solve,
+// so they are left in Grafana with a warning event on the configmap.
+func (r LibraryPanelReconciler) deleteLibraryPanel(ctx context.Context, orgAPI *grafanaAPI.GrafanaHTTPAPI, organization *grafana.Organization, libraryPanelsCM *v1.ConfigMap, libraryPanelUID string) error {
+	logger := log.FromContext(ctx)
+
+	err := grafana.DeleteLibraryPanel(ctx, orgAPI, organization.ID, libraryPanelUID)
+	if grafana.IsLibraryPanelInUse(err) {
+		logger.Error(err, "Skipping the deletion of the library panel, it is still used by dashboards", "Library panel UID", libraryPanelUID)
+		record.Warnf(libraryPanelsCM, "LibraryPanelInUse", "Library panel %s is still used by dashboards in organization %s and is not deleted", libraryPanelUID, organization.Name)
+		return nil
+	} else if err != nil && !grafana.IsNotFound(err) {
+		logger.Error(err, "Failed deleting library panel", "Library panel UID", libraryPanelUID)
+		return errors.WithStack(err)
+	}
+
+	logger.Info("deleted library panel", "Library panel UID", libraryPanelUID, "Library panel Org", organization.Name)
+	return nil
+}
+
+// reconcileDelete deletes the library panels of the configmap from Grafana and removes the finalizer.
+func (r LibraryPanelReconciler) reconcileDelete(ctx context.Context, libraryPanelsCM *v1.ConfigMap) error {
+	logger := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(libraryPanelsCM, LibraryPanelFinalizer) {
+		return nil
+	}
+
+	organization, orgAPI, err := resolveConfigMapOrganization(ctx, r.GrafanaAPI, r.ManagedOrganizations, r.AllowedOrganizations, libraryPanelsCM)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if organization != nil {
+		// Library panels removed from the configmap whose deletion failed are still recorded as synced
+		libraryPanelUIDs := getSyncedUIDs(libraryPanelsCM, syncedLibraryPanelsAnnotation)
+		for _, libraryPanelString := range libraryPanelsCM.Data {
+			var libraryPanel map[string]any
+			if err := json.Unmarshal([]byte(libraryPanelString), &libraryPanel); err != nil {
+				continue
+			}
+			libraryPanelUID, err := getLibraryPanelUID(libraryPanel)
+			if err != nil {
+				continue
+			}
+			libraryPanelUIDs = append(libraryPanelUIDs, libraryPanelUID)
+		}
+
+		slices.Sort(libraryPanelUIDs)
+		for _, libraryPanelUID := range slices.Compact(libraryPanelUIDs) {
+			if err := r.deleteLibraryPanel(ctx, orgAPI, organization, libraryPanelsCM, libraryPanelUID); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	// We use the patch from sigs.k8s.io/cluster-api/util/patch to handle the patching without conflicts
+	logger.Info("removing finalizer", "finalizer", LibraryPanelFinalizer)
+	patchHelper, err := patch.NewHelper(libraryPanelsCM, r.Client)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	controllerutil.RemoveFinalizer(libraryPanelsCM, LibraryPanelFinalizer)
+	if err := patchHelper.Patch(ctx, libraryPanelsCM); err != nil {
+		logger.Error(err, "failed to remove finalizer, requeuing", "finalizer", LibraryPanelFinalizer)
+		return errors.WithStack(err)
+	}
+	logger.Info("removed finalizer", "finalizer", LibraryPanelFinalizer)
+
+	return nil
+}
+
+func getLibraryPanelUID(libraryPanel map[string]any) (string, error) {
+	uid, ok := libraryPanel["uid"].(string)
+	if !ok || uid == "" {
+		return "", errors.New("library panel UID not found in configmap")
+	}
+	return uid, nil
+}
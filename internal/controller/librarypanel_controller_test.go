@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	grafanaAPI "github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/client/library_elements"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// fakeLibraryElements stores the library panels in memory, indexed by UID.
+type fakeLibraryElements struct {
+	library_elements.ClientService
+
+	panels  map[string]*models.LibraryElementDTO
+	created []string
+	updated []string
+	deleted []string
+	// inUse are the library panels used by dashboards, which Grafana refuses to delete
+	inUse map[string]bool
+}
+
+func (f *fakeLibraryElements) GetLibraryElementByUID(uid string, opts ...library_elements.ClientOption) (*library_elements.GetLibraryElementByUIDOK, error) {
+	panel, ok := f.panels[uid]
+	if !ok {
+		return nil, errors.New("[GET /library-elements/{library_element_uid}][404] getLibraryElementByUidNotFound (status 404)")
+	}
+	return &library_elements.GetLibraryElementByUIDOK{Payload: &models.LibraryElementResponse{Result: panel}}, nil
+}
+
+func (f *fakeLibraryElements) CreateLibraryElement(body *models.CreateLibraryElementCommand, opts ...library_elements.ClientOption) (*library_elements.CreateLibraryElementOK, error) {
+	f.created = append(f.created, body.UID)
+	f.panels[body.UID] = &models.LibraryElementDTO{UID: body.UID, Name: body.Name, Kind: body.Kind, Model: body.Model, Version: 1}
+	return &library_elements.CreateLibraryElementOK{Payload: &models.LibraryElementResponse{Result: f.panels[body.UID]}}, nil
+}
+
+func (f *fakeLibraryElements) UpdateLibraryElement(uid string, body *models.PatchLibraryElementCommand, opts ...library_elements.ClientOption) (*library_elements.UpdateLibraryElementOK, error) {
+	panel := f.panels[uid]
+	if body.Version != panel.Version {
+		return nil, errors.New("[PATCH /library-elements/{library_element_uid}][412] updateLibraryElementPreconditionFailed (status 412)")
+	}
+	f.updated = append(f.updated, uid)
+	f.panels[uid] = &models.LibraryElementDTO{UID: uid, Name: body.Name, Kind: body.Kind, Model: body.Model, FolderUID: body.FolderUID, Version: panel.Version + 1}
+	return &library_elements.UpdateLibraryElementOK{Payload: &models.LibraryElementResponse{Result: f.panels[uid]}}, nil
+}
+
+func (f *fakeLibraryElements) DeleteLibraryElementByUID(uid string, opts ...library_elements.ClientOption) (*library_elements.DeleteLibraryElementByUIDOK, error) {
+	if f.inUse[uid] {
+		return nil, errors.New("[DELETE /library-elements/{library_element_uid}][403] deleteLibraryElementByUidForbidden (status 403)")
+	}
+	if _, ok := f.panels[uid]; !ok {
+		return nil, errors.New("[DELETE /library-elements/{library_element_uid}][404] deleteLibraryElementByUidNotFound (status 404)")
+	}
+	f.deleted = append(f.deleted, uid)
+	delete(f.panels, uid)
+	return &library_elements.DeleteLibraryElementByUIDOK{}, nil
+}
+
+func TestConfigureLibraryPanels(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "library-panels",
+			Namespace:   "default",
+			Labels:      map[string]string{DashboardSelectorLabelName: LibraryPanelSelectorLabelValue},
+			Annotations: map[string]string{grafanaOrganizationLabel: "Test"},
+		},
+		Data: map[string]string{
+			"cpu.json":     `{"uid": "cpu", "title": "CPU usage", "type": "timeseries"}`,
+			"no-uid.json":  `{"title": "Without UID", "type": "stat"}`,
+			"invalid.json": `{"uid": `,
+		},
+	}
+
+	libraryElements := &fakeLibraryElements{
+		panels: map[string]*models.LibraryElementDTO{
+			// moved to a folder in Grafana
+			"memory": {UID: "memory", Name: "Memory", Kind: 1, FolderUID: "shared-panels", Version: 3},
+		},
+		inUse: map[string]bool{"memory": true},
+	}
+	r := LibraryPanelReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap).Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:            &fakeOrgs{},
+			LibraryElements: libraryElements,
+		},
+	}
+
+	t.Run("create", func(t *testing.T) {
+		if err := r.configureLibraryPanels(context.Background(), configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(libraryElements.created) != 1 || libraryElements.created[0] != "cpu" {
+			t.Fatalf("expected only the library panel with a UID to be created, got %v", libraryElements.created)
+		}
+		panel := libraryElements.panels["cpu"]
+		if panel.Name != "CPU usage" || panel.Kind != 1 {
+			t.Errorf("expected a library panel named after its title, got name %q and kind %d", panel.Name, panel.Kind)
+		}
+		if len(libraryElements.updated) != 0 {
+			t.Errorf("expected no library panel to be updated, got %v", libraryElements.updated)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		configMap.Data["cpu.json"] = `{"uid": "cpu", "title": "CPU", "type": "timeseries"}`
+		configMap.Data["memory.json"] = `{"uid": "memory", "title": "Memory usage", "type": "timeseries"}`
+
+		if err := r.configureLibraryPanels(context.Background(), configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(libraryElements.updated) != 2 {
+			t.Fatalf("expected the existing library panels to be updated, got %v", libraryElements.updated)
+		}
+		if panel := libraryElements.panels["cpu"]; panel.Name != "CPU" || panel.Version != 2 {
+			t.Errorf("expected the cpu library panel to be renamed at version 2, got name %q and version %d", panel.Name, panel.Version)
+		}
+		if panel := libraryElements.panels["memory"]; panel.FolderUID != "shared-panels" || panel.Version != 4 {
+			t.Errorf("expected the memory library panel to stay in its folder at version 4, got folder %q and version %d", panel.FolderUID, panel.Version)
+		}
+	})
+
+	t.Run("keep library panels while some cannot be parsed", func(t *testing.T) {
+		configMap.Data["cpu.json"] = `{"uid": "cpu", "title": `
+		delete(configMap.Data, "memory.json")
+
+		if err := r.configureLibraryPanels(context.Background(), configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(libraryElements.deleted) != 0 {
+			t.Errorf("expected no library panel to be deleted, got %v", libraryElements.deleted)
+		}
+		if synced := getSyncedUIDs(configMap, syncedLibraryPanelsAnnotation); len(synced) != 2 {
+			t.Errorf("expected the library panels to still be recorded as synced, got %v", synced)
+		}
+	})
+
+	t.Run("delete removed library panels", func(t *testing.T) {
+		recorder := newEventRecorder()
+
+		// The library panels are only deleted once all the remaining ones can be parsed
+		delete(configMap.Data, "cpu.json")
+		delete(configMap.Data, "no-uid.json")
+		delete(configMap.Data, "invalid.json")
+
+		if err := r.configureLibraryPanels(context.Background(), configMap); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(libraryElements.deleted) != 1 || libraryElements.deleted[0] != "cpu" {
+			t.Errorf("expected the removed library panel to be deleted, got %v", libraryElements.deleted)
+		}
+		// The library panel still used by dashboards is kept in Grafana without retrying its deletion
+		if _, ok := libraryElements.panels["memory"]; !ok {
+			t.Errorf("expected the library panel used by dashboards to be kept")
+		}
+		select {
+		case event := <-recorder.Events:
+			if !strings.Contains(event, "LibraryPanelInUse") {
+				t.Errorf("expected a library panel in use event, got %q", event)
+			}
+		default:
+			t.Errorf("expected a library panel in use event")
+		}
+		if synced := getSyncedUIDs(configMap, syncedLibraryPanelsAnnotation); len(synced) != 0 {
+			t.Errorf("expected no library panel to be recorded as synced, got %v", synced)
+		}
+	})
+}
+
+func TestConfigureLibraryPanelsNotAllowed(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "library-panels",
+			Namespace:   "team",
+			Labels:      map[string]string{DashboardSelectorLabelName: LibraryPanelSelectorLabelValue},
+			Annotations: map[string]string{grafanaOrganizationLabel: "Test"},
+		},
+		Data: map[string]string{
+			"cpu.json": `{"uid": "cpu", "title": "CPU usage", "type": "timeseries"}`,
+		},
+	}
+
+	libraryElements := &fakeLibraryElements{panels: map[string]*models.LibraryElementDTO{}}
+	r := LibraryPanelReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap).Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:            &fakeOrgs{},
+			LibraryElements: libraryElements,
+		},
+		AllowedOrganizations: map[string][]string{"team": {"Other"}},
+	}
+
+	if err := r.configureLibraryPanels(context.Background(), configMap); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(libraryElements.created) != 0 {
+		t.Errorf("expected no library panel to be pushed to an organization the namespace is not allowed to push to, got %v", libraryElements.created)
+	}
+}
+
+func TestReconcileLibraryPanelsPaused(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "library-panels",
+			Namespace: "default",
+			Labels:    map[string]string{DashboardSelectorLabelName: LibraryPanelSelectorLabelValue},
+			Annotations: map[string]string{
+				grafanaOrganizationLabel: "Test",
+				pausedAnnotation:         "true",
+			},
+		},
+		Data: map[string]string{
+			"cpu.json": `{"uid": "cpu", "title": "CPU usage", "type": "timeseries"}`,
+		},
+	}
+
+	libraryElements := &fakeLibraryElements{panels: map[string]*models.LibraryElementDTO{}}
+	r := LibraryPanelReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap).Build(),
+		GrafanaAPI: &grafanaAPI.GrafanaHTTPAPI{
+			Orgs:            &fakeOrgs{},
+			LibraryElements: libraryElements,
+		},
+	}
+
+	if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(configMap)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	current := &v1.ConfigMap{}
+	if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(configMap), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(current.Finalizers) != 0 {
+		t.Errorf("expected no finalizer on a paused configmap, got %v", current.Finalizers)
+	}
+	if len(libraryElements.created) != 0 {
+		t.Errorf("expected no library panel to be pushed, got %v", libraryElements.created)
+	}
+}
@@ -0,0 +1,284 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/observability-operator/api/v1alpha1"
+	"github.com/giantswarm/observability-operator/pkg/alertmanager"
+	"github.com/giantswarm/observability-operator/pkg/config"
+)
+
+const (
+	// maintenanceWindowClusterLabel is the alert label matched against the clusters of a maintenance window.
+	maintenanceWindowClusterLabel = "cluster_id"
+)
+
+// MaintenanceWindowReconciler reconciles a MaintenanceWindow object
+// and keeps an Alertmanager silence in sync with it for the duration of the maintenance window.
+type MaintenanceWindowReconciler struct {
+	client client.Client
+
+	alertmanagerService alertmanager.Service
+}
+
+// SetupMaintenanceWindowReconciler adds a controller into mgr that reconciles MaintenanceWindows.
+func SetupMaintenanceWindowReconciler(mgr ctrl.Manager, conf config.Config) error {
+	r := &MaintenanceWindowReconciler{
+		client:              mgr.GetClient(),
+		alertmanagerService: alertmanager.New(conf),
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("maintenancewindow").
+		For(&v1alpha1.MaintenanceWindow{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: conf.MaxConcurrentReconciles.MaintenanceWindow}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=observability.giantswarm.io,resources=maintenancewindows,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=observability.giantswarm.io,resources=maintenancewindows/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=observability.giantswarm.io,resources=maintenancewindows/finalizers,verbs=update
+
+// Reconcile main logic
+func (r MaintenanceWindowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	logger.Info("Started reconciling")
+	defer logger.Info("Finished reconciling")
+
+	maintenanceWindow := &v1alpha1.MaintenanceWindow{}
+	err := r.client.Get(ctx, req.NamespacedName, maintenanceWindow)
+	if err != nil {
+		return ctrl.Result{}, errors.WithStack(client.IgnoreNotFound(err))
+	}
+
+	if isPaused(maintenanceWindow) {
+		logger.Info("Skipping maintenance window, reconciliation is paused", "annotation", pausedAnnotation)
+		return ctrl.Result{}, nil
+	}
+
+	// Handle deleted maintenance windows
+	if !maintenanceWindow.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.reconcileDelete(ctx, maintenanceWindow)
+	}
+
+	// Handle non-deleted maintenance windows
+	return ctrl.Result{}, r.reconcileCreate(ctx, maintenanceWindow)
+}
+
+// reconcileCreate ensures the Alertmanager silence described by the maintenance window exists.
+func (r MaintenanceWindowReconciler) reconcileCreate(ctx context.Context, maintenanceWindow *v1alpha1.MaintenanceWindow) error {
+	logger := log.FromContext(ctx)
+
+	// Add finalizer first if not set to avoid the race condition between init and delete.
+	if !controllerutil.ContainsFinalizer(maintenanceWindow, v1alpha1.MaintenanceWindowFinalizer) {
+		logger.Info("adding finalizer", "finalizer", v1alpha1.MaintenanceWindowFinalizer)
+		patchHelper, err := patch.NewHelper(maintenanceWindow, r.client)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		controllerutil.AddFinalizer(maintenanceWindow, v1alpha1.MaintenanceWindowFinalizer)
+		if err := patchHelper.Patch(ctx, maintenanceWindow); err != nil {
+			logger.Error(err, "failed to add finalizer", "finalizer", v1alpha1.MaintenanceWindowFinalizer)
+			return errors.WithStack(err)
+		}
+		logger.Info("added finalizer", "finalizer", v1alpha1.MaintenanceWindowFinalizer)
+		return nil
+	}
+
+	if err := validateMaintenanceWindow(maintenanceWindow); err != nil {
+		// Retrying does not help until the maintenance window is fixed.
+		logger.Error(err, "invalid maintenance window")
+		return r.setInvalid(ctx, maintenanceWindow, err)
+	}
+
+	// Alertmanager expires the silence on its own once the maintenance window is over, unless the end of the window was moved into the past.
+	if !maintenanceWindow.Spec.EndsAt.After(time.Now()) {
+		logger.Info("maintenance window is over")
+		return r.expireSilence(ctx, maintenanceWindow)
+	}
+
+	// The silence ID is unknown to the new tenant, so the silence is expired in the previous tenant and created again.
+	if maintenanceWindow.Status.SilenceID != "" && maintenanceWindow.Status.Tenant != maintenanceWindow.Spec.Tenant {
+		logger.Info("maintenance window tenant changed, expiring the silence of the previous tenant", "tenant", maintenanceWindow.Status.Tenant)
+		err := r.alertmanagerService.DeleteSilence(ctx, string(maintenanceWindow.Status.Tenant), maintenanceWindow.Status.SilenceID)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		maintenanceWindow.Status.SilenceID = ""
+		maintenanceWindow.Status.Tenant = maintenanceWindow.Spec.Tenant
+		if err := r.client.Status().Update(ctx, maintenanceWindow); err != nil {
+			logger.Error(err, "failed to update maintenance window status")
+			return errors.WithStack(err)
+		}
+	}
+
+	silenceID, err := r.alertmanagerService.CreateOrUpdateSilence(ctx, string(maintenanceWindow.Spec.Tenant), newSilence(maintenanceWindow))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	statusChanged := maintenanceWindow.Status.SilenceID != silenceID || maintenanceWindow.Status.Tenant != maintenanceWindow.Spec.Tenant
+	maintenanceWindow.Status.SilenceID = silenceID
+	maintenanceWindow.Status.Tenant = maintenanceWindow.Spec.Tenant
+	statusChanged = meta.SetStatusCondition(&maintenanceWindow.Status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ReadyCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             v1alpha1.ReconciliationSucceededReason,
+		ObservedGeneration: maintenanceWindow.Generation,
+	}) || statusChanged
+	if statusChanged {
+		if err := r.client.Status().Update(ctx, maintenanceWindow); err != nil {
+			logger.Error(err, "failed to update maintenance window status")
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// expireSilence expires the silence of the maintenance window, if any, and forgets it.
+func (r MaintenanceWindowReconciler) expireSilence(ctx context.Context, maintenanceWindow *v1alpha1.MaintenanceWindow) error {
+	logger := log.FromContext(ctx)
+
+	if maintenanceWindow.Status.SilenceID == "" {
+		return nil
+	}
+
+	err := r.alertmanagerService.DeleteSilence(ctx, silenceTenant(maintenanceWindow), maintenanceWindow.Status.SilenceID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	maintenanceWindow.Status.SilenceID = ""
+	if err := r.client.Status().Update(ctx, maintenanceWindow); err != nil {
+		logger.Error(err, "failed to update maintenance window status")
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// silenceTenant returns the tenant the silence of the maintenance window was created for.
+// Silences created before the tenant was recorded in the status were created for the tenant of the spec.
+func silenceTenant(maintenanceWindow *v1alpha1.MaintenanceWindow) string {
+	if maintenanceWindow.Status.Tenant == "" {
+		return string(maintenanceWindow.Spec.Tenant)
+	}
+	return string(maintenanceWindow.Status.Tenant)
+}
+
+// validateMaintenanceWindow returns an error when the maintenance window cannot be turned into a silence.
+func validateMaintenanceWindow(maintenanceWindow *v1alpha1.MaintenanceWindow) error {
+	if !maintenanceWindow.Spec.EndsAt.After(maintenanceWindow.Spec.StartsAt.Time) {
+		return errors.Errorf("maintenance window %q must end after it starts", maintenanceWindow.Name)
+	}
+
+	// Alertmanager refuses silences without matchers as they would silence every alert of the tenant.
+	if len(maintenanceWindow.Spec.Clusters) == 0 && len(maintenanceWindow.Spec.Matchers) == 0 {
+		return errors.Errorf("maintenance window %q must select at least one cluster or matcher", maintenanceWindow.Name)
+	}
+
+	return nil
+}
+
+// setInvalid sets the Ready condition to false with the validation error.
+// The error is not returned as the reconciliation is retried once the maintenance window is updated.
+func (r MaintenanceWindowReconciler) setInvalid(ctx context.Context, maintenanceWindow *v1alpha1.MaintenanceWindow, err error) error {
+	changed := meta.SetStatusCondition(&maintenanceWindow.Status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ReadyCondition,
+		Status:             metav1.ConditionFalse,
+		Reason:             v1alpha1.InvalidMaintenanceWindowReason,
+		Message:            err.Error(),
+		ObservedGeneration: maintenanceWindow.Generation,
+	})
+	if !changed {
+		return nil
+	}
+
+	if updateErr := r.client.Status().Update(ctx, maintenanceWindow); updateErr != nil {
+		return errors.WithStack(updateErr)
+	}
+
+	return nil
+}
+
+// reconcileDelete expires the Alertmanager silence of the maintenance window and removes the finalizer.
+func (r MaintenanceWindowReconciler) reconcileDelete(ctx context.Context, maintenanceWindow *v1alpha1.MaintenanceWindow) error {
+	logger := log.FromContext(ctx)
+
+	// We do not need to delete anything if there is no finalizer on the maintenance window
+	if !controllerutil.ContainsFinalizer(maintenanceWindow, v1alpha1.MaintenanceWindowFinalizer) {
+		return nil
+	}
+
+	if maintenanceWindow.Status.SilenceID != "" {
+		err := r.alertmanagerService.DeleteSilence(ctx, silenceTenant(maintenanceWindow), maintenanceWindow.Status.SilenceID)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// Finalizer handling needs to come last.
+	logger.Info("removing finalizer", "finalizer", v1alpha1.MaintenanceWindowFinalizer)
+	patchHelper, err := patch.NewHelper(maintenanceWindow, r.client)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	controllerutil.RemoveFinalizer(maintenanceWindow, v1alpha1.MaintenanceWindowFinalizer)
+	if err := patchHelper.Patch(ctx, maintenanceWindow); err != nil {
+		logger.Error(err, "failed to remove finalizer, requeuing", "finalizer", v1alpha1.MaintenanceWindowFinalizer)
+		return errors.WithStack(err)
+	}
+	logger.Info("removed finalizer", "finalizer", v1alpha1.MaintenanceWindowFinalizer)
+
+	return nil
+}
+
+// newSilence builds the Alertmanager silence matching the clusters and matchers of the maintenance window.
+func newSilence(maintenanceWindow *v1alpha1.MaintenanceWindow) alertmanager.Silence {
+	matchers := make([]alertmanager.Matcher, 0, len(maintenanceWindow.Spec.Matchers)+1)
+	if len(maintenanceWindow.Spec.Clusters) > 0 {
+		matchers = append(matchers, alertmanager.Matcher{
+			Name:    maintenanceWindowClusterLabel,
+			Value:   strings.Join(maintenanceWindow.Spec.Clusters, "|"),
+			IsRegex: len(maintenanceWindow.Spec.Clusters) > 1,
+			IsEqual: true,
+		})
+	}
+	for _, matcher := range maintenanceWindow.Spec.Matchers {
+		matchers = append(matchers, alertmanager.Matcher{
+			Name:    matcher.Name,
+			Value:   matcher.Value,
+			IsRegex: matcher.IsRegex,
+			IsEqual: true,
+		})
+	}
+
+	comment := maintenanceWindow.Spec.Comment
+	if comment == "" {
+		comment = "Maintenance window " + maintenanceWindow.Name
+	}
+
+	return alertmanager.Silence{
+		ID:       maintenanceWindow.Status.SilenceID,
+		Matchers: matchers,
+		StartsAt: maintenanceWindow.Spec.StartsAt.Time,
+		EndsAt:   maintenanceWindow.Spec.EndsAt.Time,
+		Comment:  comment,
+	}
+}
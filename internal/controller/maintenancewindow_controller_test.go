@@ -0,0 +1,327 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/giantswarm/observability-operator/api/v1alpha1"
+	"github.com/giantswarm/observability-operator/pkg/alertmanager"
+	"github.com/giantswarm/observability-operator/pkg/config"
+	"github.com/giantswarm/observability-operator/pkg/monitoring"
+)
+
+func TestMaintenanceWindowReconciler(t *testing.T) {
+	var created []alertmanager.Silence
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			var silence alertmanager.Silence
+			if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+				t.Errorf("failed to decode silence: %v", err)
+			}
+			if r.Header.Get("X-Scope-OrgID") != "giantswarm" {
+				t.Errorf("expected the silence to be created for tenant giantswarm, got %q", r.Header.Get("X-Scope-OrgID"))
+			}
+			created = append(created, silence)
+			_, _ = w.Write([]byte(`{"silenceID":"silence-id"}`))
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	scheme := newTestScheme(t)
+
+	maintenanceWindow := &v1alpha1.MaintenanceWindow{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "upgrade",
+			Finalizers: []string{v1alpha1.MaintenanceWindowFinalizer},
+		},
+		Spec: v1alpha1.MaintenanceWindowSpec{
+			Tenant:   "giantswarm",
+			Clusters: []string{"golem", "grizzly"},
+			Matchers: []v1alpha1.SilenceMatcher{{Name: "team", Value: "atlas"}},
+			StartsAt: metav1.NewTime(time.Now().Add(-time.Hour)),
+			EndsAt:   metav1.NewTime(time.Now().Add(time.Hour)),
+		},
+	}
+
+	r := MaintenanceWindowReconciler{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(maintenanceWindow).
+			WithStatusSubresource(maintenanceWindow).
+			Build(),
+		alertmanagerService: alertmanager.New(config.Config{
+			Monitoring: monitoring.Config{AlertmanagerURL: server.URL},
+		}),
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "upgrade"}}
+
+	// The silence is created and its ID recorded in the status.
+	if _, err := r.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(created) != 1 {
+		t.Fatalf("expected one silence to be created, got %d", len(created))
+	}
+	expectedMatchers := []alertmanager.Matcher{
+		{Name: "cluster_id", Value: "golem|grizzly", IsRegex: true, IsEqual: true},
+		{Name: "team", Value: "atlas", IsEqual: true},
+	}
+	if len(created[0].Matchers) != len(expectedMatchers) {
+		t.Fatalf("expected matchers %v, got %v", expectedMatchers, created[0].Matchers)
+	}
+	for i, matcher := range expectedMatchers {
+		if created[0].Matchers[i] != matcher {
+			t.Errorf("expected matcher %v, got %v", matcher, created[0].Matchers[i])
+		}
+	}
+
+	current := &v1alpha1.MaintenanceWindow{}
+	if err := r.client.Get(context.Background(), client.ObjectKeyFromObject(maintenanceWindow), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if current.Status.SilenceID != "silence-id" {
+		t.Errorf("expected silence ID %q in the status, got %q", "silence-id", current.Status.SilenceID)
+	}
+
+	// The silence is expired when the maintenance window is deleted.
+	if err := r.client.Delete(context.Background(), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(deleted) != 1 || deleted[0] != "/alertmanager/api/v2/silence/silence-id" {
+		t.Errorf("expected the silence to be expired, got %v", deleted)
+	}
+}
+
+func TestMaintenanceWindowReconcilerTenantChange(t *testing.T) {
+	var createdTenants, deletedTenants []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			createdTenants = append(createdTenants, r.Header.Get("X-Scope-OrgID"))
+			_, _ = w.Write([]byte(`{"silenceID":"new-silence-id"}`))
+		case http.MethodDelete:
+			if r.URL.Path != "/alertmanager/api/v2/silence/silence-id" {
+				t.Errorf("unexpected silence deleted %q", r.URL.Path)
+			}
+			deletedTenants = append(deletedTenants, r.Header.Get("X-Scope-OrgID"))
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	scheme := newTestScheme(t)
+
+	// The silence was created for the giantswarm tenant before the tenant was changed.
+	maintenanceWindow := &v1alpha1.MaintenanceWindow{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "upgrade",
+			Finalizers: []string{v1alpha1.MaintenanceWindowFinalizer},
+		},
+		Spec: v1alpha1.MaintenanceWindowSpec{
+			Tenant:   "atlas",
+			Clusters: []string{"golem"},
+			StartsAt: metav1.NewTime(time.Now().Add(-time.Hour)),
+			EndsAt:   metav1.NewTime(time.Now().Add(time.Hour)),
+		},
+		Status: v1alpha1.MaintenanceWindowStatus{
+			SilenceID: "silence-id",
+			Tenant:    "giantswarm",
+		},
+	}
+
+	r := MaintenanceWindowReconciler{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(maintenanceWindow).
+			WithStatusSubresource(maintenanceWindow).
+			Build(),
+		alertmanagerService: alertmanager.New(config.Config{
+			Monitoring: monitoring.Config{AlertmanagerURL: server.URL},
+		}),
+	}
+
+	if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "upgrade"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(deletedTenants) != 1 || deletedTenants[0] != "giantswarm" {
+		t.Errorf("expected the silence to be expired in the previous tenant, got %v", deletedTenants)
+	}
+	if len(createdTenants) != 1 || createdTenants[0] != "atlas" {
+		t.Errorf("expected the silence to be created in the new tenant, got %v", createdTenants)
+	}
+
+	current := &v1alpha1.MaintenanceWindow{}
+	if err := r.client.Get(context.Background(), client.ObjectKeyFromObject(maintenanceWindow), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if current.Status.SilenceID != "new-silence-id" || current.Status.Tenant != "atlas" {
+		t.Errorf("expected the new silence to be recorded, got %+v", current.Status)
+	}
+}
+
+func TestMaintenanceWindowReconcilerOver(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("unexpected Alertmanager request %s %s", r.Method, r.URL.Path)
+			return
+		}
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	scheme := newTestScheme(t)
+
+	// The end of the maintenance window was moved into the past while its silence is still active.
+	maintenanceWindow := &v1alpha1.MaintenanceWindow{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "upgrade",
+			Finalizers: []string{v1alpha1.MaintenanceWindowFinalizer},
+		},
+		Spec: v1alpha1.MaintenanceWindowSpec{
+			Tenant:   "giantswarm",
+			Clusters: []string{"golem"},
+			StartsAt: metav1.NewTime(time.Now().Add(-time.Hour)),
+			EndsAt:   metav1.NewTime(time.Now().Add(-time.Minute)),
+		},
+		Status: v1alpha1.MaintenanceWindowStatus{
+			SilenceID: "silence-id",
+			Tenant:    "giantswarm",
+		},
+	}
+
+	r := MaintenanceWindowReconciler{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(maintenanceWindow).
+			WithStatusSubresource(maintenanceWindow).
+			Build(),
+		alertmanagerService: alertmanager.New(config.Config{
+			Monitoring: monitoring.Config{AlertmanagerURL: server.URL},
+		}),
+	}
+
+	// The silence is only expired once.
+	for range 2 {
+		if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "upgrade"}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(deleted) != 1 || deleted[0] != "/alertmanager/api/v2/silence/silence-id" {
+		t.Errorf("expected the silence to be expired, got %v", deleted)
+	}
+
+	current := &v1alpha1.MaintenanceWindow{}
+	if err := r.client.Get(context.Background(), client.ObjectKeyFromObject(maintenanceWindow), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if current.Status.SilenceID != "" {
+		t.Errorf("expected the expired silence to be forgotten, got %q", current.Status.SilenceID)
+	}
+}
+
+func TestMaintenanceWindowReconcilerInvalid(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	// The maintenance window ends before it starts.
+	maintenanceWindow := &v1alpha1.MaintenanceWindow{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "upgrade",
+			Finalizers: []string{v1alpha1.MaintenanceWindowFinalizer},
+		},
+		Spec: v1alpha1.MaintenanceWindowSpec{
+			Clusters: []string{"golem"},
+			StartsAt: metav1.NewTime(time.Now().Add(time.Hour)),
+			EndsAt:   metav1.NewTime(time.Now().Add(-time.Hour)),
+		},
+	}
+
+	r := MaintenanceWindowReconciler{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(maintenanceWindow).
+			WithStatusSubresource(maintenanceWindow).
+			Build(),
+		alertmanagerService: alertmanager.New(config.Config{}),
+	}
+
+	// The error is surfaced in the status instead of being retried.
+	if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "upgrade"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	current := &v1alpha1.MaintenanceWindow{}
+	if err := r.client.Get(context.Background(), client.ObjectKeyFromObject(maintenanceWindow), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	condition := meta.FindStatusCondition(current.Status.Conditions, v1alpha1.ReadyCondition)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != v1alpha1.InvalidMaintenanceWindowReason {
+		t.Errorf("expected the Ready condition to be false with reason %q, got %+v", v1alpha1.InvalidMaintenanceWindowReason, condition)
+	}
+}
+
+func TestMaintenanceWindowReconcilerPaused(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected Alertmanager request %s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	maintenanceWindow := &v1alpha1.MaintenanceWindow{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "upgrade",
+			Annotations: map[string]string{pausedAnnotation: "true"},
+		},
+		Spec: v1alpha1.MaintenanceWindowSpec{
+			Clusters: []string{"golem"},
+			StartsAt: metav1.NewTime(time.Now()),
+			EndsAt:   metav1.NewTime(time.Now().Add(time.Hour)),
+		},
+	}
+
+	r := MaintenanceWindowReconciler{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(maintenanceWindow).
+			WithStatusSubresource(maintenanceWindow).
+			Build(),
+		alertmanagerService: alertmanager.New(config.Config{Monitoring: monitoring.Config{AlertmanagerURL: server.URL}}),
+	}
+
+	if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "upgrade"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	current := &v1alpha1.MaintenanceWindow{}
+	if err := r.client.Get(context.Background(), client.ObjectKeyFromObject(maintenanceWindow), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(current.Finalizers) != 0 {
+		t.Errorf("expected no finalizer on a paused maintenance window, got %v", current.Finalizers)
+	}
+}
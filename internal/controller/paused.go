@@ -0,0 +1,14 @@
+package controller
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// pausedAnnotation freezes the writes of the operator for an object when set to "true", e.g. during an incident.
+// Paused objects are not reconciled at all, their finalizers are kept until the annotation is removed.
+const pausedAnnotation = "observability.giantswarm.io/paused"
+
+// isPaused returns whether the reconciliation of the object is paused.
+func isPaused(obj client.Object) bool {
+	return obj.GetAnnotations()[pausedAnnotation] == "true"
+}
@@ -39,6 +39,34 @@ func NewAlertmanagerSecretPredicate(conf config.Config) predicate.Predicate {
 	return p
 }
 
+// NewAlertmanagerConfigMapPredicate returns a predicate that filters only the Alertmanager configmap.
+// Unlike the secret, the configmap is not created by the observability-operator Helm chart so it is only filtered by name and namespace.
+func NewAlertmanagerConfigMapPredicate(conf config.Config) predicate.Predicate {
+	filter := func(object client.Object) bool {
+		if object == nil {
+			return false
+		}
+
+		configMap, ok := object.(*v1.ConfigMap)
+		if !ok {
+			return false
+		}
+
+		if !configMap.DeletionTimestamp.IsZero() {
+			return false
+		}
+
+		ok = configMap.GetName() == conf.Monitoring.AlertmanagerConfigMapName &&
+			configMap.GetNamespace() == conf.OperatorNamespace
+
+		return ok
+	}
+
+	p := predicate.NewPredicateFuncs(filter)
+
+	return p
+}
+
 const (
 	mimirNamespace             = "mimir"
 	mimirInstance              = "mimir"
@@ -0,0 +1,73 @@
+package predicates
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// NewClusterLabelSelectorPredicate returns a predicate that filters only the clusters matching the label selector.
+// Clusters carrying the given finalizer and delete events are always let through, so a cluster which stops matching
+// the selector can still be cleaned up and its deletion is not blocked by the finalizer. The management cluster is
+// always let through as every other cluster depends on the resources reconciled for it.
+func NewClusterLabelSelectorPredicate(selector labels.Selector, finalizer string, managementClusterName string) predicate.Predicate {
+	filter := func(object client.Object) bool {
+		if object == nil {
+			return false
+		}
+
+		return object.GetName() == managementClusterName ||
+			selector.Matches(labels.Set(object.GetLabels())) ||
+			controllerutil.ContainsFinalizer(object, finalizer)
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return filter(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return filter(e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return true
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return filter(e.Object)
+		},
+	}
+}
+
+// NewIgnoreAnnotationsChangedPredicate returns a predicate that filters out the updates only changing the given annotations.
+// It prevents a controller from reconciling an object again when it records its own state in annotations.
+func NewIgnoreAnnotationsChangedPredicate(annotations ...string) predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return true
+			}
+
+			objects := make([]client.Object, 0, 2)
+			for _, object := range []client.Object{e.ObjectOld, e.ObjectNew} {
+				object, ok := object.DeepCopyObject().(client.Object)
+				if !ok {
+					return true
+				}
+
+				objectAnnotations := object.GetAnnotations()
+				for _, annotation := range annotations {
+					delete(objectAnnotations, annotation)
+				}
+				object.SetAnnotations(objectAnnotations)
+				object.SetResourceVersion("")
+				object.SetManagedFields(nil)
+
+				objects = append(objects, object)
+			}
+
+			return !equality.Semantic.DeepEqual(objects[0], objects[1])
+		},
+	}
+}
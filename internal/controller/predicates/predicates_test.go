@@ -5,6 +5,8 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
@@ -208,3 +210,132 @@ func TestGrafanaPodRecreatedPredicate_Update(t *testing.T) {
 		})
 	}
 }
+
+func TestClusterLabelSelectorPredicate(t *testing.T) {
+	selector, err := labels.Parse("observability.giantswarm.io/managed=true")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		cluster    string
+		labels     map[string]string
+		finalizers []string
+		expected   bool
+	}{
+		{
+			name:     "matching cluster",
+			labels:   map[string]string{"observability.giantswarm.io/managed": "true"},
+			expected: true,
+		},
+		{
+			name:     "cluster with another label value",
+			labels:   map[string]string{"observability.giantswarm.io/managed": "false"},
+			expected: false,
+		},
+		{
+			name:     "cluster without labels",
+			expected: false,
+		},
+		{
+			name:       "cluster not matching anymore with the finalizer",
+			labels:     map[string]string{"observability.giantswarm.io/managed": "false"},
+			finalizers: []string{"observability.giantswarm.io/monitoring"},
+			expected:   true,
+		},
+		{
+			name:     "management cluster not matching",
+			cluster:  "management",
+			labels:   map[string]string{"observability.giantswarm.io/managed": "false"},
+			expected: true,
+		},
+		{
+			name:       "cluster not matching with another finalizer",
+			finalizers: []string{"cluster.x-k8s.io/cluster"},
+			expected:   false,
+		},
+	}
+
+	p := NewClusterLabelSelectorPredicate(selector, "observability.giantswarm.io/monitoring", "management")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := tt.cluster
+			if name == "" {
+				name = "test"
+			}
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Labels: tt.labels, Finalizers: tt.finalizers},
+			}
+
+			if got := p.Create(event.CreateEvent{Object: cluster}); got != tt.expected {
+				t.Errorf("expected create %t, got %t", tt.expected, got)
+			}
+			if got := p.Update(event.UpdateEvent{ObjectOld: cluster, ObjectNew: cluster}); got != tt.expected {
+				t.Errorf("expected update %t, got %t", tt.expected, got)
+			}
+		})
+	}
+
+	if !p.Delete(event.DeleteEvent{Object: &clusterv1.Cluster{}}) {
+		t.Errorf("expected delete events to be let through")
+	}
+
+	if !NewClusterLabelSelectorPredicate(labels.Everything(), "", "").Create(event.CreateEvent{Object: &clusterv1.Cluster{}}) {
+		t.Errorf("expected an empty selector to match all clusters")
+	}
+}
+
+func TestIgnoreAnnotationsChangedPredicate(t *testing.T) {
+	cluster := func(annotations map[string]string, labels map[string]string) *clusterv1.Cluster {
+		return &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test",
+				Namespace:   "default",
+				Annotations: annotations,
+				Labels:      labels,
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		old      *clusterv1.Cluster
+		new      *clusterv1.Cluster
+		expected bool
+	}{
+		{
+			name:     "ignored annotation added",
+			old:      cluster(nil, nil),
+			new:      cluster(map[string]string{"ignored": "value"}, nil),
+			expected: false,
+		},
+		{
+			name:     "ignored annotation changed",
+			old:      cluster(map[string]string{"ignored": "value"}, nil),
+			new:      cluster(map[string]string{"ignored": "other"}, nil),
+			expected: false,
+		},
+		{
+			name:     "other annotation changed",
+			old:      cluster(map[string]string{"ignored": "value"}, nil),
+			new:      cluster(map[string]string{"ignored": "other", "other": "value"}, nil),
+			expected: true,
+		},
+		{
+			name:     "label changed",
+			old:      cluster(map[string]string{"ignored": "value"}, nil),
+			new:      cluster(map[string]string{"ignored": "other"}, map[string]string{"label": "value"}),
+			expected: true,
+		},
+	}
+
+	p := NewIgnoreAnnotationsChangedPredicate("ignored")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Update(event.UpdateEvent{ObjectOld: tt.old, ObjectNew: tt.new}); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"testing"
+
+	appv1 "github.com/giantswarm/apiextensions-application/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"github.com/giantswarm/observability-operator/api/v1alpha1"
+)
+
+// newTestScheme returns a scheme holding all the types the controllers work with, for the fake clients of the tests.
+func newTestScheme(t testing.TB) *runtime.Scheme {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, clusterv1.AddToScheme, appv1.AddToScheme, v1alpha1.AddToScheme} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	return scheme
+}
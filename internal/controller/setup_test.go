@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"github.com/giantswarm/observability-operator/pkg/common"
+	"github.com/giantswarm/observability-operator/pkg/config"
+)
+
+// recordingManager records the runnables added to the manager, which include the controllers built on it.
+type recordingManager struct {
+	manager.Manager
+	runnables []manager.Runnable
+}
+
+func (m *recordingManager) Add(runnable manager.Runnable) error {
+	m.runnables = append(m.runnables, runnable)
+	return m.Manager.Add(runnable)
+}
+
+// newRecordingManager returns a manager which is never started, so it does not need to reach an API server.
+func newRecordingManager(t *testing.T) *recordingManager {
+	t.Helper()
+
+	mgr, err := ctrl.NewManager(&rest.Config{Host: "http://127.0.0.1:1"}, ctrl.Options{
+		Scheme:  newTestScheme(t),
+		Metrics: metricsserver.Options{BindAddress: "0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return &recordingManager{Manager: mgr}
+}
+
+// maxConcurrentReconciles returns the maximum number of concurrent reconciliations of the controller with the given name added to the manager.
+func (m *recordingManager) maxConcurrentReconciles(t *testing.T, name string) int {
+	t.Helper()
+
+	for _, runnable := range m.runnables {
+		value := reflect.Indirect(reflect.ValueOf(runnable))
+		if value.Kind() != reflect.Struct || !value.FieldByName("Name").IsValid() || value.FieldByName("Name").String() != name {
+			continue
+		}
+		return int(value.FieldByName("MaxConcurrentReconciles").Int())
+	}
+
+	t.Fatalf("controller %q not found", name)
+	return 0
+}
+
+// newTestCertificate returns a self-signed certificate and its key, PEM encoded, for the Grafana client of the tests.
+func newTestCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "observability-operator"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}))
+}
+
+func TestSetupMaxConcurrentReconciles(t *testing.T) {
+	grafanaURL, err := url.Parse("http://grafana.monitoring.svc.cluster.local")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	conf := config.Config{
+		GrafanaURL:        grafanaURL,
+		ManagementCluster: common.ManagementCluster{Name: "management"},
+		MaxConcurrentReconciles: config.MaxConcurrentReconciles{
+			ClusterMonitoring:   2,
+			GrafanaOrganization: 3,
+			Dashboard:           4,
+			Alertmanager:        5,
+			MaintenanceWindow:   6,
+			LibraryPanel:        7,
+			AlertRule:           8,
+		},
+	}
+	conf.Environment.OpsgenieApiKey = "opsgenie-api-key"
+	conf.Monitoring.HeartbeatOpsgenieRegion = "eu"
+	conf.Environment.GrafanaTLSCertFile, conf.Environment.GrafanaTLSKeyFile = newTestCertificate(t)
+	conf.Environment.GrafanaAdminUsername = "admin"
+	conf.Environment.GrafanaAdminPassword = "password"
+
+	tests := []struct {
+		name       string
+		setup      func(mgr manager.Manager, conf config.Config) error
+		controller string
+		expected   int
+	}{
+		{name: "cluster monitoring", setup: SetupClusterMonitoringReconciler, controller: "cluster", expected: 2},
+		{name: "grafana organization", setup: SetupGrafanaOrganizationReconciler, controller: "grafanaorganization", expected: 3},
+		{name: "dashboard", setup: SetupDashboardReconciler, controller: "dashboards", expected: 4},
+		{name: "alertmanager", setup: SetupAlertmanagerReconciler, controller: "alertmanager", expected: 5},
+		{name: "maintenance window", setup: SetupMaintenanceWindowReconciler, controller: "maintenancewindow", expected: 6},
+		{name: "library panel", setup: SetupLibraryPanelReconciler, controller: "librarypanels", expected: 7},
+		{name: "alert rule", setup: SetupAlertRuleReconciler, controller: "alertrules", expected: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := newRecordingManager(t)
+			if err := tt.setup(mgr, conf); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := mgr.maxConcurrentReconciles(t, tt.controller); got != tt.expected {
+				t.Errorf("expected %d concurrent reconciles, got %d", tt.expected, got)
+			}
+		})
+	}
+}
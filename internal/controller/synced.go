@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// getSyncedUIDs returns the UIDs of the Grafana objects recorded in the annotation of the object, so the ones removed from it can be deleted.
+func getSyncedUIDs(obj client.Object, annotation string) []string {
+	var synced []string
+
+	value, ok := obj.GetAnnotations()[annotation]
+	if !ok {
+		return synced
+	}
+
+	// An invalid annotation only means the removed objects are left in Grafana.
+	_ = json.Unmarshal([]byte(value), &synced)
+	return synced
+}
+
+// updateSyncedUIDs records the UIDs of the Grafana objects synced from the object in its annotation.
+func updateSyncedUIDs(ctx context.Context, c client.Client, obj client.Object, annotation string, uids []string) error {
+	slices.Sort(uids)
+	value, err := json.Marshal(slices.Compact(uids))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if obj.GetAnnotations()[annotation] == string(value) {
+		return nil
+	}
+
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[annotation] = string(value)
+	obj.SetAnnotations(annotations)
+
+	return errors.WithStack(c.Patch(ctx, obj, patch))
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"net/url"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/Netflix/go-env"
 	appv1 "github.com/giantswarm/apiextensions-application/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -22,6 +24,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
@@ -29,6 +32,9 @@ import (
 	"github.com/giantswarm/observability-operator/internal/controller"
 	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
 	"github.com/giantswarm/observability-operator/pkg/config"
+	"github.com/giantswarm/observability-operator/pkg/monitoring"
+	"github.com/giantswarm/observability-operator/pkg/monitoring/heartbeat"
+	"github.com/giantswarm/observability-operator/pkg/monitoring/mimir"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -47,8 +53,103 @@ func init() {
 	//+kubebuilder:scaffold:scheme
 }
 
+const (
+	logFormatJSON    = "json"
+	logFormatConsole = "console"
+)
+
+// logEncoderOption returns the zap option configuring the encoder of the given log format.
+func logEncoderOption(format string) (zap.Opts, error) {
+	switch format {
+	case logFormatJSON:
+		return zap.JSONEncoder(), nil
+	case logFormatConsole:
+		return zap.ConsoleEncoder(), nil
+	default:
+		return nil, fmt.Errorf("unsupported log format %q", format)
+	}
+}
+
+// managerOptions returns the options of the controller manager for the given configuration.
+func managerOptions(conf config.Config, tlsOpts []func(*tls.Config), webhookServer webhook.Server) ctrl.Options {
+	return ctrl.Options{
+		Scheme: scheme,
+		Metrics: metricsserver.Options{
+			BindAddress:   conf.MetricsAddr,
+			SecureServing: conf.SecureMetrics,
+			TLSOpts:       tlsOpts,
+		},
+		WebhookServer:          webhookServer,
+		HealthProbeBindAddress: conf.ProbeAddr,
+		LeaderElection:         conf.EnableLeaderElection,
+		LeaderElectionID:       "5c99b45b.giantswarm.io",
+		LeaseDuration:          &conf.LeaderElectionLeaseDuration,
+		RenewDeadline:          &conf.LeaderElectionRenewDeadline,
+		RetryPeriod:            &conf.LeaderElectionRetryPeriod,
+		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
+		// when the Manager ends. This requires the binary to immediately end when the
+		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
+		// speeds up voluntary leader transitions as the new leader don't have to wait
+		// LeaseDuration time first.
+		//
+		// In the default scaffold provided, the program ends immediately after
+		// the manager stops, so would be fine to enable this option. However,
+		// if you are doing or is intended to do any operation such as perform cleanups
+		// after the manager stops then its usage might be unsafe.
+		// LeaderElectionReleaseOnCancel: true,
+	}
+}
+
+// controllerSetup sets up one of the controllers of the operator with the manager.
+type controllerSetup struct {
+	name  string
+	setup func(manager.Manager, config.Config) error
+}
+
+// enabledControllers returns the setup of the controllers enabled in the configuration.
+func enabledControllers(conf config.Config) []controllerSetup {
+	var setups []controllerSetup
+
+	if conf.EnabledControllers.ClusterMonitoring {
+		setups = append(setups, controllerSetup{"ClusterMonitoringReconciler", controller.SetupClusterMonitoringReconciler})
+	}
+	if conf.EnabledControllers.GrafanaOrganization {
+		setups = append(setups, controllerSetup{"GrafanaOrganizationReconciler", controller.SetupGrafanaOrganizationReconciler})
+	}
+	if conf.Monitoring.AlertmanagerEnabled && conf.EnabledControllers.Alertmanager {
+		setups = append(setups, controllerSetup{"AlertmanagerReconciler", controller.SetupAlertmanagerReconciler})
+	}
+	if conf.Monitoring.AlertmanagerEnabled && conf.EnabledControllers.MaintenanceWindow {
+		setups = append(setups, controllerSetup{"MaintenanceWindowReconciler", controller.SetupMaintenanceWindowReconciler})
+	}
+	if conf.EnabledControllers.Dashboard {
+		setups = append(setups, controllerSetup{"Dashboard", controller.SetupDashboardReconciler})
+	}
+	if conf.EnabledControllers.LibraryPanel {
+		setups = append(setups, controllerSetup{"LibraryPanel", controller.SetupLibraryPanelReconciler})
+	}
+	if conf.EnabledControllers.AlertRule {
+		setups = append(setups, controllerSetup{"AlertRule", controller.SetupAlertRuleReconciler})
+	}
+
+	return setups
+}
+
 func main() {
 	var grafanaURL string
+	var logFormat string
+	var printConfig bool
+	var clusterLabelSelector string
+	var externalLabelsFromClusterLabels string
+	var metricRelabelRules string
+	var droppedScrapeJobs string
+	var organizationOverrides string
+	var remoteWriteHeaders string
+	var clusterProviders string
+	var dashboardAllowedOrganizations string
+	var grafanaManagedOrganizations string
+	var grafanaOrganizationForbiddenTenantIDs string
+	var mimirRuntimeOverrides string
 	var err error
 
 	flag.StringVar(&conf.MetricsAddr, "metrics-bind-address", ":8080",
@@ -58,14 +159,99 @@ func main() {
 	flag.BoolVar(&conf.EnableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&conf.LeaderElectionLeaseDuration, "leader-elect-lease-duration", 15*time.Second,
+		"The duration non-leader candidates wait before trying to acquire the leadership.")
+	flag.DurationVar(&conf.LeaderElectionRenewDeadline, "leader-elect-renew-deadline", 10*time.Second,
+		"The duration the leader retries refreshing the leadership before giving it up.")
+	flag.DurationVar(&conf.LeaderElectionRetryPeriod, "leader-elect-retry-period", 2*time.Second,
+		"The duration candidates wait between leader election actions.")
 	flag.BoolVar(&conf.SecureMetrics, "metrics-secure", false,
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&conf.EnableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.IntVar(&conf.MaxConcurrentReconciles.ClusterMonitoring, "cluster-monitoring-max-concurrent-reconciles", 1,
+		"The maximum number of clusters reconciled concurrently.")
+	flag.IntVar(&conf.MaxConcurrentReconciles.GrafanaOrganization, "grafana-organization-max-concurrent-reconciles", 1,
+		"The maximum number of Grafana organizations reconciled concurrently.")
+	flag.IntVar(&conf.MaxConcurrentReconciles.Dashboard, "dashboard-max-concurrent-reconciles", 1,
+		"The maximum number of dashboard configmaps reconciled concurrently.")
+	flag.IntVar(&conf.MaxConcurrentReconciles.LibraryPanel, "library-panel-max-concurrent-reconciles", 1,
+		"The maximum number of library panel configmaps reconciled concurrently.")
+	flag.IntVar(&conf.MaxConcurrentReconciles.AlertRule, "alert-rule-max-concurrent-reconciles", 1,
+		"The maximum number of alert rule configmaps reconciled concurrently.")
+	flag.IntVar(&conf.MaxConcurrentReconciles.Alertmanager, "alertmanager-max-concurrent-reconciles", 1,
+		"The maximum number of Alertmanager configurations reconciled concurrently.")
+	flag.IntVar(&conf.MaxConcurrentReconciles.MaintenanceWindow, "maintenance-window-max-concurrent-reconciles", 1,
+		"The maximum number of maintenance windows reconciled concurrently.")
+	flag.BoolVar(&conf.EnabledControllers.ClusterMonitoring, "enable-cluster-monitoring-controller", true,
+		"Enable the controller configuring the monitoring of the clusters.")
+	flag.BoolVar(&conf.EnabledControllers.GrafanaOrganization, "enable-grafanaorg-controller", true,
+		"Enable the controller provisioning the Grafana organizations.")
+	flag.BoolVar(&conf.EnabledControllers.Dashboard, "enable-dashboard-controller", true,
+		"Enable the controller provisioning the Grafana dashboards.")
+	flag.BoolVar(&conf.EnabledControllers.LibraryPanel, "enable-library-panel-controller", true,
+		"Enable the controller provisioning the Grafana library panels.")
+	flag.BoolVar(&conf.EnabledControllers.AlertRule, "enable-alert-rule-controller", true,
+		"Enable the controller provisioning the Grafana alert rules.")
+	flag.BoolVar(&conf.EnabledControllers.Alertmanager, "enable-alertmanager-controller", true,
+		"Enable the controller configuring the Alertmanager, when the Alertmanager is enabled.")
+	flag.BoolVar(&conf.EnabledControllers.MaintenanceWindow, "enable-maintenance-window-controller", true,
+		"Enable the controller silencing the alerts during maintenance windows, when the Alertmanager is enabled.")
 	flag.StringVar(&conf.OperatorNamespace, "operator-namespace", "",
 		"The namespace where the observability-operator is running.")
 	flag.StringVar(&grafanaURL, "grafana-url", "http://grafana.monitoring.svc.cluster.local",
 		"grafana URL")
+	flag.DurationVar(&conf.GrafanaRequestTimeout, "grafana-request-timeout", 30*time.Second,
+		"The maximum duration of a request to the Grafana API. Requests are not limited when set to 0.")
+	flag.StringVar(&logFormat, "log-format", logFormatJSON,
+		fmt.Sprintf("The format of the operator logs (%s or %s).", logFormatJSON, logFormatConsole))
+	flag.BoolVar(&printConfig, "print-config", false,
+		"Log the effective configuration, with the secrets redacted, and exit.")
+	flag.BoolVar(&conf.DashboardPermissionsEnabled, "dashboard-permissions-enabled", false,
+		"Enable the configuration of dashboard permissions based on the organization RBAC configuration.")
+	flag.IntVar(&conf.GrafanaOrganizationTenantLimit, "grafana-organization-tenant-limit", 0,
+		"The number of tenants above which a warning is emitted for a Grafana organization. There is no limit when set to 0.")
+	flag.IntVar(&conf.GrafanaOrganizationTenantIDMaxLength, "grafana-organization-tenant-id-max-length", 0,
+		fmt.Sprintf("The maximum length of the tenant IDs of Grafana organizations, at most %d. The CRD limit applies when set to 0.", observabilityv1alpha1.TenantIDMaxLength))
+	flag.StringVar(&conf.GrafanaOrganizationTenantIDPrefix, "grafana-organization-tenant-id-prefix", "",
+		"The prefix required on the tenant IDs of Grafana organizations. No prefix is required when empty.")
+	flag.StringVar(&conf.GrafanaOrganizationAdminPattern, "grafana-organization-admin-pattern", "",
+		"The regular expression the admins of Grafana organizations must fully match, e.g. the format of the organizations of the auth provider. No pattern is required when empty.")
+	flag.StringVar(&grafanaOrganizationForbiddenTenantIDs, "grafana-organization-forbidden-tenant-ids", "",
+		"JSON list of the tenant IDs which cannot be used by Grafana organizations.")
+	flag.DurationVar(&conf.GrafanaOrganizationResyncPeriod, "grafana-organization-resync-period", 30*time.Minute,
+		"The period after which Grafana organizations are reconciled again to repair drifted datasources. Organizations are not resynced when 0.")
+	flag.BoolVar(&conf.GrafanaOrganizationJanitorEnabled, "grafana-organization-janitor-enabled", false,
+		"Enable the periodic deletion of the Grafana organizations created by the operator which no longer have a GrafanaOrganization.")
+	flag.BoolVar(&conf.GrafanaOrganizationJanitorDryRun, "grafana-organization-janitor-dry-run", true,
+		"Only log the orphaned Grafana organizations instead of deleting them.")
+	flag.DurationVar(&conf.GrafanaOrganizationJanitorInterval, "grafana-organization-janitor-interval", time.Hour,
+		"The interval at which orphaned Grafana organizations are looked for.")
+	flag.StringVar(&grafanaManagedOrganizations, "grafana-managed-organizations", "",
+		"JSON list of the display names of the Grafana organizations the operator is allowed to manage. All organizations are managed when empty.")
+	flag.StringVar(&conf.GrafanaServiceAccountSecretNamespace, "grafana-service-account-secret-namespace", "",
+		"The namespace of the secrets holding the Grafana service account tokens. Defaults to the operator namespace.")
+
+	flag.StringVar(&clusterLabelSelector, "cluster-label-selector", "",
+		"Label selector restricting the clusters managed by the operator. All clusters are managed when empty. The management cluster is always managed.")
+	flag.StringVar(&conf.MonitoringFinalizer, "monitoring-finalizer", monitoring.MonitoringFinalizer,
+		"The finalizer added to the clusters. Set a distinct one on each instance when several instances of the operator run side by side. The previous finalizer is not removed when it is changed, see the README.")
+
+	flag.IntVar(&conf.DashboardMaxSize, "dashboard-max-size", 0,
+		"The maximum size in bytes of a dashboard pushed to Grafana. The size is not limited when set to 0.")
+	flag.StringVar(&conf.DashboardKeySuffix, "dashboard-key-suffix", "",
+		"The suffix of the keys of the dashboard configmaps holding dashboards, the other keys are ignored. All keys hold dashboards when empty.")
+	flag.StringVar(&conf.DashboardFinalizer, "dashboard-finalizer", controller.DashboardFinalizer,
+		"The finalizer added to the dashboard configmaps. Set a distinct one on each instance when several instances of the operator run side by side. The previous finalizer is not removed when it is changed, see the README.")
+
+	flag.StringVar(&conf.DashboardDefaultRefresh, "dashboard-default-refresh", "",
+		"The refresh interval set on dashboards which do not define one (e.g. 1m). Dashboards are left unchanged when empty.")
+	flag.StringVar(&conf.DashboardDefaultTimeFrom, "dashboard-default-time-from", "",
+		"The start of the time range set on dashboards which do not define one (e.g. now-6h). Dashboards are left unchanged when empty.")
+	flag.StringVar(&conf.DashboardTenantVariable, "dashboard-tenant-variable", "",
+		"The name of the dashboard template variable populated with the tenant IDs of the organization (e.g. tenant). Variables are left unchanged when empty.")
+	flag.StringVar(&dashboardAllowedOrganizations, "dashboard-allowed-organizations", "",
+		"JSON object mapping namespaces to the list of organizations their dashboards, library panels and alert rules may be pushed to. They are not restricted when empty.")
 
 	// Management cluster configuration flags.
 	flag.StringVar(&conf.ManagementCluster.BaseDomain, "management-cluster-base-domain", "",
@@ -86,20 +272,92 @@ func main() {
 		"Enable Alertmanager controller.")
 	flag.StringVar(&conf.Monitoring.AlertmanagerSecretName, "alertmanager-secret-name", "",
 		"The name of the secret containing the Alertmanager configuration.")
+	flag.StringVar(&conf.Monitoring.AlertmanagerConfigMapName, "alertmanager-configmap-name", "",
+		"The name of the configmap containing the Alertmanager configuration. It cannot be used together with --alertmanager-secret-name.")
 	flag.StringVar(&conf.Monitoring.AlertmanagerURL, "alertmanager-url", "",
-		"The URL of the Alertmanager API.")
+		"The URL of the Alertmanager API, or a comma separated list of URLs tried in order until one of them succeeds.")
+	flag.DurationVar(&conf.Monitoring.HeartbeatInterval, "monitoring-heartbeat-interval", heartbeat.DefaultInterval,
+		"Configures the interval after which the management cluster heartbeat expires if it was not pinged. It is rounded down to the minute.")
+	flag.IntVar(&conf.Monitoring.HeartbeatFailureThreshold, "monitoring-heartbeat-failure-threshold", heartbeat.DefaultFailureThreshold,
+		"Configures the number of consecutive heartbeat failures after which the heartbeat failure webhook is notified.")
+	flag.IntVar(&conf.Monitoring.HeartbeatRetryCount, "monitoring-heartbeat-retry-count", heartbeat.DefaultRetryCount,
+		"Configures the number of times a heartbeat API call failing with a network error or a 5xx response is retried, 0 disables the retries.")
+	flag.StringVar(&conf.Monitoring.HeartbeatOpsgenieRegion, "monitoring-heartbeat-opsgenie-region", heartbeat.OpsgenieRegionUS,
+		fmt.Sprintf("Configures the region of the Opsgenie account the heartbeats are sent to (%s or %s).", heartbeat.OpsgenieRegionUS, heartbeat.OpsgenieRegionEU))
+	flag.StringVar(&conf.Monitoring.MimirNamespace, "mimir-namespace", mimir.DefaultNamespace,
+		"The namespace where Mimir is deployed.")
+	flag.StringVar(&conf.Monitoring.MimirAuthSecretName, "mimir-auth-secret-name", mimir.DefaultAuthSecretName,
+		"The name of the secret holding the password used by the monitoring agents to authenticate against Mimir.")
+	flag.StringVar(&conf.Monitoring.MimirIngressAuthSecretName, "mimir-ingress-auth-secret-name", mimir.DefaultIngressAuthSecretName,
+		"The name of the secret holding the htpasswd used by the Mimir gateway ingress.")
+	flag.DurationVar(&conf.Monitoring.MimirPasswordMaxAge, "mimir-password-max-age", 0,
+		"The age after which the password used to authenticate against Mimir is rotated. Rotation is disabled when set to 0.")
+	flag.DurationVar(&conf.Monitoring.MimirPasswordRotationGracePeriod, "mimir-password-rotation-grace-period", time.Hour,
+		"How long the Mimir ingress keeps accepting the previous password after a rotation, while the monitoring agents are updated.")
+	flag.StringVar(&conf.Monitoring.MimirRuntimeOverridesConfigMapName, "mimir-runtime-overrides-configmap-name", "",
+		"The name of the Mimir runtime overrides configmap. The runtime overrides are not managed when empty.")
+	flag.StringVar(&mimirRuntimeOverrides, "mimir-runtime-overrides", "",
+		"JSON object of the Mimir limits merged into the runtime overrides, indexed by tenant.")
+	flag.StringVar(&conf.Monitoring.RemoteWriteAuthMethod, "monitoring-remote-write-auth-method", monitoring.RemoteWriteAuthMethodBasicAuth,
+		fmt.Sprintf("The method the Alloy monitoring agents use to authenticate against Mimir (%s or %s). prometheus-agent only supports %s, so clusters running it fail to reconcile with %s.", monitoring.RemoteWriteAuthMethodBasicAuth, monitoring.RemoteWriteAuthMethodTLS, monitoring.RemoteWriteAuthMethodBasicAuth, monitoring.RemoteWriteAuthMethodTLS))
+	flag.StringVar(&conf.Monitoring.RemoteWriteTLSSecretName, "monitoring-remote-write-tls-secret-name", "",
+		"The name of the kubernetes.io/tls secret in the Mimir namespace holding the client certificate used by the tls remote write authentication method.")
 	flag.StringVar(&conf.Monitoring.MonitoringAgent, "monitoring-agent", commonmonitoring.MonitoringAgentAlloy,
-		fmt.Sprintf("select monitoring agent to use (%s or %s)", commonmonitoring.MonitoringAgentPrometheus, commonmonitoring.MonitoringAgentAlloy))
+		fmt.Sprintf("select monitoring agent to use (%s or %s), it can be overridden per cluster with the %s annotation", commonmonitoring.MonitoringAgentPrometheus, commonmonitoring.MonitoringAgentAlloy, commonmonitoring.MonitoringAgentOverrideAnnotation))
 	flag.BoolVar(&conf.Monitoring.Enabled, "monitoring-enabled", false,
 		"Enable monitoring at the management cluster level.")
-	flag.Float64Var(&conf.Monitoring.DefaultShardingStrategy.ScaleUpSeriesCount, "monitoring-sharding-scale-up-series-count", 0,
-		"Configures the number of time series needed to add an extra prometheus agent shard.")
+	flag.DurationVar(&conf.Monitoring.UnmonitoredGracePeriod, "monitoring-unmonitored-grace-period", 0,
+		"Configures the delay before the monitoring of a cluster is torn down once it is disabled, enabling it again within the delay is a no-op. Monitoring is torn down immediately when set to 0.")
+	flag.BoolVar(&conf.Monitoring.SkipObservabilityBundleManagement, "skip-observability-bundle-management", false,
+		"Skip the configuration of the observability-bundle app of the clusters, e.g. when the bundle is configured by another tool.")
+	flag.DurationVar(&conf.Monitoring.ObservabilityBundleNotFoundRequeueAfter, "monitoring-observability-bundle-not-found-requeue-after", time.Minute,
+		"Configures the delay after which clusters whose observability-bundle app is not found yet are reconciled again, must be positive.")
+	flag.IntVar(&conf.Monitoring.ObservabilityBundleNotFoundMaxAttempts, "monitoring-observability-bundle-not-found-max-attempts", 0,
+		"Configures the number of consecutive reconciliations after which clusters without an observability-bundle app are not requeued anymore, until the cluster changes. They are requeued until the app is found when set to 0.")
+	flag.Float64Var(&conf.Monitoring.DefaultShardingStrategy.ScaleUpSeriesCount, "monitoring-sharding-scale-up-series-count", 1000000,
+		"Configures the number of time series needed to add an extra prometheus agent shard, must be positive.")
 	flag.Float64Var(&conf.Monitoring.DefaultShardingStrategy.ScaleDownPercentage, "monitoring-sharding-scale-down-percentage", 0,
 		"Configures the percentage of removed series to scale down the number of prometheus agent shards.")
+	flag.IntVar(&conf.Monitoring.DefaultShardingStrategy.ScalingReconciles, "monitoring-sharding-scaling-reconciles", 0,
+		"Configures the number of consecutive reconciliations the number of shards must be computed above or below the current one before scaling. Shards are scaled immediately when 0 or 1. It counts reconciliations, which are event driven, rather than a time window.")
+	flag.IntVar(&conf.Monitoring.DefaultShardingStrategy.MaxShards, "monitoring-sharding-max-shards", 0,
+		"Configures the maximum number of prometheus agent shards, to avoid runaway scaling. The number of shards is not limited when 0.")
 	flag.StringVar(&conf.Monitoring.PrometheusVersion, "prometheus-version", "",
 		"The version of Prometheus Agents to deploy.")
 	flag.DurationVar(&conf.Monitoring.WALTruncateFrequency, "monitoring-wal-truncate-frequency", 2*time.Hour,
 		"Configures how frequently the Write-Ahead Log (WAL) truncates segments.")
+	flag.DurationVar(&conf.Monitoring.QueueConfigSampleAgeLimit, "monitoring-queue-config-sample-age-limit", 0,
+		"Overrides the remote write sample age limit which otherwise depends on the number of shards of the cluster.")
+	flag.DurationVar(&conf.Monitoring.QueueConfigBatchSendDeadline, "monitoring-queue-config-batch-send-deadline", 0,
+		"Overrides the remote write batch send deadline which otherwise depends on the number of shards of the cluster.")
+	flag.BoolVar(&conf.Monitoring.AlloyConfigDebugEndpointEnabled, "monitoring-alloy-config-debug-endpoint-enabled", false,
+		"Serve the Alloy configuration generated for a cluster on the metrics address under /debug/alloy-config?cluster=<name>.")
+	flag.DurationVar(&conf.Monitoring.ScrapeTimeout, "monitoring-scrape-timeout", 0,
+		"Configures the scrape timeout of the Alloy monitoring agent, it can be overridden per cluster with the monitoring.giantswarm.io/scrape-timeout annotation. It cannot exceed the scrape interval and requires observability-bundle 2.2.0 or later.")
+	flag.StringVar(&droppedScrapeJobs, "monitoring-dropped-scrape-jobs", "",
+		"JSON list of the scrape jobs dropped by the Alloy monitoring agent, it can be overridden per cluster with the comma separated monitoring.giantswarm.io/dropped-scrape-jobs annotation. Requires observability-bundle 2.2.0 or later.")
+	flag.StringVar(&conf.Monitoring.DefaultWriteTenant, "monitoring-default-write-tenant", commonmonitoring.DefaultWriteTenant,
+		"The tenant the monitoring agents write metrics to.")
+	flag.StringVar(&conf.Monitoring.ManagementClusterWriteTenant, "monitoring-management-cluster-write-tenant", "",
+		"The tenant the monitoring agent of the management cluster writes metrics to. Defaults to the default write tenant when empty.")
+	flag.StringVar(&conf.Monitoring.OrgIDHeader, "monitoring-org-id-header", commonmonitoring.OrgIDHeader,
+		"The name of the header carrying the Mimir tenant in the requests of the monitoring agents, the Grafana datasources and the Alertmanager API calls.")
+	flag.StringVar(&remoteWriteHeaders, "monitoring-remote-write-headers", "",
+		"JSON object of static headers added to the remote write requests of the Alloy monitoring agent. The tenant header, whatever its case, is rejected.")
+	flag.BoolVar(&conf.Monitoring.OTLPReceiverEnabled, "monitoring-otlp-receiver-enabled", false,
+		"Enable the OTLP receiver in the Alloy monitoring agent.")
+	flag.IntVar(&conf.Monitoring.OTLPReceiverGRPCPort, "monitoring-otlp-receiver-grpc-port", commonmonitoring.OTLPReceiverGRPCPort,
+		"The port the Alloy OTLP receiver listens on for gRPC.")
+	flag.IntVar(&conf.Monitoring.OTLPReceiverHTTPPort, "monitoring-otlp-receiver-http-port", commonmonitoring.OTLPReceiverHTTPPort,
+		"The port the Alloy OTLP receiver listens on for HTTP.")
+	flag.StringVar(&clusterProviders, "monitoring-cluster-providers", "",
+		"JSON object mapping the kinds of the cluster infrastructure references to the provider of the clusters, on top of the kinds supported by default.")
+	flag.StringVar(&externalLabelsFromClusterLabels, "monitoring-external-labels-from-cluster-labels", "",
+		"Comma separated list of cluster_label=external_label pairs copying cluster labels into the external labels of the monitoring agents.")
+	flag.StringVar(&organizationOverrides, "organization-overrides", "",
+		"JSON object mapping clusters, as <namespace>/<name>, or namespaces to their organization when it cannot be derived from the namespace.")
+	flag.StringVar(&metricRelabelRules, "monitoring-metric-relabel-rules", "",
+		"JSON list of the rules, with an action (drop or keep) and a regex, applied in order to the metric names before the monitoring agents send them to Mimir.")
 	flag.StringVar(&conf.Monitoring.MetricsQueryURL, "monitoring-metrics-query-url", "http://mimir-gateway.mimir.svc/prometheus",
 		"URL to query for cluster metrics")
 	opts := zap.Options{
@@ -115,7 +373,104 @@ func main() {
 		panic(fmt.Sprintf("failed to parse grafana url: %v", err))
 	}
 
-	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	// parse mimir runtime overrides
+	if mimirRuntimeOverrides != "" {
+		err = json.Unmarshal([]byte(mimirRuntimeOverrides), &conf.Monitoring.MimirRuntimeOverrides)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse mimir runtime overrides: %v", err))
+		}
+	}
+
+	// parse cluster label selector
+	conf.ClusterLabelSelector, err = labels.Parse(clusterLabelSelector)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse cluster label selector: %v", err))
+	}
+
+	// parse the cluster labels copied into the external labels
+	if externalLabelsFromClusterLabels != "" {
+		conf.Monitoring.ExternalLabelsFromClusterLabels, err = labels.ConvertSelectorToLabelsMap(externalLabelsFromClusterLabels)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse external labels from cluster labels: %v", err))
+		}
+	}
+
+	// parse the dashboard allowed organizations
+	if dashboardAllowedOrganizations != "" {
+		err = json.Unmarshal([]byte(dashboardAllowedOrganizations), &conf.DashboardAllowedOrganizations)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse dashboard allowed organizations: %v", err))
+		}
+	}
+
+	// parse the grafana managed organizations
+	if grafanaManagedOrganizations != "" {
+		err = json.Unmarshal([]byte(grafanaManagedOrganizations), &conf.GrafanaManagedOrganizations)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse grafana managed organizations: %v", err))
+		}
+	}
+
+	// parse the grafana organization forbidden tenant IDs
+	if grafanaOrganizationForbiddenTenantIDs != "" {
+		err = json.Unmarshal([]byte(grafanaOrganizationForbiddenTenantIDs), &conf.GrafanaOrganizationForbiddenTenantIDs)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse grafana organization forbidden tenant IDs: %v", err))
+		}
+	}
+
+	// parse the cluster providers
+	if clusterProviders != "" {
+		err = json.Unmarshal([]byte(clusterProviders), &conf.Monitoring.ClusterProviders)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse cluster providers: %v", err))
+		}
+	}
+
+	// parse the remote write headers
+	if remoteWriteHeaders != "" {
+		err = json.Unmarshal([]byte(remoteWriteHeaders), &conf.Monitoring.RemoteWriteHeaders)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse remote write headers: %v", err))
+		}
+	}
+
+	// parse the organization overrides
+	if organizationOverrides != "" {
+		err = json.Unmarshal([]byte(organizationOverrides), &conf.OrganizationOverrides)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse organization overrides: %v", err))
+		}
+	}
+
+	// parse the dropped scrape jobs
+	if droppedScrapeJobs != "" {
+		err = json.Unmarshal([]byte(droppedScrapeJobs), &conf.Monitoring.DroppedScrapeJobs)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse dropped scrape jobs: %v", err))
+		}
+	}
+
+	// parse the metric relabel rules
+	if metricRelabelRules != "" {
+		err = json.Unmarshal([]byte(metricRelabelRules), &conf.Monitoring.MetricRelabelRules)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse metric relabel rules: %v", err))
+		}
+	}
+
+	// the validation errors are logged along with the configuration when it is printed
+	validationErr := conf.Validate()
+	if validationErr != nil && !printConfig {
+		panic(fmt.Sprintf("invalid configuration: %v", validationErr))
+	}
+
+	logEncoder, err := logEncoderOption(logFormat)
+	if err != nil {
+		panic(fmt.Sprintf("failed to configure the log format: %v", err))
+	}
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts), logEncoder))
 
 	// Load environment variables.
 	_, err = env.UnmarshalFromEnviron(&conf.Environment)
@@ -124,6 +479,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	if printConfig {
+		setupLog.Info("effective configuration", "config", conf.Redacted())
+		if validationErr != nil {
+			setupLog.Error(validationErr, "invalid configuration")
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancelation and
@@ -144,29 +508,7 @@ func main() {
 		TLSOpts: tlsOpts,
 	})
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme: scheme,
-		Metrics: metricsserver.Options{
-			BindAddress:   conf.MetricsAddr,
-			SecureServing: conf.SecureMetrics,
-			TLSOpts:       tlsOpts,
-		},
-		WebhookServer:          webhookServer,
-		HealthProbeBindAddress: conf.ProbeAddr,
-		LeaderElection:         conf.EnableLeaderElection,
-		LeaderElectionID:       "5c99b45b.giantswarm.io",
-		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
-		// when the Manager ends. This requires the binary to immediately end when the
-		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
-		// speeds up voluntary leader transitions as the new leader don't have to wait
-		// LeaseDuration time first.
-		//
-		// In the default scaffold provided, the program ends immediately after
-		// the manager stops, so would be fine to enable this option. However,
-		// if you are doing or is intended to do any operation such as perform cleanups
-		// after the manager stops then its usage might be unsafe.
-		// LeaderElectionReleaseOnCancel: true,
-	})
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), managerOptions(conf, tlsOpts, webhookServer))
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
@@ -175,34 +517,13 @@ func main() {
 	// Initialize event recorder.
 	record.InitFromRecorder(mgr.GetEventRecorderFor("observability-operator"))
 
-	// Setup controller for the Cluster resource.
-	err = controller.SetupClusterMonitoringReconciler(mgr, conf)
-	if err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ClusterMonitoringReconciler")
-		os.Exit(1)
-	}
-
-	// Setup controller for the GrafanaOrganization resource.
-	err = controller.SetupGrafanaOrganizationReconciler(mgr, conf)
-	if err != nil {
-		setupLog.Error(err, "unable to setup controller", "controller", "GrafanaOrganizationReconciler")
-		os.Exit(1)
-	}
-
-	if conf.Monitoring.AlertmanagerEnabled {
-		// Setup controller for Alertmanager
-		err = controller.SetupAlertmanagerReconciler(mgr, conf)
+	for _, c := range enabledControllers(conf) {
+		err = c.setup(mgr, conf)
 		if err != nil {
-			setupLog.Error(err, "unable to setup controller", "controller", "AlertmanagerReconciler")
+			setupLog.Error(err, "unable to setup controller", "controller", c.name)
 			os.Exit(1)
 		}
 	}
-
-	err = controller.SetupDashboardReconciler(mgr, conf)
-	if err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Dashboard")
-		os.Exit(1)
-	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
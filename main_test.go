@@ -0,0 +1,117 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/giantswarm/observability-operator/pkg/config"
+)
+
+func TestLogEncoderOption(t *testing.T) {
+	for _, format := range []string{logFormatJSON, logFormatConsole} {
+		t.Run(format, func(t *testing.T) {
+			option, err := logEncoderOption(format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			logger := zap.New(option)
+			logger.Info("test message")
+		})
+	}
+
+	if _, err := logEncoderOption("xml"); err == nil {
+		t.Errorf("expected an error for an unsupported log format")
+	}
+}
+
+func TestManagerOptionsLeaderElection(t *testing.T) {
+	conf := config.Config{
+		EnableLeaderElection:        true,
+		LeaderElectionLeaseDuration: 60 * time.Second,
+		LeaderElectionRenewDeadline: 40 * time.Second,
+		LeaderElectionRetryPeriod:   5 * time.Second,
+	}
+
+	options := managerOptions(conf, nil, nil)
+
+	if !options.LeaderElection {
+		t.Errorf("expected leader election to be enabled")
+	}
+	if options.LeaseDuration == nil || *options.LeaseDuration != conf.LeaderElectionLeaseDuration {
+		t.Errorf("expected lease duration %s, got %v", conf.LeaderElectionLeaseDuration, options.LeaseDuration)
+	}
+	if options.RenewDeadline == nil || *options.RenewDeadline != conf.LeaderElectionRenewDeadline {
+		t.Errorf("expected renew deadline %s, got %v", conf.LeaderElectionRenewDeadline, options.RenewDeadline)
+	}
+	if options.RetryPeriod == nil || *options.RetryPeriod != conf.LeaderElectionRetryPeriod {
+		t.Errorf("expected retry period %s, got %v", conf.LeaderElectionRetryPeriod, options.RetryPeriod)
+	}
+}
+
+func TestEnabledControllers(t *testing.T) {
+	allEnabled := config.EnabledControllers{
+		ClusterMonitoring:   true,
+		GrafanaOrganization: true,
+		Dashboard:           true,
+		LibraryPanel:        true,
+		AlertRule:           true,
+		Alertmanager:        true,
+		MaintenanceWindow:   true,
+	}
+
+	tests := []struct {
+		name                string
+		enabledControllers  func(config.EnabledControllers) config.EnabledControllers
+		alertmanagerEnabled bool
+		expected            []string
+	}{
+		{
+			name:                "all controllers",
+			alertmanagerEnabled: true,
+			expected:            []string{"ClusterMonitoringReconciler", "GrafanaOrganizationReconciler", "AlertmanagerReconciler", "MaintenanceWindowReconciler", "Dashboard", "LibraryPanel", "AlertRule"},
+		},
+		{
+			name:     "alertmanager disabled",
+			expected: []string{"ClusterMonitoringReconciler", "GrafanaOrganizationReconciler", "Dashboard", "LibraryPanel", "AlertRule"},
+		},
+		{
+			name: "alertmanager controller disabled",
+			enabledControllers: func(c config.EnabledControllers) config.EnabledControllers {
+				c.Alertmanager = false
+				return c
+			},
+			alertmanagerEnabled: true,
+			expected:            []string{"ClusterMonitoringReconciler", "GrafanaOrganizationReconciler", "MaintenanceWindowReconciler", "Dashboard", "LibraryPanel", "AlertRule"},
+		},
+		{
+			name: "dashboard controller only",
+			enabledControllers: func(config.EnabledControllers) config.EnabledControllers {
+				return config.EnabledControllers{Dashboard: true}
+			},
+			alertmanagerEnabled: true,
+			expected:            []string{"Dashboard"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.Config{EnabledControllers: allEnabled}
+			if tt.enabledControllers != nil {
+				conf.EnabledControllers = tt.enabledControllers(allEnabled)
+			}
+			conf.Monitoring.AlertmanagerEnabled = tt.alertmanagerEnabled
+
+			var names []string
+			for _, c := range enabledControllers(conf) {
+				names = append(names, c.name)
+			}
+			if !slices.Equal(names, tt.expected) {
+				t.Errorf("expected controllers %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
@@ -8,32 +8,55 @@ import (
 	"net/http"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/alertmanager/config"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/yaml"
 
-	common "github.com/giantswarm/observability-operator/pkg/common/monitoring"
+	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
 	pkgconfig "github.com/giantswarm/observability-operator/pkg/config"
 )
 
 const (
 	// Those values are used to retrieve the Alertmanager configuration from the secret named after conf.Monitoring.AlertmanagerSecretName
-	// alertmanagerConfigKey is the key to the alertmanager configuration in the secret
+	// or the configmap named after conf.Monitoring.AlertmanagerConfigMapName
+	// alertmanagerConfigKey is the key to the alertmanager configuration in the secret or configmap
 	alertmanagerConfigKey = "alertmanager.yaml"
-	// templatesSuffix is the suffix used to identify the templates in the secret
+	// templatesSuffix is the suffix used to identify the templates in the secret or configmap
 	templatesSuffix = ".tmpl"
 
 	alertmanagerAPIPath = "/api/v1/alerts"
 
-	//TODO: get this from somewhere
-	tenantID = "anonymous"
+	// StrictInhibitRulesAnnotation makes the configuration be rejected instead of only logging a warning when an inhibition rule uses deprecated fields.
+	StrictInhibitRulesAnnotation = "observability.giantswarm.io/strict-inhibit-rules"
+
+	// requestTimeout bounds each request to an Alertmanager URL, so an unresponsive URL fails over to the next one.
+	requestTimeout = 30 * time.Second
+
+	// PlatformTenant is the tenant whose Alertmanager configuration is managed from the platform configuration by the AlertmanagerReconciler.
+	// It cannot be configured by a GrafanaOrganization.
+	PlatformTenant = "anonymous"
 )
 
 type Service struct {
-	alertmanagerURL string
+	// alertmanagerURLs are the URLs of the Alertmanager API, tried in order until one of them succeeds.
+	alertmanagerURLs []string
+	// orgIDHeader is the name of the header carrying the tenant of the requests, X-Scope-OrgID when it is empty.
+	orgIDHeader string
+	// httpClient sends the requests to the Alertmanager API.
+	httpClient *http.Client
+}
+
+// orgIDHeaderName returns the name of the header carrying the tenant of the requests.
+func (s Service) orgIDHeaderName() string {
+	if s.orgIDHeader == "" {
+		return commonmonitoring.OrgIDHeader
+	}
+	return s.orgIDHeader
 }
 
 // configRequest is the structure used to send the configuration to Alertmanager's API
@@ -43,32 +66,98 @@ type configRequest struct {
 	AlertmanagerConfig string            `json:"alertmanager_config"`
 }
 
+// New creates a Service for the comma separated list of Alertmanager URLs of the configuration.
 func New(conf pkgconfig.Config) Service {
 	service := Service{
-		alertmanagerURL: strings.TrimSuffix(conf.Monitoring.AlertmanagerURL, "/"),
+		orgIDHeader: conf.Monitoring.OrgIDHeaderName(),
+		httpClient:  &http.Client{Timeout: requestTimeout},
+	}
+	for _, alertmanagerURL := range strings.Split(conf.Monitoring.AlertmanagerURL, ",") {
+		alertmanagerURL = strings.TrimSuffix(strings.TrimSpace(alertmanagerURL), "/")
+		if alertmanagerURL != "" {
+			service.alertmanagerURLs = append(service.alertmanagerURLs, alertmanagerURL)
+		}
 	}
 
 	return service
 }
 
-func (s Service) Configure(ctx context.Context, secret *v1.Secret) error {
+// doWithFailover calls do with each Alertmanager URL in order until one of them succeeds.
+// It does not fail over on 4xx responses, which the other URLs would reject as well.
+// The errors of all the URLs which were tried are returned when none of them succeeds.
+func (s Service) doWithFailover(ctx context.Context, do func(alertmanagerURL string) error) error {
 	logger := log.FromContext(ctx)
 
-	logger.Info("Alertmanager: configuring")
+	if len(s.alertmanagerURLs) == 0 {
+		return errors.WithStack(fmt.Errorf("alertmanager: no URL configured"))
+	}
 
+	var urlErrors []error
+	for i, alertmanagerURL := range s.alertmanagerURLs {
+		err := do(alertmanagerURL)
+		if err == nil {
+			return nil
+		}
+		urlErrors = append(urlErrors, errors.Wrapf(err, "alertmanager: request to %s failed", alertmanagerURL))
+
+		if isClientError(err) {
+			break
+		}
+		if i < len(s.alertmanagerURLs)-1 {
+			logger.Info("Alertmanager: request failed, failing over to the next URL", "url", alertmanagerURL, "error", err.Error())
+		}
+	}
+
+	return kerrors.NewAggregate(urlErrors)
+}
+
+// isClientError returns true if the error is a 4xx response of the Alertmanager API.
+func isClientError(err error) bool {
+	var apiError APIError
+	return errors.As(err, &apiError) && apiError.Code >= http.StatusBadRequest && apiError.Code < http.StatusInternalServerError
+}
+
+func (s Service) Configure(ctx context.Context, secret *v1.Secret) error {
 	if secret == nil {
 		return errors.WithStack(fmt.Errorf("alertmanager: failed to get secret"))
 	}
 
-	// Retrieve Alertmanager configuration from secret
-	alertmanagerConfigContent, ok := secret.Data[alertmanagerConfigKey]
+	return s.configureFromData(ctx, secret.Data, secret.GetAnnotations()[StrictInhibitRulesAnnotation] == "true")
+}
+
+// ConfigureFromConfigMap configures Alertmanager with the configuration stored in a configmap rather than a secret.
+func (s Service) ConfigureFromConfigMap(ctx context.Context, configMap *v1.ConfigMap) error {
+	if configMap == nil {
+		return errors.WithStack(fmt.Errorf("alertmanager: failed to get configmap"))
+	}
+
+	data := make(map[string][]byte, len(configMap.Data)+len(configMap.BinaryData))
+	for key, value := range configMap.BinaryData {
+		data[key] = value
+	}
+	for key, value := range configMap.Data {
+		data[key] = []byte(value)
+	}
+
+	return s.configureFromData(ctx, data, configMap.GetAnnotations()[StrictInhibitRulesAnnotation] == "true")
+}
+
+// configureFromData configures Alertmanager with the configuration and templates found in data.
+// When strictInhibitRules is set, inhibition rules using deprecated fields make the configuration be rejected.
+func (s Service) configureFromData(ctx context.Context, data map[string][]byte, strictInhibitRules bool) error {
+	logger := log.FromContext(ctx)
+
+	logger.Info("Alertmanager: configuring")
+
+	// Retrieve Alertmanager configuration from data
+	alertmanagerConfigContent, ok := data[alertmanagerConfigKey]
 	if !ok {
 		return errors.WithStack(fmt.Errorf("alertmanager: config not found"))
 	}
 
-	// Retrieve all alertmanager templates from secret
+	// Retrieve all alertmanager templates from data
 	templates := make(map[string]string)
-	for key, value := range secret.Data {
+	for key, value := range data {
 		if strings.HasSuffix(key, templatesSuffix) {
 			// Template key/name should not be a path otherwise the request will fail with:
 			// > error validating Alertmanager config: invalid template name "/etc/dummy.tmpl": the template name cannot contain any path
@@ -77,7 +166,7 @@ func (s Service) Configure(ctx context.Context, secret *v1.Secret) error {
 		}
 	}
 
-	err := s.configure(ctx, alertmanagerConfigContent, templates, tenantID)
+	err := s.configure(ctx, alertmanagerConfigContent, templates, PlatformTenant, strictInhibitRules)
 	if err != nil {
 		return errors.WithStack(fmt.Errorf("alertmanager: failed to configure: %w", err))
 	}
@@ -89,16 +178,29 @@ func (s Service) Configure(ctx context.Context, secret *v1.Secret) error {
 // configure sends the configuration and templates to Mimir Alertmanager's API
 // It is the caller responsibility to make sure templates names are valid (do not contain any path), and that templates are referenced in the configuration.
 // https://grafana.com/docs/mimir/latest/references/http-api/#set-alertmanager-configuration
-func (s Service) configure(ctx context.Context, alertmanagerConfigContent []byte, templates map[string]string, tenantID string) error {
+func (s Service) configure(ctx context.Context, alertmanagerConfigContent []byte, templates map[string]string, tenantID string, strictInhibitRules bool) error {
 	logger := log.FromContext(ctx)
 
 	// Validate Alertmanager configuration
-	// The returned config is not used, as transforming it via String() would produce an invalid configuration with all secrets replaced with <redacted>.
-	_, err := config.Load(string(alertmanagerConfigContent))
+	// The returned config is only used for validation, as transforming it via String() would produce an invalid configuration with all secrets replaced with <redacted>.
+	alertmanagerConfig, err := config.Load(string(alertmanagerConfigContent))
 	if err != nil {
 		return errors.WithStack(fmt.Errorf("alertmanager: failed to load configuration: %w", err))
 	}
 
+	warnings := validateInhibitRules(alertmanagerConfig)
+	if len(warnings) > 0 && strictInhibitRules {
+		return errors.WithStack(fmt.Errorf("alertmanager: invalid inhibit rules: %s", strings.Join(warnings, ", ")))
+	}
+	for _, warning := range warnings {
+		logger.Info("Alertmanager: deprecated inhibit rule", "warning", warning)
+	}
+
+	err = validateTemplates(templates)
+	if err != nil {
+		return errors.WithStack(fmt.Errorf("alertmanager: %w", err))
+	}
+
 	// Prepare request for Alertmanager API
 	requestData := configRequest{
 		AlertmanagerConfig: string(alertmanagerConfigContent),
@@ -110,38 +212,40 @@ func (s Service) configure(ctx context.Context, alertmanagerConfigContent []byte
 	}
 	dataLen := len(data)
 
-	url := s.alertmanagerURL + alertmanagerAPIPath
-	logger.WithValues("url", url, "data_size", dataLen, "config_size", len(alertmanagerConfigContent), "templates_count", len(templates)).Info("Alertmanager: sending configuration")
+	return s.doWithFailover(ctx, func(alertmanagerURL string) error {
+		url := alertmanagerURL + alertmanagerAPIPath
+		logger.WithValues("url", url, "data_size", dataLen, "config_size", len(alertmanagerConfigContent), "templates_count", len(templates)).Info("Alertmanager: sending configuration")
 
-	// Send request to Alertmanager's API
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
-	if err != nil {
-		return errors.WithStack(fmt.Errorf("alertmanager: failed to create request: %w", err))
-	}
-	req.Header.Set(common.OrgIDHeader, tenantID)
-	req.ContentLength = int64(dataLen)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return errors.WithStack(fmt.Errorf("alertmanager: failed to send request: %w", err))
-	}
-	defer resp.Body.Close() // nolint: errcheck
-
-	logger.WithValues("status_code", resp.StatusCode).Info("Alertmanager: configuration sent")
-
-	if resp.StatusCode != http.StatusCreated {
-		respBody, err := io.ReadAll(resp.Body)
+		// Send request to Alertmanager's API
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(data))
 		if err != nil {
-			return errors.WithStack(fmt.Errorf("alertmanager: failed to read response: %w", err))
+			return errors.WithStack(fmt.Errorf("alertmanager: failed to create request: %w", err))
 		}
+		req.Header.Set(s.orgIDHeaderName(), tenantID)
+		req.ContentLength = int64(dataLen)
 
-		e := APIError{
-			Code:    resp.StatusCode,
-			Message: string(respBody),
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			return errors.WithStack(fmt.Errorf("alertmanager: failed to send request: %w", err))
 		}
+		defer resp.Body.Close() // nolint: errcheck
 
-		return errors.WithStack(fmt.Errorf("alertmanager: failed to send configuration: %w", e))
-	}
+		logger.WithValues("status_code", resp.StatusCode).Info("Alertmanager: configuration sent")
 
-	return nil
+		if resp.StatusCode != http.StatusCreated {
+			respBody, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return errors.WithStack(fmt.Errorf("alertmanager: failed to read response: %w", err))
+			}
+
+			e := APIError{
+				Code:    resp.StatusCode,
+				Message: string(respBody),
+			}
+
+			return errors.WithStack(fmt.Errorf("alertmanager: failed to send configuration: %w", e))
+		}
+
+		return nil
+	})
 }
@@ -0,0 +1,310 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/giantswarm/observability-operator/pkg/config"
+	"github.com/giantswarm/observability-operator/pkg/monitoring"
+)
+
+func TestNewSplitsAlertmanagerURLs(t *testing.T) {
+	s := New(config.Config{Monitoring: monitoring.Config{AlertmanagerURL: "http://alertmanager-0/, http://alertmanager-1,,"}})
+
+	expected := []string{"http://alertmanager-0", "http://alertmanager-1"}
+	if strings.Join(s.alertmanagerURLs, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected URLs %v, got %v", expected, s.alertmanagerURLs)
+	}
+}
+
+func TestConfigureFailsOver(t *testing.T) {
+	const alertmanagerConfig = `
+route:
+  receiver: default
+receivers:
+- name: default
+`
+
+	tests := []struct {
+		name          string
+		statuses      []int
+		expectedError bool
+	}{
+		{
+			name:     "first URL succeeds",
+			statuses: []int{http.StatusCreated, http.StatusCreated},
+		},
+		{
+			name:     "first URL fails",
+			statuses: []int{http.StatusInternalServerError, http.StatusCreated},
+		},
+		{
+			name:          "all URLs fail",
+			statuses:      []int{http.StatusInternalServerError, http.StatusServiceUnavailable},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := make([]int, len(tt.statuses))
+			s := Service{orgIDHeader: "X-Scope-OrgID", httpClient: &http.Client{}}
+			for i, status := range tt.statuses {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					requests[i]++
+					w.WriteHeader(status)
+				}))
+				defer server.Close()
+				s.alertmanagerURLs = append(s.alertmanagerURLs, server.URL)
+			}
+
+			err := s.configure(context.Background(), []byte(alertmanagerConfig), nil, PlatformTenant, false)
+			if tt.expectedError != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.expectedError, err)
+			}
+
+			if requests[0] != 1 {
+				t.Errorf("expected the first URL to be called once, got %d requests", requests[0])
+			}
+			expectedFailover := 0
+			if tt.statuses[0] != http.StatusCreated {
+				expectedFailover = 1
+			}
+			if requests[1] != expectedFailover {
+				t.Errorf("expected the second URL to be called %d times, got %d requests", expectedFailover, requests[1])
+			}
+		})
+	}
+}
+
+func TestDeleteSilenceFailsOverUnreachableURL(t *testing.T) {
+	unreachable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	unreachable.Close()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := Service{alertmanagerURLs: []string{unreachable.URL, server.URL}, orgIDHeader: "X-Scope-OrgID", httpClient: &http.Client{}}
+	if err := s.DeleteSilence(context.Background(), PlatformTenant, "silence-id"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected the second URL to be called once, got %d requests", requests)
+	}
+}
+
+func TestDoWithFailover(t *testing.T) {
+	tests := []struct {
+		name             string
+		statuses         []int
+		expectedRequests int
+		expectedErrors   int
+	}{
+		{
+			name:             "server error fails over to the next URL",
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedRequests: 2,
+		},
+		{
+			name:             "client error does not fail over",
+			statuses:         []int{http.StatusBadRequest, http.StatusOK},
+			expectedRequests: 1,
+			expectedErrors:   1,
+		},
+		{
+			name:             "errors of all the URLs are returned",
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusBadGateway},
+			expectedRequests: 2,
+			expectedErrors:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			var urls []string
+			for _, status := range tt.statuses {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					requests++
+					w.WriteHeader(status)
+				}))
+				defer server.Close()
+				urls = append(urls, server.URL)
+			}
+
+			s := Service{alertmanagerURLs: urls, orgIDHeader: "X-Scope-OrgID", httpClient: &http.Client{}}
+			err := s.DeleteTenantConfiguration(context.Background(), "giantswarm")
+
+			if requests != tt.expectedRequests {
+				t.Errorf("expected %d requests, got %d", tt.expectedRequests, requests)
+			}
+			if tt.expectedErrors == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var aggregate kerrors.Aggregate
+			if !errors.As(err, &aggregate) || len(aggregate.Errors()) != tt.expectedErrors {
+				t.Errorf("expected %d errors, got %v", tt.expectedErrors, err)
+			}
+		})
+	}
+}
+
+func TestDoWithFailoverTimeout(t *testing.T) {
+	unresponsive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer unresponsive.Close()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := Service{alertmanagerURLs: []string{unresponsive.URL, server.URL}, orgIDHeader: "X-Scope-OrgID", httpClient: &http.Client{Timeout: 100 * time.Millisecond}}
+	if err := s.DeleteTenantConfiguration(context.Background(), "giantswarm"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected the second URL to be called once, got %d requests", requests)
+	}
+}
+
+func TestConfigureValidatesRouteReceivers(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{
+			name: "valid route tree",
+			config: `
+route:
+  receiver: default
+  routes:
+  - receiver: team
+    routes:
+    - receiver: default
+receivers:
+- name: default
+- name: team
+`,
+		},
+		{
+			name: "dangling receiver in a nested route",
+			config: `
+route:
+  receiver: default
+  routes:
+  - receiver: team
+    routes:
+    - receiver: missing
+receivers:
+- name: default
+- name: team
+`,
+			expectedError: `undefined receiver "missing"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			s := Service{alertmanagerURLs: []string{server.URL}, orgIDHeader: "X-Scope-OrgID", httpClient: &http.Client{}}
+			err := s.configure(context.Background(), []byte(tt.config), nil, PlatformTenant, false)
+
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if requests != 1 {
+					t.Errorf("expected the configuration to be sent, got %d requests", requests)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+			if requests != 0 {
+				t.Errorf("expected the configuration not to be sent, got %d requests", requests)
+			}
+		})
+	}
+}
+
+func TestSilences(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Scope-OrgID"))
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == silencesAPIPath:
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"silenceID":"silence-id"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == silenceAPIPath+"missing":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete && r.URL.Path == silenceAPIPath+"expired":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`"silence expired already expired"`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	s := Service{alertmanagerURLs: []string{server.URL}, orgIDHeader: "X-Scope-OrgID", httpClient: &http.Client{}}
+
+	id, err := s.CreateOrUpdateSilence(context.Background(), "giantswarm", Silence{
+		Matchers: []Matcher{{Name: "cluster_id", Value: "golem", IsEqual: true}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "silence-id" {
+		t.Errorf("expected silence ID %q, got %q", "silence-id", id)
+	}
+
+	if err := s.DeleteSilence(context.Background(), "", "silence-id"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.DeleteSilence(context.Background(), "", "missing"); err != nil {
+		t.Fatalf("expected missing silences to be ignored, got %v", err)
+	}
+	if err := s.DeleteSilence(context.Background(), "", "expired"); err != nil {
+		t.Fatalf("expected expired silences to be ignored, got %v", err)
+	}
+
+	expected := []string{
+		"POST " + silencesAPIPath + " giantswarm",
+		"DELETE " + silenceAPIPath + "silence-id " + PlatformTenant,
+		"DELETE " + silenceAPIPath + "missing " + PlatformTenant,
+		"DELETE " + silenceAPIPath + "expired " + PlatformTenant,
+	}
+	if strings.Join(requests, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+}
@@ -0,0 +1,155 @@
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	silencesAPIPath = "/alertmanager/api/v2/silences"
+	silenceAPIPath  = "/alertmanager/api/v2/silence/"
+
+	silenceCreatedBy = "observability-operator"
+)
+
+// Silence is an Alertmanager silence.
+// https://github.com/prometheus/alertmanager/blob/main/api/v2/openapi.yaml
+type Silence struct {
+	ID        string    `json:"id,omitempty"`
+	Matchers  []Matcher `json:"matchers"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	CreatedBy string    `json:"createdBy"`
+	Comment   string    `json:"comment"`
+}
+
+// Matcher is a label matcher of an Alertmanager silence.
+type Matcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+type silenceResponse struct {
+	SilenceID string `json:"silenceID"`
+}
+
+// CreateOrUpdateSilence creates the silence for the given tenant, or updates it when its ID is set, and returns the silence ID.
+// Alertmanager may return a new ID when an existing silence is updated, in which case the previous one is expired.
+func (s Service) CreateOrUpdateSilence(ctx context.Context, tenant string, silence Silence) (string, error) {
+	logger := log.FromContext(ctx)
+
+	if silence.CreatedBy == "" {
+		silence.CreatedBy = silenceCreatedBy
+	}
+
+	data, err := json.Marshal(silence)
+	if err != nil {
+		return "", errors.WithStack(fmt.Errorf("alertmanager: failed to marshal silence: %w", err))
+	}
+
+	var response silenceResponse
+	err = s.doWithFailover(ctx, func(alertmanagerURL string) error {
+		resp, err := s.doSilenceRequest(ctx, http.MethodPost, alertmanagerURL+silencesAPIPath, tenant, data)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer resp.Body.Close() // nolint: errcheck
+
+		if resp.StatusCode != http.StatusOK {
+			return errors.WithStack(fmt.Errorf("alertmanager: failed to create silence: %w", newAPIError(resp)))
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			return errors.WithStack(fmt.Errorf("alertmanager: failed to decode silence response: %w", err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	logger.Info("Alertmanager: silence created", "silence_id", response.SilenceID)
+
+	return response.SilenceID, nil
+}
+
+// DeleteSilence expires the silence with the given ID for the given tenant.
+// Silences which do not exist anymore or which are already expired are ignored.
+func (s Service) DeleteSilence(ctx context.Context, tenant string, id string) error {
+	logger := log.FromContext(ctx)
+
+	return s.doWithFailover(ctx, func(alertmanagerURL string) error {
+		resp, err := s.doSilenceRequest(ctx, http.MethodDelete, alertmanagerURL+silenceAPIPath+url.PathEscape(id), tenant, nil)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer resp.Body.Close() // nolint: errcheck
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			logger.Info("Alertmanager: silence expired", "silence_id", id)
+		case http.StatusNotFound:
+			logger.Info("Alertmanager: silence not found", "silence_id", id)
+		default:
+			err := newAPIError(resp)
+			// Alertmanager refuses to expire a silence again, e.g. once its maintenance window is over.
+			var apiErr APIError
+			if errors.As(err, &apiErr) && strings.Contains(apiErr.Message, "already expired") {
+				logger.Info("Alertmanager: silence already expired", "silence_id", id)
+				return nil
+			}
+			return errors.WithStack(fmt.Errorf("alertmanager: failed to expire silence: %w", err))
+		}
+
+		return nil
+	})
+}
+
+// doSilenceRequest sends a request to the Alertmanager silences API on behalf of the given tenant.
+func (s Service) doSilenceRequest(ctx context.Context, method string, endpoint string, tenant string, data []byte) (*http.Response, error) {
+	if tenant == "" {
+		tenant = PlatformTenant
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.WithStack(fmt.Errorf("alertmanager: failed to create request: %w", err))
+	}
+	req.Header.Set(s.orgIDHeaderName(), tenant)
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(fmt.Errorf("alertmanager: failed to send request: %w", err))
+	}
+
+	return resp, nil
+}
+
+// newAPIError builds an APIError from an unexpected Alertmanager response.
+func newAPIError(resp *http.Response) error {
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.WithStack(fmt.Errorf("alertmanager: failed to read response: %w", err))
+	}
+
+	return APIError{
+		Code:    resp.StatusCode,
+		Message: string(respBody),
+	}
+}
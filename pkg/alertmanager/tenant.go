@@ -0,0 +1,154 @@
+package alertmanager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+// TenantConfig is the Alertmanager configuration of a tenant, made of receivers and the routing tree of the alerts.
+// json tags also applies yaml field names
+type TenantConfig struct {
+	Route     Route      `json:"route"`
+	Receivers []Receiver `json:"receivers"`
+}
+
+// Receiver is a named set of integrations notified of the alerts routed to it.
+type Receiver struct {
+	Name           string          `json:"name"`
+	WebhookConfigs []WebhookConfig `json:"webhook_configs,omitempty"`
+}
+
+// WebhookConfig is a webhook notified of the alerts.
+type WebhookConfig struct {
+	URL          string `json:"url"`
+	SendResolved bool   `json:"send_resolved"`
+}
+
+// Route sends the alerts matching its matchers to its receiver, unless one of its child routes matches them.
+type Route struct {
+	Receiver string   `json:"receiver"`
+	GroupBy  []string `json:"group_by,omitempty"`
+	Matchers []string `json:"matchers,omitempty"`
+	Continue bool     `json:"continue,omitempty"`
+	Routes   []Route  `json:"routes,omitempty"`
+}
+
+// Validate ensures the receivers are uniquely named, that every route references a defined receiver and that the matchers are valid.
+func (c TenantConfig) Validate() error {
+	receivers := make(map[string]bool, len(c.Receivers))
+	for _, receiver := range c.Receivers {
+		if receivers[receiver.Name] {
+			return errors.Errorf("duplicate contact point %q", receiver.Name)
+		}
+		receivers[receiver.Name] = true
+	}
+
+	if len(c.Route.Matchers) > 0 {
+		return errors.New("the root route cannot have matchers")
+	}
+
+	return validateRoute(c.Route, receivers)
+}
+
+// validateRoute ensures the route and its child routes reference defined receivers and have valid matchers.
+func validateRoute(route Route, receivers map[string]bool) error {
+	if !receivers[route.Receiver] {
+		return errors.Errorf("route references undefined contact point %q", route.Receiver)
+	}
+
+	for _, matcher := range route.Matchers {
+		if _, err := labels.ParseMatcher(matcher); err != nil {
+			return errors.Wrapf(err, "invalid matcher %q", matcher)
+		}
+	}
+
+	for _, child := range route.Routes {
+		if err := validateRoute(child, receivers); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Generate validates the tenant configuration and returns it in the Alertmanager configuration format.
+func (c TenantConfig) Generate() ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return data, nil
+}
+
+// ConfigureTenant sends the Alertmanager configuration of the tenant to Mimir Alertmanager's API.
+// The configuration of the platform tenant cannot be overwritten.
+func (s Service) ConfigureTenant(ctx context.Context, tenant string, tenantConfig TenantConfig) error {
+	logger := log.FromContext(ctx).WithValues("tenant", tenant)
+
+	if tenant == PlatformTenant {
+		return errors.Errorf("alertmanager: the configuration of the platform tenant %q cannot be overwritten", PlatformTenant)
+	}
+
+	logger.Info("Alertmanager: configuring tenant")
+
+	alertmanagerConfigContent, err := tenantConfig.Generate()
+	if err != nil {
+		return errors.WithStack(fmt.Errorf("alertmanager: invalid tenant configuration: %w", err))
+	}
+
+	err = s.configure(ctx, alertmanagerConfigContent, nil, tenant, false)
+	if err != nil {
+		return errors.WithStack(fmt.Errorf("alertmanager: failed to configure tenant %q: %w", tenant, err))
+	}
+
+	logger.Info("Alertmanager: configured tenant")
+	return nil
+}
+
+// DeleteTenantConfiguration removes the Alertmanager configuration of the tenant from Mimir Alertmanager.
+// https://grafana.com/docs/mimir/latest/references/http-api/#delete-alertmanager-configuration
+// The configuration of the platform tenant cannot be deleted.
+func (s Service) DeleteTenantConfiguration(ctx context.Context, tenant string) error {
+	logger := log.FromContext(ctx).WithValues("tenant", tenant)
+
+	if tenant == PlatformTenant {
+		return errors.Errorf("alertmanager: the configuration of the platform tenant %q cannot be deleted", PlatformTenant)
+	}
+
+	err := s.doWithFailover(ctx, func(alertmanagerURL string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, alertmanagerURL+alertmanagerAPIPath, nil)
+		if err != nil {
+			return errors.WithStack(fmt.Errorf("alertmanager: failed to create request: %w", err))
+		}
+		req.Header.Set(s.orgIDHeaderName(), tenant)
+
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			return errors.WithStack(fmt.Errorf("alertmanager: failed to send request: %w", err))
+		}
+		defer resp.Body.Close() // nolint: errcheck
+
+		if resp.StatusCode != http.StatusOK {
+			return errors.WithStack(fmt.Errorf("alertmanager: failed to delete tenant configuration: %w", newAPIError(resp)))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	logger.Info("Alertmanager: deleted tenant configuration")
+	return nil
+}
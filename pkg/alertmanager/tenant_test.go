@@ -0,0 +1,135 @@
+package alertmanager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTenantConfigValidate(t *testing.T) {
+	receivers := []Receiver{
+		{Name: "default"},
+		{Name: "team", WebhookConfigs: []WebhookConfig{{URL: "https://example.com/alerts"}}},
+	}
+
+	tests := []struct {
+		name          string
+		config        TenantConfig
+		expectedError string
+	}{
+		{
+			name: "valid routing tree",
+			config: TenantConfig{
+				Route: Route{
+					Receiver: "default",
+					Routes:   []Route{{Receiver: "team", Matchers: []string{`severity="page"`}}},
+				},
+				Receivers: receivers,
+			},
+		},
+		{
+			name: "undefined root contact point",
+			config: TenantConfig{
+				Route:     Route{Receiver: "missing"},
+				Receivers: receivers,
+			},
+			expectedError: `undefined contact point "missing"`,
+		},
+		{
+			name: "undefined route contact point",
+			config: TenantConfig{
+				Route: Route{
+					Receiver: "default",
+					Routes:   []Route{{Receiver: "missing"}},
+				},
+				Receivers: receivers,
+			},
+			expectedError: `undefined contact point "missing"`,
+		},
+		{
+			name: "duplicate contact point",
+			config: TenantConfig{
+				Route:     Route{Receiver: "default"},
+				Receivers: append(receivers, Receiver{Name: "team"}),
+			},
+			expectedError: `duplicate contact point "team"`,
+		},
+		{
+			name: "invalid matcher",
+			config: TenantConfig{
+				Route: Route{
+					Receiver: "default",
+					Routes:   []Route{{Receiver: "team", Matchers: []string{`severity=~"(page"`}}},
+				},
+				Receivers: receivers,
+			},
+			expectedError: "invalid matcher",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestTenantConfiguration(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Scope-OrgID"))
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	// The tenant is sent in the X-Scope-OrgID header when no header is configured
+	s := Service{alertmanagerURLs: []string{server.URL}, httpClient: &http.Client{}}
+
+	err := s.ConfigureTenant(context.Background(), "giantswarm", TenantConfig{
+		Route:     Route{Receiver: "default"},
+		Receivers: []Receiver{{Name: "default", WebhookConfigs: []WebhookConfig{{URL: "https://example.com/alerts", SendResolved: true}}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Invalid configurations are not sent to Alertmanager
+	err = s.ConfigureTenant(context.Background(), "giantswarm", TenantConfig{
+		Route:     Route{Receiver: "missing"},
+		Receivers: []Receiver{{Name: "default"}},
+	})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	if err := s.DeleteTenantConfiguration(context.Background(), "giantswarm"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"POST " + alertmanagerAPIPath + " giantswarm",
+		"DELETE " + alertmanagerAPIPath + " giantswarm",
+	}
+	if strings.Join(requests, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+}
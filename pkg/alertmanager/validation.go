@@ -0,0 +1,54 @@
+package alertmanager
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"text/template"
+
+	"github.com/prometheus/alertmanager/config"
+	amtemplate "github.com/prometheus/alertmanager/template"
+)
+
+// mimirTemplateFuncNames are the names of the functions Mimir adds to the Alertmanager default functions.
+var mimirTemplateFuncNames = []string{"tenantID", "grafanaExploreURL", "queryFromGeneratorURL"}
+
+// validateTemplates parses the templates and returns an error naming the first template with a syntax error.
+// Mimir accepts such templates, which then fail when notifications are sent.
+func validateTemplates(templates map[string]string) error {
+	// The templates are only parsed, so the Mimir functions only need to be defined
+	funcs := template.FuncMap(maps.Clone(amtemplate.DefaultFuncs))
+	for _, name := range mimirTemplateFuncNames {
+		funcs[name] = func(...any) any { return nil }
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(templates)) {
+		if _, err := template.New(name).Funcs(funcs).Parse(templates[name]); err != nil {
+			return fmt.Errorf("invalid template %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateInhibitRules returns a warning for each inhibition rule using the deprecated source_match, source_match_re, target_match
+// or target_match_re fields, which should be replaced by source_matchers and target_matchers.
+// The equal labels are not checked: they only need to be set on both the source and target alerts, not to be used in their matchers.
+func validateInhibitRules(cfg *config.Config) []string {
+	var warnings []string
+	for i, rule := range cfg.InhibitRules {
+		for field, deprecated := range map[string]bool{
+			"source_match":    len(rule.SourceMatch) > 0,
+			"source_match_re": len(rule.SourceMatchRE) > 0,
+			"target_match":    len(rule.TargetMatch) > 0,
+			"target_match_re": len(rule.TargetMatchRE) > 0,
+		} {
+			if deprecated {
+				warnings = append(warnings, fmt.Sprintf("inhibit rule %d: %s is deprecated, use source_matchers and target_matchers instead", i, field))
+			}
+		}
+	}
+
+	slices.Sort(warnings)
+	return warnings
+}
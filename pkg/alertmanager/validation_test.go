@@ -0,0 +1,109 @@
+package alertmanager
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/config"
+	amtemplate "github.com/prometheus/alertmanager/template"
+)
+
+func TestValidateInhibitRules(t *testing.T) {
+	tests := []struct {
+		name             string
+		inhibitRules     string
+		expectedWarnings int
+	}{
+		{
+			name: "well-formed inhibit rule",
+			inhibitRules: `
+- source_matchers:
+  - alertname=ClusterDown
+  target_matchers:
+  - severity=page
+  equal: [cluster_id, installation]
+`,
+			expectedWarnings: 0,
+		},
+		{
+			name: "deprecated match fields",
+			inhibitRules: `
+- source_match:
+    alertname: ClusterDown
+  target_match_re:
+    cluster_id: .+
+  equal: [cluster_id]
+`,
+			expectedWarnings: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.Load("route:\n  receiver: default\nreceivers:\n- name: default\ninhibit_rules:" + tt.inhibitRules)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			warnings := validateInhibitRules(cfg)
+			if len(warnings) != tt.expectedWarnings {
+				t.Errorf("expected %d warnings, got %v", tt.expectedWarnings, warnings)
+			}
+		})
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	tests := []struct {
+		name          string
+		templates     map[string]string
+		expectedError string
+	}{
+		{
+			name: "valid templates",
+			templates: map[string]string{
+				"title.tmpl": `{{ define "title" }}[{{ .Status | toUpper }}] {{ .CommonLabels.alertname }}{{ end }}`,
+				"url.tmpl":   `{{ define "url" }}{{ grafanaExploreURL "https://grafana" "mimir" "now-1h" "now" "up" }}{{ end }}`,
+			},
+		},
+		{
+			name: "syntax error",
+			templates: map[string]string{
+				"title.tmpl":  `{{ define "title" }}{{ .CommonLabels.alertname }}{{ end }}`,
+				"broken.tmpl": `{{ define "broken" }}{{ }}{{ end }}`,
+			},
+			expectedError: `"broken.tmpl"`,
+		},
+		{
+			name: "undefined function",
+			templates: map[string]string{
+				"title.tmpl": `{{ define "title" }}{{ .Status | shout }}{{ end }}`,
+			},
+			expectedError: `"title.tmpl"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTemplates(tt.templates)
+
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("expected error containing %s, got %v", tt.expectedError, err)
+			}
+		})
+	}
+
+	// The Mimir functions must not leak into the Alertmanager default functions
+	for _, name := range mimirTemplateFuncNames {
+		if _, ok := amtemplate.DefaultFuncs[name]; ok {
+			t.Errorf("expected function %s not to be added to the Alertmanager default functions", name)
+		}
+	}
+}
@@ -2,14 +2,17 @@ package monitoring
 
 import (
 	"context"
+	"encoding/json"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/yaml"
 
 	"github.com/giantswarm/observability-operator/pkg/monitoring/prometheusagent/sharding"
@@ -18,8 +21,6 @@ import (
 const (
 	// DefaultServicePriority is the default service priority if not set.
 	defaultServicePriority = "highest"
-	mimirApiKey            = "mimir-basic-auth" // #nosec G101
-	mimirNamespace         = "mimir"
 	// ServicePriorityLabel is the label used to determine the priority of a service.
 	servicePriorityLabel = "giantswarm.io/service-priority"
 
@@ -38,13 +39,63 @@ const (
 	QueueConfigMaxSamplesPerSend = 150000
 	QueueConfigMaxShards         = 10
 
+	// Default ports of the OTLP receiver
+	OTLPReceiverGRPCPort = 4317
+	OTLPReceiverHTTPPort = 4318
+
 	RemoteWriteName                = "mimir"
 	RemoteWriteEndpointTemplateURL = "https://mimir.%s/api/v1/push"
 	RemoteWriteTimeout             = "60s"
 
+	// MimirAuthUsernameKey is the key of the Mimir auth secret holding the username matching the password.
+	MimirAuthUsernameKey = "username"
+
+	// ScrapeInterval is the default scrape interval of the Alloy monitoring agent.
+	ScrapeInterval = "60s"
+	// ScrapeTimeoutAnnotation overrides the scrape timeout of the Alloy monitoring agent for a cluster.
+	ScrapeTimeoutAnnotation = "monitoring.giantswarm.io/scrape-timeout"
+	// MonitoringAgentOverrideAnnotation overrides the monitoring agent of a cluster (prometheus-agent or alloy).
+	MonitoringAgentOverrideAnnotation = "monitoring.giantswarm.io/monitoring-agent"
+	// DroppedScrapeJobsAnnotation overrides the comma separated list of the scrape jobs dropped by the Alloy monitoring agent for a cluster.
+	DroppedScrapeJobsAnnotation = "monitoring.giantswarm.io/dropped-scrape-jobs"
+	// PendingScalingAnnotation is set on the clusters with the change of the number of monitoring agent shards which is not applied yet.
+	PendingScalingAnnotation = "observability.giantswarm.io/monitoring-pending-scaling"
+
+	// OrgIDHeader is the default name of the header carrying the Mimir tenant.
 	OrgIDHeader = "X-Scope-OrgID"
+	// DefaultWriteTenant is the tenant the monitoring agents write to by default.
+	DefaultWriteTenant = "anonymous"
 )
 
+// QueueConfigTier holds the remote write queue settings applied to clusters running at least MinShards shards.
+type QueueConfigTier struct {
+	MinShards int
+	// SampleAgeLimit is the age after which samples are dropped instead of being sent. Samples are never dropped when it is 0.
+	SampleAgeLimit time.Duration
+	// BatchSendDeadline is the maximum time a sample waits in the queue before being sent.
+	BatchSendDeadline time.Duration
+}
+
+// QueueConfigTiers are sorted by increasing number of shards.
+// Small clusters have no sample age limit, like before the tiers were introduced.
+// Larger clusters get a sample age limit growing with their size to tolerate bursts, and a longer deadline to send fuller batches.
+var QueueConfigTiers = []QueueConfigTier{
+	{MinShards: 0, BatchSendDeadline: 5 * time.Second},
+	{MinShards: 3, SampleAgeLimit: 30 * time.Minute, BatchSendDeadline: 10 * time.Second},
+	{MinShards: 6, SampleAgeLimit: time.Hour, BatchSendDeadline: 15 * time.Second},
+}
+
+// GetQueueConfigTier returns the queue config tier matching the given number of shards.
+func GetQueueConfigTier(shards int) QueueConfigTier {
+	tier := QueueConfigTiers[0]
+	for _, t := range QueueConfigTiers {
+		if shards >= t.MinShards {
+			tier = t
+		}
+	}
+	return tier
+}
+
 func GetServicePriority(cluster *clusterv1.Cluster) string {
 	if servicePriority, ok := cluster.GetLabels()[servicePriorityLabel]; ok && servicePriority != "" {
 		return servicePriority
@@ -52,30 +103,32 @@ func GetServicePriority(cluster *clusterv1.Cluster) string {
 	return defaultServicePriority
 }
 
-func GetMimirIngressPassword(ctx context.Context) (string, error) {
-	cfg, err := config.GetConfig()
-	if err != nil {
-		return "", err
-	}
-
-	c, err := client.New(cfg, client.Options{})
-	if err != nil {
-		return "", err
-	}
-
+// GetMimirIngressCredentials reads the username and password used to authenticate against Mimir from the secret with the given name and namespace.
+// Secrets created before the username was recorded fall back to defaultUsername.
+// The reader should be uncached (e.g. the manager API reader) so the operator does not cache every secret in the cluster
+// and always sees the current password.
+func GetMimirIngressCredentials(ctx context.Context, c client.Reader, name string, namespace string, defaultUsername string) (string, string, error) {
 	secret := &corev1.Secret{}
 
-	err = c.Get(ctx, client.ObjectKey{
-		Name:      mimirApiKey,
-		Namespace: mimirNamespace,
+	err := c.Get(ctx, client.ObjectKey{
+		Name:      name,
+		Namespace: namespace,
 	}, secret)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	mimirPassword, err := readMimirAuthPasswordFromSecret(*secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	username := defaultUsername
+	if value, ok := secret.Data[MimirAuthUsernameKey]; ok && len(value) > 0 {
+		username = string(value)
+	}
 
-	return mimirPassword, err
+	return username, mimirPassword, nil
 }
 
 func readMimirAuthPasswordFromSecret(secret corev1.Secret) (string, error) {
@@ -92,9 +145,35 @@ func readMimirAuthPasswordFromSecret(secret corev1.Secret) (string, error) {
 	}
 }
 
+// GetClusterScrapeTimeout returns the scrape timeout set on the cluster annotation, or 0 when it is not set.
+func GetClusterScrapeTimeout(cluster metav1.Object) (time.Duration, error) {
+	value, ok := cluster.GetAnnotations()[ScrapeTimeoutAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// GetClusterDroppedScrapeJobs returns the scrape jobs set on the cluster annotation, and whether the annotation is set.
+func GetClusterDroppedScrapeJobs(cluster metav1.Object) ([]string, bool) {
+	value, ok := cluster.GetAnnotations()[DroppedScrapeJobsAnnotation]
+	if !ok {
+		return nil, false
+	}
+
+	var jobs []string
+	for _, job := range strings.Split(value, ",") {
+		if job = strings.TrimSpace(job); job != "" {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, true
+}
+
 func GetClusterShardingStrategy(cluster metav1.Object) (*sharding.Strategy, error) {
 	var err error
 	var scaleUpSeriesCount, scaleDownPercentage float64
+	var scalingReconciles int
 	if value, ok := cluster.GetAnnotations()["monitoring.giantswarm.io/prometheus-agent-scale-up-series-count"]; ok {
 		if scaleUpSeriesCount, err = strconv.ParseFloat(value, 64); err != nil {
 			return nil, err
@@ -105,8 +184,33 @@ func GetClusterShardingStrategy(cluster metav1.Object) (*sharding.Strategy, erro
 			return nil, err
 		}
 	}
+	if value, ok := cluster.GetAnnotations()["monitoring.giantswarm.io/prometheus-agent-scaling-reconciles"]; ok {
+		if scalingReconciles, err = strconv.Atoi(value); err != nil {
+			return nil, err
+		}
+	}
 	return &sharding.Strategy{
 		ScaleUpSeriesCount:  scaleUpSeriesCount,
 		ScaleDownPercentage: scaleDownPercentage,
+		ScalingReconciles:   scalingReconciles,
 	}, nil
 }
+
+// ComputeClusterShards computes the number of shards of the cluster monitoring agent with the given sharding strategy.
+// It returns the scaling still pending after the computation, which the caller records on the cluster with the
+// PendingScalingAnnotation so the next reconciliation carries on with it. The debounce counts reconciliations,
+// so it depends on how often the cluster is reconciled rather than on a time window.
+func ComputeClusterShards(ctx context.Context, cluster *clusterv1.Cluster, strategy sharding.Strategy, currentShards int, headSeries float64) (int, sharding.PendingScaling) {
+	var pending sharding.PendingScaling
+	if value, ok := cluster.GetAnnotations()[PendingScalingAnnotation]; ok {
+		// An invalid pending scaling is discarded.
+		_ = json.Unmarshal([]byte(value), &pending)
+	}
+
+	desiredShards := strategy.ComputeShards(currentShards, headSeries)
+	if strategy.IsCapped(currentShards, headSeries) {
+		log.FromContext(ctx).Info("number of shards capped at the maximum", "maxShards", strategy.MaxShards, "headSeries", headSeries)
+	}
+
+	return strategy.Debounce(currentShards, desiredShards, pending)
+}
@@ -37,3 +37,27 @@ func (r NamespaceOrganizationRepository) Read(ctx context.Context, cluster *clus
 	}
 	return "", errors.New("cluster namespace missing organization label")
 }
+
+// OverrideOrganizationRepository resolves the organization of a cluster from an explicit mapping before falling back to another repository.
+// The mapping is indexed either by "<namespace>/<name>" to match a single cluster or by "<namespace>" to match all clusters of a namespace.
+type OverrideOrganizationRepository struct {
+	overrides map[string]string
+	fallback  OrganizationRepository
+}
+
+func NewOverrideRepository(overrides map[string]string, fallback OrganizationRepository) OrganizationRepository {
+	return OverrideOrganizationRepository{
+		overrides: overrides,
+		fallback:  fallback,
+	}
+}
+
+func (r OverrideOrganizationRepository) Read(ctx context.Context, cluster *clusterv1.Cluster) (string, error) {
+	if organization, ok := r.overrides[cluster.GetNamespace()+"/"+cluster.GetName()]; ok {
+		return organization, nil
+	}
+	if organization, ok := r.overrides[cluster.GetNamespace()]; ok {
+		return organization, nil
+	}
+	return r.fallback.Read(ctx, cluster)
+}
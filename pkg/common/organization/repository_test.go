@@ -0,0 +1,74 @@
+package organization
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestOverrideOrganizationRepository(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "org-acme",
+			Labels: map[string]string{OrganizationLabel: "acme"},
+		},
+	}
+	repository := NewOverrideRepository(map[string]string{
+		"org-acme/override":  "cluster-override",
+		"shared":             "namespace-override",
+		"org-acme/unrelated": "unrelated",
+	}, NewNamespaceRepository(fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespace).Build()))
+
+	tests := []struct {
+		name      string
+		cluster   string
+		namespace string
+		expected  string
+	}{
+		{
+			name:      "cluster override takes precedence",
+			cluster:   "override",
+			namespace: "org-acme",
+			expected:  "cluster-override",
+		},
+		{
+			name:      "namespace override takes precedence",
+			cluster:   "any",
+			namespace: "shared",
+			expected:  "namespace-override",
+		},
+		{
+			name:      "fallback to the namespace label",
+			cluster:   "other",
+			namespace: "org-acme",
+			expected:  "acme",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: tt.cluster, Namespace: tt.namespace},
+			}
+
+			organization, err := repository.Read(context.Background(), cluster)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if organization != tt.expected {
+				t.Errorf("expected organization %q, got %q", tt.expected, organization)
+			}
+		})
+	}
+}
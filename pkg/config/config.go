@@ -1,8 +1,16 @@
 package config
 
 import (
+	"encoding/json"
 	"net/url"
+	"regexp"
+	"time"
 
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	observabilityv1alpha1 "github.com/giantswarm/observability-operator/api/v1alpha1"
 	"github.com/giantswarm/observability-operator/pkg/common"
 	"github.com/giantswarm/observability-operator/pkg/monitoring"
 )
@@ -15,14 +23,199 @@ type Config struct {
 	EnableHTTP2          bool
 	OperatorNamespace    string
 	GrafanaURL           *url.URL
+	// LeaderElectionLeaseDuration is the duration non-leader candidates wait before trying to acquire the leadership.
+	LeaderElectionLeaseDuration time.Duration
+	// LeaderElectionRenewDeadline is the duration the leader retries refreshing the leadership before giving it up.
+	LeaderElectionRenewDeadline time.Duration
+	// LeaderElectionRetryPeriod is the duration candidates wait between leader election actions.
+	LeaderElectionRetryPeriod time.Duration
+	// GrafanaRequestTimeout is the maximum duration of a request to the Grafana API. Requests are not limited when it is 0.
+	GrafanaRequestTimeout time.Duration
+
+	// DashboardPermissionsEnabled enables the configuration of dashboard permissions based on the organization RBAC.
+	DashboardPermissionsEnabled bool
+	// DashboardMaxSize is the maximum size in bytes of a dashboard pushed to Grafana. The size is not limited when it is 0.
+	DashboardMaxSize int
+	// DashboardKeySuffix is the suffix of the configmap keys holding dashboards, the other keys are ignored.
+	DashboardKeySuffix string
+	// DashboardFinalizer is the finalizer added to the dashboard configmaps.
+	DashboardFinalizer string
+	// DashboardDefaultRefresh is the refresh interval set on dashboards which do not define one.
+	DashboardDefaultRefresh string
+	// DashboardDefaultTimeFrom is the start of the time range set on dashboards which do not define one.
+	DashboardDefaultTimeFrom string
+	// DashboardAllowedOrganizations maps namespaces to the organizations their dashboards may be pushed to.
+	// Dashboards are not restricted when it is empty, otherwise dashboards from unlisted namespaces are rejected.
+	DashboardAllowedOrganizations map[string][]string
+	// DashboardTenantVariable is the name of the template variable of the dashboards populated with the tenant IDs of their organization. Variables are left unchanged when it is empty.
+	DashboardTenantVariable string
+
+	// GrafanaOrganizationTenantLimit is the number of tenants above which a warning is emitted for a Grafana organization. There is no limit when it is 0.
+	GrafanaOrganizationTenantLimit int
+	// GrafanaOrganizationTenantIDMaxLength is the maximum length of the tenant IDs, stricter than the CRD limit. The CRD limit applies when it is 0.
+	GrafanaOrganizationTenantIDMaxLength int
+	// GrafanaOrganizationTenantIDPrefix is the prefix required on the tenant IDs. Tenant IDs are not required a prefix when it is empty.
+	GrafanaOrganizationTenantIDPrefix string
+	// GrafanaOrganizationForbiddenTenantIDs are the tenant IDs which cannot be used by Grafana organizations.
+	GrafanaOrganizationForbiddenTenantIDs []string
+	// GrafanaOrganizationAdminPattern is the pattern the admins of the Grafana organizations must fully match. Admins are not required a pattern when it is empty.
+	GrafanaOrganizationAdminPattern string
+	// GrafanaServiceAccountSecretNamespace is the namespace of the secrets holding the Grafana service account tokens. Defaults to the operator namespace.
+	GrafanaServiceAccountSecretNamespace string
+	// GrafanaManagedOrganizations are the display names of the Grafana organizations the operator is allowed to manage. All organizations are managed when it is empty.
+	GrafanaManagedOrganizations []string
+	// GrafanaOrganizationResyncPeriod is the period after which Grafana organizations are reconciled again to repair drifted datasources. Organizations are not resynced when it is 0.
+	GrafanaOrganizationResyncPeriod time.Duration
+	// GrafanaOrganizationJanitorEnabled enables the periodic deletion of the Grafana organizations created by the operator which no longer have a GrafanaOrganization.
+	GrafanaOrganizationJanitorEnabled bool
+	// GrafanaOrganizationJanitorDryRun only logs the orphaned Grafana organizations instead of deleting them.
+	GrafanaOrganizationJanitorDryRun bool
+	// GrafanaOrganizationJanitorInterval is the interval at which orphaned Grafana organizations are looked for.
+	GrafanaOrganizationJanitorInterval time.Duration
+
+	// ClusterLabelSelector selects the clusters managed by the operator.
+	ClusterLabelSelector labels.Selector
+	// MonitoringFinalizer is the finalizer added to the clusters.
+	MonitoringFinalizer string
+	// OrganizationOverrides maps clusters, as "<namespace>/<name>", or namespaces to their organization when it cannot be derived from the namespace.
+	OrganizationOverrides map[string]string
 
 	ManagementCluster common.ManagementCluster
 
 	Monitoring monitoring.Config
 
+	// MaxConcurrentReconciles is the maximum number of concurrent reconciliations of each controller.
+	MaxConcurrentReconciles MaxConcurrentReconciles
+	// EnabledControllers selects the controllers set up by the operator.
+	EnabledControllers EnabledControllers
+
 	Environment Environment
 }
 
+// redacted replaces the secrets when the configuration is printed.
+const redacted = "REDACTED"
+
+// Validate ensures the configuration parsed from the flags is consistent. All the errors are reported together.
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.GrafanaOrganizationTenantIDMaxLength < 0 || c.GrafanaOrganizationTenantIDMaxLength > observabilityv1alpha1.TenantIDMaxLength {
+		errs = append(errs, errors.Errorf("invalid grafana organization tenant ID max length %d, must be between 0 and %d", c.GrafanaOrganizationTenantIDMaxLength, observabilityv1alpha1.TenantIDMaxLength))
+	}
+
+	if c.GrafanaOrganizationJanitorEnabled && c.GrafanaOrganizationJanitorInterval <= 0 {
+		errs = append(errs, errors.Errorf("invalid grafana organization janitor interval %s, must be positive", c.GrafanaOrganizationJanitorInterval))
+	}
+
+	if _, err := regexp.Compile(c.GrafanaOrganizationAdminPattern); err != nil {
+		errs = append(errs, errors.Wrapf(err, "invalid grafana organization admin pattern %q", c.GrafanaOrganizationAdminPattern))
+	}
+
+	for _, rule := range c.Monitoring.MetricRelabelRules {
+		if err := rule.Validate(); err != nil {
+			errs = append(errs, errors.Wrap(err, "invalid metric relabel rule"))
+		}
+	}
+
+	if err := c.Monitoring.ValidateScrapeTimeout(); err != nil {
+		errs = append(errs, errors.Wrap(err, "invalid scrape timeout"))
+	}
+
+	if err := c.Monitoring.ValidateRemoteWriteHeaders(); err != nil {
+		errs = append(errs, errors.Wrap(err, "invalid remote write headers"))
+	}
+
+	if c.Monitoring.DefaultShardingStrategy.ScaleUpSeriesCount <= 0 {
+		errs = append(errs, errors.Errorf("invalid sharding scale up series count %g, must be positive", c.Monitoring.DefaultShardingStrategy.ScaleUpSeriesCount))
+	}
+
+	if c.Monitoring.ObservabilityBundleNotFoundRequeueAfter <= 0 {
+		errs = append(errs, errors.Errorf("invalid observability-bundle not found requeue delay %s, must be positive", c.Monitoring.ObservabilityBundleNotFoundRequeueAfter))
+	}
+
+	if c.Monitoring.ObservabilityBundleNotFoundMaxAttempts < 0 {
+		errs = append(errs, errors.Errorf("invalid observability-bundle not found max attempts %d, must be positive or 0", c.Monitoring.ObservabilityBundleNotFoundMaxAttempts))
+	}
+
+	if err := c.Monitoring.ValidateRemoteWriteAuth(); err != nil {
+		errs = append(errs, errors.Wrap(err, "invalid remote write authentication"))
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
+// Redacted returns a copy of the configuration without its secrets, so it can be printed.
+func (c Config) Redacted() Config {
+	c.Environment.GrafanaAdminPassword = redactSecret(c.Environment.GrafanaAdminPassword)
+	c.Environment.OpsgenieApiKey = redactSecret(c.Environment.OpsgenieApiKey)
+	c.Environment.HeartbeatFailureWebhookURL = redactSecret(c.Environment.HeartbeatFailureWebhookURL)
+
+	// The remote write headers can carry the credentials of a gateway in front of Mimir.
+	// The Mimir runtime overrides only hold limits, they are printed as is.
+	if c.Monitoring.RemoteWriteHeaders != nil {
+		headers := make(map[string]string, len(c.Monitoring.RemoteWriteHeaders))
+		for name, value := range c.Monitoring.RemoteWriteHeaders {
+			headers[name] = redactSecret(value)
+		}
+		c.Monitoring.RemoteWriteHeaders = headers
+	}
+	return c
+}
+
+// MarshalJSON renders the Grafana URL, without its password, and the cluster label selector as strings.
+func (c Config) MarshalJSON() ([]byte, error) {
+	type config Config
+
+	var grafanaURL, clusterLabelSelector string
+	if c.GrafanaURL != nil {
+		grafanaURL = c.GrafanaURL.Redacted()
+	}
+	if c.ClusterLabelSelector != nil {
+		clusterLabelSelector = c.ClusterLabelSelector.String()
+	}
+
+	return json.Marshal(struct {
+		config
+		GrafanaURL           string
+		ClusterLabelSelector string
+	}{
+		config:               config(c),
+		GrafanaURL:           grafanaURL,
+		ClusterLabelSelector: clusterLabelSelector,
+	})
+}
+
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+// MaxConcurrentReconciles holds the maximum number of concurrent reconciliations of each controller.
+// The controller-runtime default of 1 applies when a value is 0.
+type MaxConcurrentReconciles struct {
+	ClusterMonitoring   int
+	GrafanaOrganization int
+	Dashboard           int
+	LibraryPanel        int
+	AlertRule           int
+	Alertmanager        int
+	MaintenanceWindow   int
+}
+
+// EnabledControllers holds whether each controller is set up.
+// The Alertmanager and MaintenanceWindow controllers also require the Alertmanager to be enabled.
+type EnabledControllers struct {
+	ClusterMonitoring   bool
+	GrafanaOrganization bool
+	Dashboard           bool
+	LibraryPanel        bool
+	AlertRule           bool
+	Alertmanager        bool
+	MaintenanceWindow   bool
+}
+
 type Environment struct {
 	GrafanaAdminUsername string `env:"GRAFANA_ADMIN_USERNAME,required=true"`
 	GrafanaAdminPassword string `env:"GRAFANA_ADMIN_PASSWORD,required=true"`
@@ -30,4 +223,7 @@ type Environment struct {
 	GrafanaTLSKeyFile    string `env:"GRAFANA_TLS_KEY_FILE,required=true"`
 
 	OpsgenieApiKey string `env:"OPSGENIE_API_KEY,required=true"`
+
+	// HeartbeatFailureWebhookURL is the optional webhook notified when the heartbeat fails repeatedly.
+	HeartbeatFailureWebhookURL string `env:"HEARTBEAT_FAILURE_WEBHOOK_URL"`
 }
@@ -0,0 +1,38 @@
+package grafana
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/client/provisioning"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/pkg/errors"
+)
+
+// PublishAlertRule creates or updates the Grafana-managed alert rule in the organization of the client.
+func PublishAlertRule(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, orgID int64, rule *models.ProvisionedAlertRule) error {
+	if rule.UID == "" {
+		return errors.New("alert rule UID not found")
+	}
+	rule.OrgID = &orgID
+
+	_, err := grafanaAPI.Provisioning.GetAlertRule(rule.UID)
+	if IsNotFound(err) {
+		_, err = grafanaAPI.Provisioning.PostAlertRule(provisioning.NewPostAlertRuleParams().WithBody(rule))
+		audit(ctx, auditOperationCreate, "alert-rule", orgID, rule.UID, err)
+		return errors.WithStack(err)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = grafanaAPI.Provisioning.PutAlertRule(provisioning.NewPutAlertRuleParams().WithUID(rule.UID).WithBody(rule))
+	audit(ctx, auditOperationUpdate, "alert-rule", orgID, rule.UID, err)
+	return errors.WithStack(err)
+}
+
+// DeleteAlertRule deletes the Grafana-managed alert rule from the organization of the client.
+func DeleteAlertRule(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, orgID int64, uid string) error {
+	_, err := grafanaAPI.Provisioning.DeleteAlertRule(provisioning.NewDeleteAlertRuleParams().WithUID(uid))
+	audit(ctx, auditOperationDelete, "alert-rule", orgID, uid, err)
+	return err
+}
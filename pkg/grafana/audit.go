@@ -0,0 +1,34 @@
+package grafana
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// auditLoggerName is the name of the logger recording every write the operator performs on Grafana.
+// Audit entries can be filtered from the rest of the operator logs using the logger name.
+const auditLoggerName = "grafana-audit"
+
+const (
+	auditOperationCreate = "create"
+	auditOperationUpdate = "update"
+	auditOperationDelete = "delete"
+	auditOperationAdopt  = "adopt"
+)
+
+// audit records a write performed on Grafana in the audit log.
+// Only identifiers are recorded, never the payload sent to Grafana, so secrets cannot end up in the audit log.
+func audit(ctx context.Context, operation string, resource string, orgID int64, uid string, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+
+	log.FromContext(ctx).WithName(auditLoggerName).Info("grafana write",
+		"operation", operation,
+		"resource", resource,
+		"orgID", orgID,
+		"uid", uid,
+		"outcome", outcome)
+}
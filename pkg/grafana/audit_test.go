@@ -0,0 +1,81 @@
+package grafana
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/client/dashboards"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type fakeDashboards struct {
+	dashboards.ClientService
+
+	version int64
+}
+
+func (f *fakeDashboards) PostDashboard(body *models.SaveDashboardCommand, opts ...dashboards.ClientOption) (*dashboards.PostDashboardOK, error) {
+	return &dashboards.PostDashboardOK{Payload: &models.PostDashboardOKBody{Version: &f.version}}, nil
+}
+
+// auditContext returns a context whose logger collects the audit entries.
+func auditContext(entries *[]string) context.Context {
+	logger := funcr.New(func(prefix, args string) {
+		if prefix == auditLoggerName {
+			*entries = append(*entries, args)
+		}
+	}, funcr.Options{})
+
+	return log.IntoContext(context.Background(), logger)
+}
+
+func TestAuditDashboardCreate(t *testing.T) {
+	var entries []string
+	grafanaAPI := &client.GrafanaHTTPAPI{Dashboards: &fakeDashboards{version: 1}}
+
+	_, err := PublishDashboard(auditContext(&entries), grafanaAPI, 2, map[string]any{"uid": "my-dashboard", "title": "My dashboard"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %v", entries)
+	}
+	for _, expected := range []string{`"operation"="create"`, `"resource"="dashboard"`, `"orgID"=2`, `"uid"="my-dashboard"`, `"outcome"="success"`} {
+		if !strings.Contains(entries[0], expected) {
+			t.Errorf("expected audit entry to contain %s, got %s", expected, entries[0])
+		}
+	}
+}
+
+func TestAuditDatasourceUpdate(t *testing.T) {
+	var entries []string
+	organization := Organization{ID: 2, Name: "test", TenantIDs: []string{"tenant"}}
+
+	current := configuredDatasources(t, organization)
+	current[0].URL = "http://changed"
+	grafanaAPI := &client.GrafanaHTTPAPI{
+		Datasources: &fakeDatasources{current: current},
+	}
+
+	_, err := ConfigureDefaultDatasources(auditContext(&entries), grafanaAPI, organization)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %v", entries)
+	}
+	for _, expected := range []string{`"operation"="update"`, `"resource"="datasource"`, `"orgID"=2`, `"uid"="` + current[0].Name + `"`, `"outcome"="success"`} {
+		if !strings.Contains(entries[0], expected) {
+			t.Errorf("expected audit entry to contain %s, got %s", expected, entries[0])
+		}
+	}
+	if strings.Contains(entries[0], "tenant") {
+		t.Errorf("expected audit entry not to contain secure data, got %s", entries[0])
+	}
+}
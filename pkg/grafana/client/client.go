@@ -2,8 +2,11 @@ package client
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
+	"time"
 
+	httptransport "github.com/go-openapi/runtime/client"
 	grafana "github.com/grafana/grafana-openapi-client-go/client"
 
 	"github.com/giantswarm/observability-operator/pkg/config"
@@ -50,5 +53,23 @@ func GenerateGrafanaClient(grafanaURL *url.URL, conf config.Config) (*grafana.Gr
 		TLSConfig:  grafanaTLSConfig,
 	}
 
-	return grafana.NewHTTPClientWithConfig(nil, cfg), nil
+	return newGrafanaClient(cfg, conf.GrafanaRequestTimeout), nil
+}
+
+// newGrafanaClient creates a Grafana client whose requests fail once they take longer than timeout. Requests are not limited when timeout is 0.
+func newGrafanaClient(cfg *grafana.TransportConfig, timeout time.Duration) *grafana.GrafanaHTTPAPI {
+	httpClient := &http.Client{Timeout: timeout}
+	cfg.Client = httpClient
+
+	// The runtime cancels every request after its own default timeout of 30 seconds, which would cap the configured one.
+	httptransport.DefaultTimeout = timeout
+
+	grafanaAPI := grafana.NewHTTPClientWithConfig(nil, cfg)
+
+	// The retries and tls configuration are set up on the transport of the runtime rather than on the provided client.
+	if runtime, ok := grafanaAPI.Transport.(*httptransport.Runtime); ok {
+		httpClient.Transport = runtime.Transport
+	}
+
+	return grafanaAPI
 }
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	httptransport "github.com/go-openapi/runtime/client"
+	grafana "github.com/grafana/grafana-openapi-client-go/client"
+)
+
+func TestNewGrafanaClientRequestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Simulate a Grafana instance which never answers
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	grafanaAPI := newGrafanaClient(&grafana.TransportConfig{
+		Schemes:  []string{serverURL.Scheme},
+		BasePath: "/api",
+		Host:     serverURL.Host,
+	}, 100*time.Millisecond)
+
+	start := time.Now()
+	_, err = grafanaAPI.Orgs.GetOrgByName("test")
+	if err == nil {
+		t.Fatalf("expected a timeout error")
+	}
+	if !os.IsTimeout(err) {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the request to time out quickly, took %s", elapsed)
+	}
+}
+
+func TestNewGrafanaClientTimeouts(t *testing.T) {
+	defaultTimeout := httptransport.DefaultTimeout
+	t.Cleanup(func() { httptransport.DefaultTimeout = defaultTimeout })
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{
+			name:    "below the runtime default",
+			timeout: 10 * time.Second,
+		},
+		{
+			name:    "above the runtime default",
+			timeout: 2 * time.Minute,
+		},
+		{
+			name:    "unlimited",
+			timeout: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &grafana.TransportConfig{
+				Schemes:  []string{"http"},
+				BasePath: "/api",
+				Host:     "localhost",
+			}
+			newGrafanaClient(cfg, tt.timeout)
+
+			if cfg.Client.Timeout != tt.timeout {
+				t.Errorf("expected the http client timeout to be %s, got %s", tt.timeout, cfg.Client.Timeout)
+			}
+			if httptransport.DefaultTimeout != tt.timeout {
+				t.Errorf("expected the runtime timeout to be %s, got %s", tt.timeout, httptransport.DefaultTimeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package grafana
+
+import (
+	"context"
+	"slices"
+
+	"github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Grafana dashboard permission levels as defined by the dashboard permissions API.
+const (
+	dashboardPermissionView  models.PermissionType = 1
+	dashboardPermissionEdit  models.PermissionType = 2
+	dashboardPermissionAdmin models.PermissionType = 4
+)
+
+// ConfigureDashboardPermissions ensures the role based permissions of a dashboard match the RBAC configuration of the organization.
+// Each role that has at least one org attribute mapped in the organization RBAC gets the matching permission on the dashboard.
+// User and team permissions that were set manually are preserved.
+// It is the caller responsibility to scope the client to the dashboard organization, see WithOrgID.
+func ConfigureDashboardPermissions(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, dashboardUID string, organization Organization) error {
+	logger := log.FromContext(ctx)
+
+	resp, err := grafanaAPI.DashboardPermissions.GetDashboardPermissionsListByUID(dashboardUID)
+	if err != nil {
+		logger.Error(err, "failed to get dashboard permissions", "Dashboard UID", dashboardUID)
+		return errors.WithStack(err)
+	}
+
+	current := make([]*models.DashboardACLUpdateItem, 0, len(resp.Payload))
+	for _, item := range resp.Payload {
+		// Inherited permissions come from the parent folder and cannot be set on the dashboard itself.
+		if item.Inherited {
+			continue
+		}
+		current = append(current, &models.DashboardACLUpdateItem{
+			Permission: item.Permission,
+			Role:       item.Role,
+			TeamID:     item.TeamID,
+			UserID:     item.UserID,
+		})
+	}
+
+	desired := desiredDashboardPermissions(current, organization)
+	if equalDashboardPermissions(current, desired) {
+		logger.Info("dashboard permissions are up to date", "Dashboard UID", dashboardUID)
+		return nil
+	}
+
+	_, err = grafanaAPI.DashboardPermissions.UpdateDashboardPermissionsByUID(dashboardUID, &models.UpdateDashboardACLCommand{
+		Items: desired,
+	})
+	audit(ctx, auditOperationUpdate, "dashboard-permissions", organization.ID, dashboardUID, err)
+	if err != nil {
+		logger.Error(err, "failed to update dashboard permissions", "Dashboard UID", dashboardUID)
+		return errors.WithStack(err)
+	}
+	logger.Info("updated dashboard permissions", "Dashboard UID", dashboardUID)
+
+	return nil
+}
+
+// desiredDashboardPermissions computes the dashboard permissions from the current ones and the organization RBAC.
+// Role permissions are owned by the operator while user and team permissions are kept as is.
+func desiredDashboardPermissions(current []*models.DashboardACLUpdateItem, organization Organization) []*models.DashboardACLUpdateItem {
+	desired := make([]*models.DashboardACLUpdateItem, 0, len(current)+3)
+	for _, item := range current {
+		if item.Role == "" {
+			desired = append(desired, item)
+		}
+	}
+
+	if len(organization.Viewers) > 0 {
+		desired = append(desired, &models.DashboardACLUpdateItem{Role: grafanaViewerRole, Permission: dashboardPermissionView})
+	}
+	if len(organization.Editors) > 0 {
+		desired = append(desired, &models.DashboardACLUpdateItem{Role: grafanaEditorRole, Permission: dashboardPermissionEdit})
+	}
+	if len(organization.Admins) > 0 {
+		desired = append(desired, &models.DashboardACLUpdateItem{Role: grafanaAdminRole, Permission: dashboardPermissionAdmin})
+	}
+
+	return desired
+}
+
+// equalDashboardPermissions reports whether both lists hold the same permissions, regardless of their order.
+func equalDashboardPermissions(a, b []*models.DashboardACLUpdateItem) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, item := range a {
+		if !slices.ContainsFunc(b, func(i *models.DashboardACLUpdateItem) bool { return *i == *item }) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package grafana
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/client/dashboard_permissions"
+	"github.com/grafana/grafana-openapi-client-go/models"
+)
+
+type fakeDashboardPermissions struct {
+	dashboard_permissions.ClientService
+
+	current []*models.DashboardACLInfoDTO
+	updates []*models.UpdateDashboardACLCommand
+}
+
+func (f *fakeDashboardPermissions) GetDashboardPermissionsListByUID(uid string, opts ...dashboard_permissions.ClientOption) (*dashboard_permissions.GetDashboardPermissionsListByUIDOK, error) {
+	return &dashboard_permissions.GetDashboardPermissionsListByUIDOK{Payload: f.current}, nil
+}
+
+func (f *fakeDashboardPermissions) UpdateDashboardPermissionsByUID(uid string, body *models.UpdateDashboardACLCommand, opts ...dashboard_permissions.ClientOption) (*dashboard_permissions.UpdateDashboardPermissionsByUIDOK, error) {
+	f.updates = append(f.updates, body)
+	return &dashboard_permissions.UpdateDashboardPermissionsByUIDOK{}, nil
+}
+
+func TestConfigureDashboardPermissions(t *testing.T) {
+	organization := Organization{
+		Name:    "test",
+		Admins:  []string{"admins"},
+		Viewers: []string{"viewers"},
+	}
+
+	tests := []struct {
+		name            string
+		current         []*models.DashboardACLInfoDTO
+		expectedUpdates int
+		expectedItems   []*models.DashboardACLUpdateItem
+	}{
+		{
+			name: "new dashboard gets role permissions",
+			current: []*models.DashboardACLInfoDTO{
+				{Role: grafanaViewerRole, Permission: dashboardPermissionView, Inherited: true},
+			},
+			expectedUpdates: 1,
+			expectedItems: []*models.DashboardACLUpdateItem{
+				{Role: grafanaViewerRole, Permission: dashboardPermissionView},
+				{Role: grafanaAdminRole, Permission: dashboardPermissionAdmin},
+			},
+		},
+		{
+			name: "user permissions are preserved and stale role permissions are removed",
+			current: []*models.DashboardACLInfoDTO{
+				{UserID: 42, Permission: dashboardPermissionEdit},
+				{Role: grafanaEditorRole, Permission: dashboardPermissionEdit},
+			},
+			expectedUpdates: 1,
+			expectedItems: []*models.DashboardACLUpdateItem{
+				{UserID: 42, Permission: dashboardPermissionEdit},
+				{Role: grafanaViewerRole, Permission: dashboardPermissionView},
+				{Role: grafanaAdminRole, Permission: dashboardPermissionAdmin},
+			},
+		},
+		{
+			name: "up to date permissions are not updated",
+			current: []*models.DashboardACLInfoDTO{
+				{Role: grafanaAdminRole, Permission: dashboardPermissionAdmin},
+				{TeamID: 7, Permission: dashboardPermissionView},
+				{Role: grafanaViewerRole, Permission: dashboardPermissionView},
+			},
+			expectedUpdates: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permissions := &fakeDashboardPermissions{current: tt.current}
+			grafanaAPI := &client.GrafanaHTTPAPI{DashboardPermissions: permissions}
+
+			err := ConfigureDashboardPermissions(context.Background(), grafanaAPI, "dashboard", organization)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(permissions.updates) != tt.expectedUpdates {
+				t.Fatalf("expected %d updates, got %d", tt.expectedUpdates, len(permissions.updates))
+			}
+			if tt.expectedUpdates == 0 {
+				return
+			}
+			if !equalDashboardPermissions(permissions.updates[0].Items, tt.expectedItems) {
+				t.Errorf("expected items %v, got %v", tt.expectedItems, permissions.updates[0].Items)
+			}
+		})
+	}
+}
@@ -3,11 +3,15 @@ package grafana
 import (
 	"context"
 	_ "embed"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/client/orgs"
 	"github.com/grafana/grafana-openapi-client-go/models"
 	"github.com/pkg/errors"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -15,6 +19,9 @@ import (
 
 const (
 	datasourceProxyAccessMode = "proxy"
+
+	mimirDatasourceName          = "Mimir"
+	federatedMimirDatasourceName = "Mimir Federated"
 )
 
 var SharedOrg = Organization{
@@ -48,7 +55,7 @@ var defaultDatasources = []Datasource{
 		},
 	},
 	{
-		Name:      "Mimir",
+		Name:      mimirDatasourceName,
 		Type:      "prometheus",
 		IsDefault: true,
 		URL:       "http://mimir-gateway.mimir.svc/prometheus",
@@ -69,6 +76,88 @@ var defaultDatasources = []Datasource{
 	},
 }
 
+// extraDatasources are the datasources organizations can opt in to, indexed by type.
+var extraDatasources = map[string]Datasource{
+	"graphite": {
+		Name:   "Graphite",
+		Type:   "graphite",
+		URL:    "http://graphite.graphite.svc",
+		Access: datasourceProxyAccessMode,
+		JSONData: map[string]interface{}{
+			"graphiteType":    "default",
+			"graphiteVersion": "1.1",
+		},
+	},
+}
+
+// federatedMimirDatasource returns a Mimir datasource querying all the given tenants at once using Mimir tenant federation.
+func federatedMimirDatasource(tenantIDs []string) Datasource {
+	index := slices.IndexFunc(defaultDatasources, func(d Datasource) bool { return d.Name == mimirDatasourceName })
+	datasource := defaultDatasources[index]
+	datasource.Name = federatedMimirDatasourceName
+	datasource.IsDefault = false
+	datasource.TenantIDs = tenantIDs
+	return datasource
+}
+
+// organizationDatasources returns the datasources desired in the organization.
+func organizationDatasources(organization Organization) ([]Datasource, error) {
+	datasources := slices.Clone(defaultDatasources)
+	if len(organization.FederatedReadTenantIDs) > 0 {
+		datasources = append(datasources, federatedMimirDatasource(organization.FederatedReadTenantIDs))
+	}
+	for _, datasourceType := range organization.ExtraDatasources {
+		datasource, ok := extraDatasources[datasourceType]
+		if !ok {
+			return nil, errors.Errorf("unsupported datasource type %q", datasourceType)
+		}
+		datasources = append(datasources, datasource)
+	}
+	return applyDatasourceJSONDataOverrides(datasources, organization.DatasourceJSONDataOverrides)
+}
+
+// ValidateDatasources ensures the datasources requested by the organization can be configured.
+func ValidateDatasources(organization Organization) error {
+	_, err := organizationDatasources(organization)
+	return err
+}
+
+// applyDatasourceJSONDataOverrides merges the overrides on top of the JSON data of the datasources of the same type.
+// Only the top-level fields given in an override are replaced, the fields set by the operator cannot be overridden.
+func applyDatasourceJSONDataOverrides(datasources []Datasource, overrides map[string][]byte) ([]Datasource, error) {
+	for _, datasourceType := range slices.Sorted(maps.Keys(overrides)) {
+		var override map[string]interface{}
+		err := json.Unmarshal(overrides[datasourceType], &override)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid JSON data override of datasource type %q", datasourceType)
+		}
+
+		for _, key := range operatorJSONDataKeys {
+			if _, ok := override[key]; ok {
+				return nil, errors.Errorf("JSON data override of datasource type %q sets %q, which is managed by the operator", datasourceType, key)
+			}
+		}
+
+		if !slices.ContainsFunc(datasources, func(d Datasource) bool { return d.Type == datasourceType }) {
+			return nil, errors.Errorf("no datasource of type %q to override", datasourceType)
+		}
+
+		for i := range datasources {
+			if datasources[i].Type != datasourceType {
+				continue
+			}
+			// The default datasources are shared between organizations so their JSON data must not be modified in place.
+			jsonData := maps.Clone(datasources[i].JSONData)
+			if jsonData == nil {
+				jsonData = make(map[string]interface{})
+			}
+			maps.Copy(jsonData, override)
+			datasources[i].JSONData = jsonData
+		}
+	}
+	return datasources, nil
+}
+
 func UpsertOrganization(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, organization *Organization) error {
 	logger := log.FromContext(ctx)
 
@@ -78,21 +167,43 @@ func UpsertOrganization(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI,
 	}
 
 	logger.Info("upserting organization")
-	found, err := findOrgByID(grafanaAPI, organization.ID)
+	found, err := FindOrgByID(grafanaAPI, organization.ID)
 	if err != nil {
-		if isNotFound(err) {
+		if IsNotFound(err) {
 			logger.Info("organization id not found, creating")
 			// If the CR orgID does not exist in Grafana, then we create the organization
 			createdOrg, err := grafanaAPI.Orgs.CreateOrg(&models.CreateOrgCommand{
 				Name: organization.Name,
 			})
-			if err != nil {
+			if IsConflict(err) {
+				// The organization was created with the same name since we looked for it, e.g. manually, so we adopt it.
+				logger.Info("organization name already taken, adopting the existing organization")
+				existing, err := FindOrgByName(grafanaAPI, organization.Name)
+				if err != nil {
+					logger.Error(err, fmt.Sprintf("failed to find organization with name: %s", organization.Name))
+					return errors.WithStack(err)
+				}
+
+				// Two GrafanaOrganizations sharing an organization would delete it for both when either is deleted.
+				if slices.Contains(organization.OwnedOrgIDs, existing.ID) {
+					err = errors.Errorf("organization %q with ID %d is already owned by another GrafanaOrganization", organization.Name, existing.ID)
+					audit(ctx, auditOperationAdopt, "organization", existing.ID, organization.Name, err)
+					logger.Error(err, "failed to adopt organization")
+					return err
+				}
+
+				organization.ID = existing.ID
+				audit(ctx, auditOperationAdopt, "organization", organization.ID, organization.Name, nil)
+				return nil
+			} else if err != nil {
+				audit(ctx, auditOperationCreate, "organization", 0, organization.Name, err)
 				logger.Error(err, "failed to create organization")
 				return errors.WithStack(err)
 			}
 			logger.Info("created organization")
 
 			organization.ID = *createdOrg.Payload.OrgID
+			audit(ctx, auditOperationCreate, "organization", organization.ID, organization.Name, nil)
 			return nil
 		}
 		logger.Error(err, fmt.Sprintf("failed to find organization with ID: %d", organization.ID))
@@ -109,6 +220,7 @@ func UpsertOrganization(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI,
 	_, err = grafanaAPI.Orgs.UpdateOrg(organization.ID, &models.UpdateOrgForm{
 		Name: organization.Name,
 	})
+	audit(ctx, auditOperationUpdate, "organization", organization.ID, organization.Name, err)
 	if err != nil {
 		logger.Error(err, "failed to update organization name")
 		return errors.WithStack(err)
@@ -123,9 +235,9 @@ func DeleteOrganization(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI,
 	logger := log.FromContext(ctx)
 
 	logger.Info("deleting organization")
-	_, err := findOrgByID(grafanaAPI, organization.ID)
+	_, err := FindOrgByID(grafanaAPI, organization.ID)
 	if err != nil {
-		if isNotFound(err) {
+		if IsNotFound(err) {
 			logger.Info("organization id was not found, skipping deletion")
 			// If the CR orgID does not exist in Grafana, then we create the organization
 			return nil
@@ -135,6 +247,7 @@ func DeleteOrganization(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI,
 	}
 
 	_, err = grafanaAPI.Orgs.DeleteOrgByID(organization.ID)
+	audit(ctx, auditOperationDelete, "organization", organization.ID, organization.Name, err)
 	if err != nil {
 		logger.Error(err, "failed to delete organization")
 		return errors.WithStack(err)
@@ -147,21 +260,12 @@ func DeleteOrganization(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI,
 func ConfigureDefaultDatasources(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, organization Organization) ([]Datasource, error) {
 	logger := log.FromContext(ctx)
 
-	// TODO using a serviceaccount later would be better as they are scoped to an organization
-
-	var err error
-	// Switch context to the current org
-	if _, err = grafanaAPI.SignedInUser.UserSetUsingOrg(organization.ID); err != nil {
-		logger.Error(err, "failed to change current org for signed in user")
+	desiredDatasources, err := organizationDatasources(organization)
+	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	// We always switch back to the shared org
-	defer func() {
-		if _, err = grafanaAPI.SignedInUser.UserSetUsingOrg(SharedOrg.ID); err != nil {
-			logger.Error(err, "failed to change current org for signed in user")
-		}
-	}()
+	grafanaAPI = WithOrgID(grafanaAPI, organization.ID)
 
 	configuredDatasourcesInGrafana, err := listDatasourcesForOrganization(ctx, grafanaAPI)
 	if err != nil {
@@ -169,27 +273,9 @@ func ConfigureDefaultDatasources(ctx context.Context, grafanaAPI *client.Grafana
 		return nil, errors.WithStack(err)
 	}
 
-	datasourcesToCreate := make([]Datasource, 0)
-	datasourcesToUpdate := make([]Datasource, 0)
+	plan := planDatasources(configuredDatasourcesInGrafana, desiredDatasources, organization)
 
-	// Check if the default datasources are already configured
-	for _, defaultDatasource := range defaultDatasources {
-		found := false
-		for _, configuredDatasource := range configuredDatasourcesInGrafana {
-			if configuredDatasource.Name == defaultDatasource.Name {
-				found = true
-
-				// We need to extract the ID from the configured datasource
-				datasourcesToUpdate = append(datasourcesToUpdate, defaultDatasource.withID(configuredDatasource.ID))
-				break
-			}
-		}
-		if !found {
-			datasourcesToCreate = append(datasourcesToCreate, defaultDatasource)
-		}
-	}
-
-	for index, datasource := range datasourcesToCreate {
+	for index, datasource := range plan.toCreate {
 		logger.Info("creating datasource", "datasource", datasource.Name)
 		created, err := grafanaAPI.Datasources.AddDataSource(
 			&models.AddDataSourceCommand{
@@ -197,19 +283,20 @@ func ConfigureDefaultDatasources(ctx context.Context, grafanaAPI *client.Grafana
 				Type:           datasource.Type,
 				URL:            datasource.URL,
 				IsDefault:      datasource.IsDefault,
-				JSONData:       models.JSON(datasource.buildJSONData()),
+				JSONData:       models.JSON(datasource.JSONData),
 				SecureJSONData: datasource.buildSecureJSONData(organization),
 				Access:         models.DsAccess(datasource.Access),
 			})
+		audit(ctx, auditOperationCreate, "datasource", organization.ID, datasource.Name, err)
 		if err != nil {
-			logger.Error(err, "failed to create datasources", "datasource", datasourcesToCreate[index].Name)
+			logger.Error(err, "failed to create datasources", "datasource", datasource.Name)
 			return nil, errors.WithStack(err)
 		}
-		datasourcesToCreate[index].ID = *created.Payload.ID
+		plan.toCreate[index].ID = *created.Payload.ID
 		logger.Info("datasource created", "datasource", datasource.Name)
 	}
 
-	for _, datasource := range datasourcesToUpdate {
+	for _, datasource := range plan.toUpdate {
 		logger.Info("updating datasource", "datasource", datasource.Name)
 		_, err := grafanaAPI.Datasources.UpdateDataSourceByID(
 			strconv.FormatInt(datasource.ID, 10),
@@ -218,10 +305,11 @@ func ConfigureDefaultDatasources(ctx context.Context, grafanaAPI *client.Grafana
 				Type:           datasource.Type,
 				URL:            datasource.URL,
 				IsDefault:      datasource.IsDefault,
-				JSONData:       models.JSON(datasource.buildJSONData()),
+				JSONData:       models.JSON(datasource.JSONData),
 				SecureJSONData: datasource.buildSecureJSONData(organization),
 				Access:         models.DsAccess(datasource.Access),
 			})
+		audit(ctx, auditOperationUpdate, "datasource", organization.ID, datasource.Name, err)
 		if err != nil {
 			logger.Error(err, "failed to update datasources", "datasource", datasource.Name)
 			return nil, errors.WithStack(err)
@@ -229,8 +317,72 @@ func ConfigureDefaultDatasources(ctx context.Context, grafanaAPI *client.Grafana
 		logger.Info("datasource updated", "datasource", datasource.Name)
 	}
 
+	for _, datasource := range plan.toDelete {
+		logger.Info("deleting datasource", "datasource", datasource.Name)
+		_, err := grafanaAPI.Datasources.DeleteDataSourceByID(strconv.FormatInt(datasource.ID, 10))
+		audit(ctx, auditOperationDelete, "datasource", organization.ID, datasource.Name, err)
+		if err != nil && !IsNotFound(err) {
+			logger.Error(err, "failed to delete datasources", "datasource", datasource.Name)
+			return nil, errors.WithStack(err)
+		}
+		logger.Info("datasource deleted", "datasource", datasource.Name)
+	}
+
+	logger.Info("datasources configured", "created", len(plan.toCreate), "updated", len(plan.toUpdate), "deleted", len(plan.toDelete), "unchanged", len(plan.unchanged))
+
+	configuredDatasources := append(plan.toCreate, plan.toUpdate...)
+	configuredDatasources = append(configuredDatasources, plan.unchanged...)
+
 	// We return the datasources and the error if it exists. This allows us to return the defer function error it it exists.
-	return append(datasourcesToCreate, datasourcesToUpdate...), errors.WithStack(err)
+	return configuredDatasources, errors.WithStack(err)
+}
+
+// datasourcesPlan holds the changes needed to reconcile the datasources of an organization.
+type datasourcesPlan struct {
+	toCreate  []Datasource
+	toUpdate  []Datasource
+	toDelete  []Datasource
+	unchanged []Datasource
+}
+
+// planDatasources diffs the desired datasources against the ones configured in Grafana.
+// Datasources that are up to date are left untouched and datasources managed by the operator that are not desired anymore are deleted.
+func planDatasources(configured []Datasource, desired []Datasource, organization Organization) datasourcesPlan {
+	plan := datasourcesPlan{
+		toCreate:  make([]Datasource, 0),
+		toUpdate:  make([]Datasource, 0),
+		toDelete:  make([]Datasource, 0),
+		unchanged: make([]Datasource, 0),
+	}
+
+	for _, datasource := range desired {
+		datasource.JSONData = datasource.buildJSONData(organization)
+
+		index := slices.IndexFunc(configured, func(d Datasource) bool { return d.Name == datasource.Name })
+		if index == -1 {
+			plan.toCreate = append(plan.toCreate, datasource)
+			continue
+		}
+
+		// We need to extract the ID from the configured datasource
+		datasource = datasource.withID(configured[index].ID)
+		if datasource.isUpToDate(configured[index]) {
+			plan.unchanged = append(plan.unchanged, datasource)
+		} else {
+			plan.toUpdate = append(plan.toUpdate, datasource)
+		}
+	}
+
+	for _, datasource := range configured {
+		if !datasource.isManaged() {
+			continue
+		}
+		if !slices.ContainsFunc(desired, func(d Datasource) bool { return d.Name == datasource.Name }) {
+			plan.toDelete = append(plan.toDelete, datasource)
+		}
+	}
+
+	return plan
 }
 
 func listDatasourcesForOrganization(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI) ([]Datasource, error) {
@@ -244,6 +396,7 @@ func listDatasourcesForOrganization(ctx context.Context, grafanaAPI *client.Graf
 
 	datasources := make([]Datasource, len(resp.Payload))
 	for i, datasource := range resp.Payload {
+		jsonData, _ := datasource.JSONData.(map[string]interface{})
 		datasources[i] = Datasource{
 			ID:        datasource.ID,
 			Name:      datasource.Name,
@@ -251,13 +404,72 @@ func listDatasourcesForOrganization(ctx context.Context, grafanaAPI *client.Graf
 			Type:      datasource.Type,
 			URL:       datasource.URL,
 			Access:    string(datasource.Access),
+			JSONData:  jsonData,
 		}
 	}
 
 	return datasources, nil
 }
 
-func isNotFound(err error) bool {
+// organizationsPerPage is the number of organizations requested per page when listing the Grafana organizations.
+const organizationsPerPage = 1000
+
+// ListOrganizations lists all the organizations of Grafana.
+func ListOrganizations(grafanaAPI *client.GrafanaHTTPAPI) ([]Organization, error) {
+	var organizations []Organization
+	perPage := int64(organizationsPerPage)
+	for page := int64(1); ; page++ {
+		resp, err := grafanaAPI.Orgs.SearchOrgs(orgs.NewSearchOrgsParams().WithPage(&page).WithPerpage(&perPage))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		for _, organization := range resp.Payload {
+			organizations = append(organizations, Organization{
+				ID:   organization.ID,
+				Name: organization.Name,
+			})
+		}
+
+		if int64(len(resp.Payload)) < perPage {
+			return organizations, nil
+		}
+	}
+}
+
+// WithOrgID returns a copy of the Grafana client whose requests target the given organization.
+// Unlike switching the current organization of the signed in user, it does not affect concurrent reconciliations.
+func WithOrgID(grafanaAPI *client.GrafanaHTTPAPI, orgID int64) *client.GrafanaHTTPAPI {
+	// Clients built from stubbed services, e.g. in tests, have no transport to clone
+	if grafanaAPI.Transport == nil {
+		return grafanaAPI
+	}
+
+	return grafanaAPI.Clone().WithOrgID(orgID)
+}
+
+// HasManagedDatasources returns true if the organization holds datasources created by the operator.
+func HasManagedDatasources(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, orgID int64) (bool, error) {
+	datasources, err := listDatasourcesForOrganization(ctx, WithOrgID(grafanaAPI, orgID))
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return slices.ContainsFunc(datasources, Datasource.isManaged), nil
+}
+
+// IsManagedOrganization returns true if the organization with the given name may be managed by the operator.
+// All organizations are managed when managedOrganizations is empty, and the shared organization is always managed.
+func IsManagedOrganization(managedOrganizations []string, name string) bool {
+	if len(managedOrganizations) == 0 || name == SharedOrg.Name {
+		return true
+	}
+
+	return slices.Contains(managedOrganizations, name)
+}
+
+// IsNotFound returns true if the error returned by the Grafana API is a 404.
+func IsNotFound(err error) bool {
 	if err == nil {
 		return false
 	}
@@ -266,6 +478,16 @@ func isNotFound(err error) bool {
 	return strings.Contains(err.Error(), "(status 404)")
 }
 
+// IsConflict returns true if the error returned by the Grafana API is a 409, e.g. when an organization name is already taken.
+func IsConflict(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// Parsing error message to find out the error code
+	return strings.Contains(err.Error(), "(status 409)")
+}
+
 // assertNameIsAvailable is a helper function to check if the organization name is available in Grafana
 func assertNameIsAvailable(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, organization *Organization) error {
 	logger := log.FromContext(ctx)
@@ -273,7 +495,7 @@ func assertNameIsAvailable(ctx context.Context, grafanaAPI *client.GrafanaHTTPAP
 	found, err := FindOrgByName(grafanaAPI, organization.Name)
 	if err != nil {
 		// We only error if we have any error other than a 404
-		if !isNotFound(err) {
+		if !IsNotFound(err) {
 			logger.Error(err, fmt.Sprintf("failed to find organization with name: %s", organization.Name))
 			return errors.WithStack(err)
 		}
@@ -300,8 +522,8 @@ func FindOrgByName(grafanaAPI *client.GrafanaHTTPAPI, name string) (*Organizatio
 	}, nil
 }
 
-// findOrgByID is a wrapper function used to find a Grafana organization by its id
-func findOrgByID(grafanaAPI *client.GrafanaHTTPAPI, orgID int64) (*Organization, error) {
+// FindOrgByID is a wrapper function used to find a Grafana organization by its id
+func FindOrgByID(grafanaAPI *client.GrafanaHTTPAPI, orgID int64) (*Organization, error) {
 	organization, err := grafanaAPI.Orgs.GetOrgByID(orgID)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -313,13 +535,59 @@ func findOrgByID(grafanaAPI *client.GrafanaHTTPAPI, orgID int64) (*Organization,
 	}, nil
 }
 
-// PublishDashboard creates or updates a dashboard in Grafana
-func PublishDashboard(grafanaAPI *client.GrafanaHTTPAPI, dashboard map[string]any) error {
-	_, err := grafanaAPI.Dashboards.PostDashboard(&models.SaveDashboardCommand{
+// PublishDashboard creates or updates the dashboard in the organization of the client.
+func PublishDashboard(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, orgID int64, dashboard map[string]any) (int64, error) {
+	resp, err := grafanaAPI.Dashboards.PostDashboard(&models.SaveDashboardCommand{
 		Dashboard: any(dashboard),
 		Message:   "Added by observability-operator",
 		Overwrite: true, // allows dashboard to be updated by the same UID
 
 	})
+
+	// Grafana does not tell whether the dashboard was created or updated, but new dashboards always start at version 1.
+	operation := auditOperationUpdate
+	if err == nil && resp.Payload != nil && resp.Payload.Version != nil && *resp.Payload.Version == 1 {
+		operation = auditOperationCreate
+	}
+	uid, _ := dashboard["uid"].(string)
+	audit(ctx, operation, "dashboard", orgID, uid, err)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	if resp.Payload != nil && resp.Payload.Version != nil {
+		version = *resp.Payload.Version
+	}
+	return version, nil
+}
+
+// DashboardExists returns true if the dashboard exists in the organization of the client.
+func DashboardExists(grafanaAPI *client.GrafanaHTTPAPI, uid string) (bool, error) {
+	_, exists, err := GetDashboardVersion(grafanaAPI, uid)
+	return exists, err
+}
+
+// GetDashboardVersion returns the version of the dashboard in the organization of the client and whether it exists.
+// Grafana increments the version whenever the dashboard is saved, including from its UI.
+func GetDashboardVersion(grafanaAPI *client.GrafanaHTTPAPI, uid string) (int64, bool, error) {
+	resp, err := grafanaAPI.Dashboards.GetDashboardByUID(uid)
+	if IsNotFound(err) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, errors.WithStack(err)
+	}
+
+	var version int64
+	if resp.Payload != nil && resp.Payload.Meta != nil {
+		version = resp.Payload.Meta.Version
+	}
+	return version, true, nil
+}
+
+// DeleteDashboard deletes the dashboard from the organization of the client.
+func DeleteDashboard(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, orgID int64, uid string) error {
+	_, err := grafanaAPI.Dashboards.DeleteDashboardByUID(uid)
+	audit(ctx, auditOperationDelete, "dashboard", orgID, uid, err)
 	return err
 }
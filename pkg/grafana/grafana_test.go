@@ -0,0 +1,474 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/client/datasources"
+	"github.com/grafana/grafana-openapi-client-go/client/orgs"
+	"github.com/grafana/grafana-openapi-client-go/models"
+)
+
+type fakeDatasources struct {
+	datasources.ClientService
+
+	current models.DataSourceList
+	created []*models.AddDataSourceCommand
+	updated []*models.UpdateDataSourceCommand
+	deleted []string
+}
+
+func (f *fakeDatasources) GetDataSources(opts ...datasources.ClientOption) (*datasources.GetDataSourcesOK, error) {
+	return &datasources.GetDataSourcesOK{Payload: f.current}, nil
+}
+
+func (f *fakeDatasources) AddDataSource(body *models.AddDataSourceCommand, opts ...datasources.ClientOption) (*datasources.AddDataSourceOK, error) {
+	f.created = append(f.created, body)
+	id := int64(100 + len(f.created))
+	return &datasources.AddDataSourceOK{Payload: &models.AddDataSourceOKBody{ID: &id}}, nil
+}
+
+func (f *fakeDatasources) UpdateDataSourceByID(id string, body *models.UpdateDataSourceCommand, opts ...datasources.ClientOption) (*datasources.UpdateDataSourceByIDOK, error) {
+	f.updated = append(f.updated, body)
+	return &datasources.UpdateDataSourceByIDOK{}, nil
+}
+
+func (f *fakeDatasources) DeleteDataSourceByID(id string, opts ...datasources.ClientOption) (*datasources.DeleteDataSourceByIDOK, error) {
+	f.deleted = append(f.deleted, id)
+	return &datasources.DeleteDataSourceByIDOK{}, nil
+}
+
+// fakeOrgs simulates an organization created with the same name, e.g. manually, between the lookup and the creation.
+type fakeOrgs struct {
+	orgs.ClientService
+
+	// existing is the ID of the organization created concurrently, it is not found until the creation is attempted.
+	existing int64
+	created  bool
+}
+
+func (f *fakeOrgs) GetOrgByName(name string, opts ...orgs.ClientOption) (*orgs.GetOrgByNameOK, error) {
+	if !f.created {
+		return nil, errors.New("[GET /orgs/name/{org_name}][404] getOrgByNameNotFound (status 404)")
+	}
+	return &orgs.GetOrgByNameOK{Payload: &models.OrgDetailsDTO{ID: f.existing, Name: name}}, nil
+}
+
+func (f *fakeOrgs) GetOrgByID(orgID int64, opts ...orgs.ClientOption) (*orgs.GetOrgByIDOK, error) {
+	return nil, errors.New("[GET /orgs/{org_id}][404] getOrgByIdNotFound (status 404)")
+}
+
+func (f *fakeOrgs) CreateOrg(body *models.CreateOrgCommand, opts ...orgs.ClientOption) (*orgs.CreateOrgOK, error) {
+	f.created = true
+	return nil, errors.New("[POST /orgs][409] createOrgConflict {\"message\":\"Organization name taken\"} (status 409)")
+}
+
+// configuredDatasources returns the datasources as Grafana would list them once configured for the organization.
+func configuredDatasources(t *testing.T, organization Organization) models.DataSourceList {
+	list := make(models.DataSourceList, 0, len(defaultDatasources))
+	for i, datasource := range defaultDatasources {
+		// Round trip the json data as it would be decoded from the Grafana API response.
+		var jsonData map[string]interface{}
+		data, err := json.Marshal(datasource.buildJSONData(organization))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := json.Unmarshal(data, &jsonData); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		list = append(list, &models.DataSourceListItemDTO{
+			ID:        int64(i + 1),
+			Name:      datasource.Name,
+			Type:      datasource.Type,
+			URL:       datasource.URL,
+			IsDefault: datasource.IsDefault,
+			Access:    models.DsAccess(datasource.Access),
+			JSONData:  jsonData,
+		})
+	}
+	return list
+}
+
+func TestConfigureDefaultDatasources(t *testing.T) {
+	organization := Organization{
+		ID:        2,
+		Name:      "test",
+		TenantIDs: []string{"tenant"},
+	}
+
+	tests := []struct {
+		name            string
+		current         func(t *testing.T) models.DataSourceList
+		expectedCreated int
+		expectedUpdated int
+		expectedDeleted []string
+	}{
+		{
+			name: "new organization gets all datasources created",
+			current: func(t *testing.T) models.DataSourceList {
+				return models.DataSourceList{}
+			},
+			expectedCreated: len(defaultDatasources),
+		},
+		{
+			name:    "unchanged organization triggers no write",
+			current: func(t *testing.T) models.DataSourceList { return configuredDatasources(t, organization) },
+		},
+		{
+			name: "changed tenants only update the affected datasources",
+			current: func(t *testing.T) models.DataSourceList {
+				return configuredDatasources(t, Organization{ID: 2, Name: "test", TenantIDs: []string{"old-tenant"}})
+			},
+			// Only the Loki datasource uses the organization tenants.
+			expectedUpdated: 1,
+		},
+		{
+			name: "changed datasource url only updates that datasource",
+			current: func(t *testing.T) models.DataSourceList {
+				list := configuredDatasources(t, organization)
+				list[0].URL = "http://changed"
+				return list
+			},
+			expectedUpdated: 1,
+		},
+		{
+			name: "stale managed datasources are deleted and unmanaged ones are kept",
+			current: func(t *testing.T) models.DataSourceList {
+				list := configuredDatasources(t, organization)
+				return append(list,
+					&models.DataSourceListItemDTO{ID: 42, Name: "Old", JSONData: map[string]interface{}{datasourceManagedByKey: datasourceManagedByValue}},
+					&models.DataSourceListItemDTO{ID: 43, Name: "Custom", JSONData: map[string]interface{}{}},
+				)
+			},
+			expectedDeleted: []string{"42"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeDatasources{current: tt.current(t)}
+			grafanaAPI := &client.GrafanaHTTPAPI{
+				Datasources: fake,
+			}
+
+			configured, err := ConfigureDefaultDatasources(context.Background(), grafanaAPI, organization)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(fake.created) != tt.expectedCreated {
+				t.Errorf("expected %d created datasources, got %d", tt.expectedCreated, len(fake.created))
+			}
+			if len(fake.updated) != tt.expectedUpdated {
+				t.Errorf("expected %d updated datasources, got %d", tt.expectedUpdated, len(fake.updated))
+			}
+			if len(fake.deleted) != len(tt.expectedDeleted) {
+				t.Fatalf("expected deleted datasources %v, got %v", tt.expectedDeleted, fake.deleted)
+			}
+			for i := range fake.deleted {
+				if fake.deleted[i] != tt.expectedDeleted[i] {
+					t.Errorf("expected deleted datasources %v, got %v", tt.expectedDeleted, fake.deleted)
+				}
+			}
+			if len(configured) != len(defaultDatasources) {
+				t.Errorf("expected %d configured datasources, got %d", len(defaultDatasources), len(configured))
+			}
+		})
+	}
+}
+
+func TestConfigureDefaultDatasourcesExtraDatasources(t *testing.T) {
+	t.Run("organization requesting graphite gets a graphite datasource", func(t *testing.T) {
+		organization := Organization{ID: 2, Name: "test", ExtraDatasources: []string{"graphite"}}
+		fake := &fakeDatasources{current: configuredDatasources(t, organization)}
+		grafanaAPI := &client.GrafanaHTTPAPI{
+			Datasources: fake,
+		}
+
+		configured, err := ConfigureDefaultDatasources(context.Background(), grafanaAPI, organization)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(fake.created) != 1 || fake.created[0].Type != "graphite" {
+			t.Fatalf("expected a graphite datasource to be created, got %v", fake.created)
+		}
+		if len(configured) != len(defaultDatasources)+1 {
+			t.Errorf("expected %d configured datasources, got %d", len(defaultDatasources)+1, len(configured))
+		}
+	})
+
+	t.Run("organization requesting an unknown datasource type is rejected", func(t *testing.T) {
+		organization := Organization{ID: 2, Name: "test", ExtraDatasources: []string{"unknown"}}
+		fake := &fakeDatasources{}
+		grafanaAPI := &client.GrafanaHTTPAPI{
+			Datasources: fake,
+		}
+
+		_, err := ConfigureDefaultDatasources(context.Background(), grafanaAPI, organization)
+		if err == nil {
+			t.Fatalf("expected an error for an unknown datasource type")
+		}
+		if len(fake.created) != 0 || len(fake.updated) != 0 || len(fake.deleted) != 0 {
+			t.Errorf("expected no datasource change, got %d created, %d updated, %d deleted", len(fake.created), len(fake.updated), len(fake.deleted))
+		}
+	})
+}
+
+func TestConfigureDefaultDatasourcesFederatedReadTenants(t *testing.T) {
+	organization := Organization{ID: 2, Name: "test", TenantIDs: []string{"giantswarm"}, FederatedReadTenantIDs: []string{"giantswarm", "atlas"}}
+	fake := &fakeDatasources{current: configuredDatasources(t, organization)}
+	grafanaAPI := &client.GrafanaHTTPAPI{
+		Datasources: fake,
+	}
+
+	_, err := ConfigureDefaultDatasources(context.Background(), grafanaAPI, organization)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(fake.created) != 1 || fake.created[0].Name != federatedMimirDatasourceName {
+		t.Fatalf("expected a federated Mimir datasource to be created, got %v", fake.created)
+	}
+	if fake.created[0].IsDefault {
+		t.Errorf("expected the federated Mimir datasource not to be the default one")
+	}
+	if got := fake.created[0].SecureJSONData["httpHeaderValue1"]; got != "giantswarm|atlas" {
+		t.Errorf("expected the federated tenants header %q, got %q", "giantswarm|atlas", got)
+	}
+	if len(fake.updated) != 0 {
+		t.Errorf("expected the default datasources to be left untouched, got %d updated", len(fake.updated))
+	}
+}
+
+func TestConfigureDefaultDatasourcesOrgIDHeader(t *testing.T) {
+	organization := Organization{ID: 2, Name: "test", TenantIDs: []string{"giantswarm"}, OrgIDHeader: "X-Tenant-ID"}
+
+	t.Run("new datasources use the configured header", func(t *testing.T) {
+		fake := &fakeDatasources{}
+		grafanaAPI := &client.GrafanaHTTPAPI{
+			Datasources: fake,
+		}
+
+		_, err := ConfigureDefaultDatasources(context.Background(), grafanaAPI, organization)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(fake.created) != len(defaultDatasources) {
+			t.Fatalf("expected %d datasources to be created, got %d", len(defaultDatasources), len(fake.created))
+		}
+		for _, created := range fake.created {
+			jsonData := created.JSONData.(map[string]interface{})
+			if got := jsonData["httpHeaderName1"]; got != "X-Tenant-ID" {
+				t.Errorf("expected datasource %q to use the header %q, got %q", created.Name, "X-Tenant-ID", got)
+			}
+		}
+	})
+
+	t.Run("new datasources use the default header when none is configured", func(t *testing.T) {
+		fake := &fakeDatasources{}
+		grafanaAPI := &client.GrafanaHTTPAPI{
+			Datasources: fake,
+		}
+
+		unconfigured := organization
+		unconfigured.OrgIDHeader = ""
+		_, err := ConfigureDefaultDatasources(context.Background(), grafanaAPI, unconfigured)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, created := range fake.created {
+			jsonData := created.JSONData.(map[string]interface{})
+			if got := jsonData["httpHeaderName1"]; got != "X-Scope-OrgID" {
+				t.Errorf("expected datasource %q to use the header %q, got %q", created.Name, "X-Scope-OrgID", got)
+			}
+		}
+	})
+
+	t.Run("existing datasources are updated when the header changes", func(t *testing.T) {
+		previous := organization
+		previous.OrgIDHeader = "X-Scope-OrgID"
+		fake := &fakeDatasources{current: configuredDatasources(t, previous)}
+		grafanaAPI := &client.GrafanaHTTPAPI{
+			Datasources: fake,
+		}
+
+		_, err := ConfigureDefaultDatasources(context.Background(), grafanaAPI, organization)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(fake.updated) != len(defaultDatasources) {
+			t.Fatalf("expected %d datasources to be updated, got %d", len(defaultDatasources), len(fake.updated))
+		}
+		for _, updated := range fake.updated {
+			jsonData := updated.JSONData.(map[string]interface{})
+			if got := jsonData["httpHeaderName1"]; got != "X-Tenant-ID" {
+				t.Errorf("expected datasource %q to use the header %q, got %q", updated.Name, "X-Tenant-ID", got)
+			}
+		}
+	})
+}
+
+func TestConfigureDefaultDatasourcesJSONDataOverrides(t *testing.T) {
+	t.Run("only the overridden fields of the datasources of that type change", func(t *testing.T) {
+		organization := Organization{
+			ID:        2,
+			Name:      "test",
+			TenantIDs: []string{"giantswarm"},
+			DatasourceJSONDataOverrides: map[string][]byte{
+				"prometheus": []byte(`{"timeInterval": "30s", "cacheLevel": "Low"}`),
+			},
+		}
+		fake := &fakeDatasources{current: configuredDatasources(t, Organization{ID: 2, Name: "test", TenantIDs: []string{"giantswarm"}})}
+		grafanaAPI := &client.GrafanaHTTPAPI{
+			Datasources: fake,
+		}
+
+		_, err := ConfigureDefaultDatasources(context.Background(), grafanaAPI, organization)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(fake.updated) != 1 || fake.updated[0].Name != mimirDatasourceName {
+			t.Fatalf("expected only the Mimir datasource to be updated, got %v", fake.updated)
+		}
+		jsonData := fake.updated[0].JSONData.(map[string]interface{})
+		expected := map[string]interface{}{
+			"cacheLevel":     "Low",
+			"httpMethod":     "POST",
+			"mimirVersion":   "2.14.0",
+			"prometheusType": "Mimir",
+			"timeInterval":   "30s",
+		}
+		for key, value := range expected {
+			if jsonData[key] != value {
+				t.Errorf("expected %s to be %v, got %v", key, value, jsonData[key])
+			}
+		}
+
+		// The defaults shared by the other organizations are left unchanged
+		mimir := defaultDatasources[slices.IndexFunc(defaultDatasources, func(d Datasource) bool { return d.Name == mimirDatasourceName })]
+		if mimir.JSONData["timeInterval"] != "60s" {
+			t.Errorf("expected the default Mimir datasource to be left unchanged, got timeInterval %v", mimir.JSONData["timeInterval"])
+		}
+	})
+
+	t.Run("overrides of a datasource type which is not configured are rejected", func(t *testing.T) {
+		organization := Organization{
+			ID:                          2,
+			Name:                        "test",
+			DatasourceJSONDataOverrides: map[string][]byte{"graphite": []byte(`{"graphiteVersion": "1.2"}`)},
+		}
+		fake := &fakeDatasources{}
+		grafanaAPI := &client.GrafanaHTTPAPI{
+			Datasources: fake,
+		}
+
+		_, err := ConfigureDefaultDatasources(context.Background(), grafanaAPI, organization)
+		if err == nil {
+			t.Fatalf("expected an error for an override of a datasource type which is not configured")
+		}
+		if len(fake.created) != 0 || len(fake.updated) != 0 || len(fake.deleted) != 0 {
+			t.Errorf("expected no datasource change, got %d created, %d updated, %d deleted", len(fake.created), len(fake.updated), len(fake.deleted))
+		}
+	})
+
+	t.Run("overrides of the fields set by the operator are rejected", func(t *testing.T) {
+		for _, key := range operatorJSONDataKeys {
+			organization := Organization{
+				ID:                          2,
+				Name:                        "test",
+				DatasourceJSONDataOverrides: map[string][]byte{"prometheus": []byte(`{"` + key + `": "other"}`)},
+			}
+
+			if err := ValidateDatasources(organization); err == nil {
+				t.Errorf("expected an error for an override of %q", key)
+			}
+		}
+	})
+}
+
+func TestUpsertOrganizationAdoptsExistingOrganization(t *testing.T) {
+	var entries []string
+	fakeOrgs := &fakeOrgs{existing: 7}
+	grafanaAPI := &client.GrafanaHTTPAPI{Orgs: fakeOrgs}
+
+	organization := &Organization{Name: "Giant Swarm", OwnedOrgIDs: []int64{3}}
+	if err := UpsertOrganization(auditContext(&entries), grafanaAPI, organization); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !fakeOrgs.created {
+		t.Fatalf("expected the organization creation to be attempted")
+	}
+	if organization.ID != 7 {
+		t.Errorf("expected the existing organization ID %d to be adopted, got %d", 7, organization.ID)
+	}
+	if len(entries) != 1 || !strings.Contains(entries[0], `"operation"="adopt"`) || !strings.Contains(entries[0], `"outcome"="success"`) {
+		t.Errorf("expected the adoption to be audited, got %v", entries)
+	}
+}
+
+func TestUpsertOrganizationRefusesOwnedOrganization(t *testing.T) {
+	var entries []string
+	fakeOrgs := &fakeOrgs{existing: 7}
+	grafanaAPI := &client.GrafanaHTTPAPI{Orgs: fakeOrgs}
+
+	// the existing organization is owned by another GrafanaOrganization
+	organization := &Organization{Name: "Giant Swarm", OwnedOrgIDs: []int64{3, 7}}
+	if err := UpsertOrganization(auditContext(&entries), grafanaAPI, organization); err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	if organization.ID != 0 {
+		t.Errorf("expected the existing organization not to be adopted, got ID %d", organization.ID)
+	}
+	if len(entries) != 1 || !strings.Contains(entries[0], `"operation"="adopt"`) || !strings.Contains(entries[0], `"outcome"="failure"`) {
+		t.Errorf("expected the refused adoption to be audited, got %v", entries)
+	}
+}
+
+func TestWithOrgID(t *testing.T) {
+	var orgIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orgIDs = append(orgIDs, r.Header.Get(client.OrgIDHeader))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	grafanaAPI := client.NewHTTPClientWithConfig(nil, &client.TransportConfig{
+		Schemes:  []string{serverURL.Scheme},
+		BasePath: "/api",
+		Host:     serverURL.Host,
+	})
+
+	if _, err := WithOrgID(grafanaAPI, 2).Datasources.GetDataSources(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The organization of the original client is left untouched
+	if _, err := grafanaAPI.Datasources.GetDataSources(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(orgIDs, []string{"2", ""}) {
+		t.Errorf("expected the organization header to be set on the scoped client only, got %q", orgIDs)
+	}
+}
@@ -0,0 +1,70 @@
+package grafana
+
+import (
+	"context"
+	"strings"
+
+	"github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/pkg/errors"
+)
+
+// libraryPanelKind is the kind of the library elements holding panels.
+const libraryPanelKind = 1
+
+// PublishLibraryPanel creates or updates the library panel in the organization of the client.
+// The panel is named after its title and keeps the folder it was moved to in Grafana.
+func PublishLibraryPanel(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, orgID int64, panel map[string]any) error {
+	uid, _ := panel["uid"].(string)
+	if uid == "" {
+		return errors.New("library panel UID not found")
+	}
+	name, _ := panel["title"].(string)
+	if name == "" {
+		name = uid
+	}
+
+	existing, err := grafanaAPI.LibraryElements.GetLibraryElementByUID(uid)
+	if IsNotFound(err) {
+		_, err = grafanaAPI.LibraryElements.CreateLibraryElement(&models.CreateLibraryElementCommand{
+			UID:   uid,
+			Name:  name,
+			Kind:  libraryPanelKind,
+			Model: any(panel),
+		})
+		audit(ctx, auditOperationCreate, "library-panel", orgID, uid, err)
+		return errors.WithStack(err)
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Grafana rejects the update unless it is based on the current version of the library panel
+	_, err = grafanaAPI.LibraryElements.UpdateLibraryElement(uid, &models.PatchLibraryElementCommand{
+		UID:       uid,
+		Name:      name,
+		Kind:      libraryPanelKind,
+		Model:     any(panel),
+		FolderUID: existing.Payload.Result.FolderUID,
+		Version:   existing.Payload.Result.Version,
+	})
+	audit(ctx, auditOperationUpdate, "library-panel", orgID, uid, err)
+	return errors.WithStack(err)
+}
+
+// DeleteLibraryPanel deletes the library panel from the organization of the client.
+// Grafana refuses to delete library panels which are still used by dashboards.
+func DeleteLibraryPanel(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, orgID int64, uid string) error {
+	_, err := grafanaAPI.LibraryElements.DeleteLibraryElementByUID(uid)
+	audit(ctx, auditOperationDelete, "library-panel", orgID, uid, err)
+	return err
+}
+
+// IsLibraryPanelInUse returns true if the error returned by the Grafana API is a 403 refusing to delete a library panel still used by dashboards.
+func IsLibraryPanelInUse(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// Parsing error message to find out the error code
+	return strings.Contains(err.Error(), "(status 403)")
+}
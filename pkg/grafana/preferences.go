@@ -0,0 +1,49 @@
+package grafana
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ConfigureOrganizationPreferences sets the default home dashboard and theme of the organization.
+// Preferences which are not set on the organization are left untouched so they can still be managed from Grafana.
+func ConfigureOrganizationPreferences(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, organization Organization) error {
+	logger := log.FromContext(ctx)
+
+	if organization.HomeDashboardUID == "" && organization.Theme == "" {
+		logger.Info("no organization preferences to configure")
+		return nil
+	}
+
+	grafanaAPI = WithOrgID(grafanaAPI, organization.ID)
+
+	resp, err := grafanaAPI.OrgPreferences.GetOrgPreferences()
+	if err != nil {
+		logger.Error(err, "failed to get organization preferences")
+		return errors.WithStack(err)
+	}
+
+	current := resp.Payload
+	if (organization.HomeDashboardUID == "" || current.HomeDashboardUID == organization.HomeDashboardUID) &&
+		(organization.Theme == "" || current.Theme == organization.Theme) {
+		logger.Info("organization preferences are up to date")
+		return nil
+	}
+
+	_, err = grafanaAPI.OrgPreferences.PatchOrgPreferences(&models.PatchPrefsCmd{
+		HomeDashboardUID: organization.HomeDashboardUID,
+		Theme:            organization.Theme,
+	})
+	audit(ctx, auditOperationUpdate, "organization-preferences", organization.ID, organization.Name, err)
+	if err != nil {
+		logger.Error(err, "failed to update organization preferences")
+		return errors.WithStack(err)
+	}
+	logger.Info("updated organization preferences")
+
+	return nil
+}
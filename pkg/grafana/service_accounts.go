@@ -0,0 +1,139 @@
+package grafana
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/client/service_accounts"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// CreateServiceAccountToken ensures the service account exists in the organization with the given role and creates a new token for it.
+// Existing tokens are left untouched so it is the caller responsibility to only request a token when it does not have one already.
+func CreateServiceAccountToken(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, organization Organization, name string, role string) (string, error) {
+	logger := log.FromContext(ctx)
+
+	grafanaAPI = WithOrgID(grafanaAPI, organization.ID)
+
+	serviceAccountID, err := ensureServiceAccount(ctx, grafanaAPI, organization, name, role)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	// Token names must be unique for a service account
+	tokenName := fmt.Sprintf("%s-%d", name, time.Now().Unix())
+	token, err := grafanaAPI.ServiceAccounts.CreateToken(service_accounts.NewCreateTokenParams().
+		WithServiceAccountID(serviceAccountID).
+		WithBody(&models.AddServiceAccountTokenCommand{Name: tokenName}))
+	audit(ctx, auditOperationCreate, "service-account-token", organization.ID, tokenName, err)
+	if err != nil {
+		logger.Error(err, "failed to create service account token", "serviceAccount", name)
+		return "", errors.WithStack(err)
+	}
+	logger.Info("created service account token", "serviceAccount", name)
+
+	return token.Payload.Key, nil
+}
+
+// ConfigureServiceAccount ensures the service account exists in the organization with the given role.
+func ConfigureServiceAccount(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, organization Organization, name string, role string) error {
+	_, err := ensureServiceAccount(ctx, WithOrgID(grafanaAPI, organization.ID), organization, name, role)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// DeleteServiceAccount deletes the service account and its tokens from the organization, if it exists.
+func DeleteServiceAccount(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, organization Organization, name string) error {
+	logger := log.FromContext(ctx)
+
+	grafanaAPI = WithOrgID(grafanaAPI, organization.ID)
+
+	serviceAccount, err := findServiceAccount(ctx, grafanaAPI, name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if serviceAccount == nil {
+		return nil
+	}
+
+	_, err = grafanaAPI.ServiceAccounts.DeleteServiceAccount(serviceAccount.ID)
+	audit(ctx, auditOperationDelete, "service-account", organization.ID, name, err)
+	if err != nil {
+		logger.Error(err, "failed to delete service account", "serviceAccount", name)
+		return errors.WithStack(err)
+	}
+	logger.Info("deleted service account", "serviceAccount", name)
+
+	return nil
+}
+
+// ensureServiceAccount returns the ID of the service account, creating it in the organization of the client if it does not exist
+// and updating its role if it changed.
+func ensureServiceAccount(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, organization Organization, name string, role string) (int64, error) {
+	logger := log.FromContext(ctx)
+
+	serviceAccount, err := findServiceAccount(ctx, grafanaAPI, name)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	if serviceAccount != nil {
+		if role == "" || serviceAccount.Role == role {
+			return serviceAccount.ID, nil
+		}
+
+		_, err = grafanaAPI.ServiceAccounts.UpdateServiceAccount(service_accounts.NewUpdateServiceAccountParams().
+			WithServiceAccountID(serviceAccount.ID).
+			WithBody(&models.UpdateServiceAccountForm{Role: role}))
+		audit(ctx, auditOperationUpdate, "service-account", organization.ID, name, err)
+		if err != nil {
+			logger.Error(err, "failed to update service account role", "serviceAccount", name)
+			return 0, errors.WithStack(err)
+		}
+		logger.Info("updated service account role", "serviceAccount", name, "role", role)
+
+		return serviceAccount.ID, nil
+	}
+
+	created, err := grafanaAPI.ServiceAccounts.CreateServiceAccount(service_accounts.NewCreateServiceAccountParams().
+		WithBody(&models.CreateServiceAccountForm{
+			Name: name,
+			Role: role,
+		}))
+	audit(ctx, auditOperationCreate, "service-account", organization.ID, name, err)
+	if err != nil {
+		logger.Error(err, "failed to create service account", "serviceAccount", name)
+		return 0, errors.WithStack(err)
+	}
+	logger.Info("created service account", "serviceAccount", name)
+
+	return created.Payload.ID, nil
+}
+
+// findServiceAccount returns the service account with the given name in the organization of the client, or nil if it does not exist.
+func findServiceAccount(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI, name string) (*models.ServiceAccountDTO, error) {
+	logger := log.FromContext(ctx)
+
+	resp, err := grafanaAPI.ServiceAccounts.SearchOrgServiceAccountsWithPaging(service_accounts.NewSearchOrgServiceAccountsWithPagingParams().
+		WithQuery(&name))
+	if err != nil {
+		logger.Error(err, "failed to search service accounts", "serviceAccount", name)
+		return nil, errors.WithStack(err)
+	}
+
+	// The query matches service accounts whose name contains the given name
+	for _, serviceAccount := range resp.Payload.ServiceAccounts {
+		if serviceAccount.Name == name {
+			return serviceAccount, nil
+		}
+	}
+
+	return nil, nil
+}
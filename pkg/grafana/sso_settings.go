@@ -42,6 +42,7 @@ func ConfigureSSOSettings(ctx context.Context, grafanaAPI *client.GrafanaHTTPAPI
 			Provider: resp.Payload.Provider,
 			Settings: settings,
 		})
+	audit(ctx, auditOperationUpdate, "sso-settings", 0, provider, err)
 
 	if err != nil {
 		logger.Error(err, "failed to configure grafana sso.")
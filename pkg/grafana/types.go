@@ -1,11 +1,27 @@
 package grafana
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"slices"
 	"strings"
 
-	common "github.com/giantswarm/observability-operator/pkg/common/monitoring"
+	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
 )
 
+const (
+	datasourceManagedByKey          = "managedBy"
+	datasourceManagedByValue        = "observability-operator"
+	datasourceSecureJSONDataHashKey = "secureJsonDataHash"
+	datasourceHTTPHeaderNameKey     = "httpHeaderName1"
+)
+
+// operatorJSONDataKeys are the JSON data keys set by the operator, which the overrides may not replace.
+var operatorJSONDataKeys = []string{datasourceHTTPHeaderNameKey, datasourceManagedByKey, datasourceSecureJSONDataHashKey}
+
 type Organization struct {
 	ID        int64
 	Name      string
@@ -13,6 +29,29 @@ type Organization struct {
 	Admins    []string
 	Editors   []string
 	Viewers   []string
+
+	// HomeDashboardUID is the UID of the default home dashboard of the organization.
+	HomeDashboardUID string
+	// Theme is the default theme of the organization.
+	Theme string
+	// ExtraDatasources are the types of the datasources configured on top of the default ones.
+	ExtraDatasources []string
+	// DatasourceJSONDataOverrides are the JSON objects merged on top of the JSON data of the datasources, indexed by datasource type.
+	DatasourceJSONDataOverrides map[string][]byte
+	// FederatedReadTenantIDs are the tenants queried together through the federated Mimir datasource.
+	FederatedReadTenantIDs []string
+	// OwnedOrgIDs are the IDs of the organizations owned by other GrafanaOrganizations, which are never adopted.
+	OwnedOrgIDs []int64
+	// OrgIDHeader is the name of the header the datasources send the tenants in, X-Scope-OrgID when it is empty.
+	OrgIDHeader string
+}
+
+// orgIDHeaderName returns the name of the header the datasources send the tenants in.
+func (o Organization) orgIDHeaderName() string {
+	if o.OrgIDHeader == "" {
+		return commonmonitoring.OrgIDHeader
+	}
+	return o.OrgIDHeader
 }
 
 type Datasource struct {
@@ -23,6 +62,9 @@ type Datasource struct {
 	URL       string
 	Access    string
 	JSONData  map[string]interface{}
+
+	// TenantIDs overrides the tenants the datasource queries when set.
+	TenantIDs []string
 }
 
 func (d Datasource) withID(id int64) Datasource {
@@ -30,20 +72,51 @@ func (d Datasource) withID(id int64) Datasource {
 	return d
 }
 
-func (d Datasource) buildJSONData() map[string]interface{} {
-	if d.JSONData == nil {
-		d.JSONData = make(map[string]interface{})
+func (d Datasource) buildJSONData(organization Organization) map[string]interface{} {
+	jsonData := maps.Clone(d.JSONData)
+	if jsonData == nil {
+		jsonData = make(map[string]interface{})
 	}
 
 	// Add tenant header name
-	d.JSONData["httpHeaderName1"] = common.OrgIDHeader
+	jsonData[datasourceHTTPHeaderNameKey] = organization.orgIDHeaderName()
+	// Mark the datasource as managed so we can clean it up once it is not desired anymore
+	jsonData[datasourceManagedByKey] = datasourceManagedByValue
+	// Secure json data cannot be read back from Grafana so we keep track of its hash to detect changes
+	jsonData[datasourceSecureJSONDataHashKey] = hashSecureJSONData(d.buildSecureJSONData(organization))
 
-	return d.JSONData
+	return jsonData
+}
+
+// isUpToDate returns true if the configured datasource matches the desired datasource.
+func (d Datasource) isUpToDate(configured Datasource) bool {
+	if d.Type != configured.Type || d.URL != configured.URL || d.IsDefault != configured.IsDefault || d.Access != configured.Access {
+		return false
+	}
+
+	// Values read from Grafana are decoded from JSON so we compare the JSON representations.
+	desiredJSONData, err := json.Marshal(d.JSONData)
+	if err != nil {
+		return false
+	}
+	configuredJSONData, err := json.Marshal(configured.JSONData)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(desiredJSONData, configuredJSONData)
+}
+
+// isManaged returns true if the datasource was created by the operator.
+func (d Datasource) isManaged() bool {
+	return d.JSONData[datasourceManagedByKey] == datasourceManagedByValue
 }
 
 func (d Datasource) buildSecureJSONData(organization Organization) map[string]string {
 	tenantIDs := organization.TenantIDs
-	if d.Type != "loki" {
+	if len(d.TenantIDs) > 0 {
+		tenantIDs = d.TenantIDs
+	} else if d.Type != "loki" {
 		// We do not support multi-tenancy for Mimir yet
 		tenantIDs = []string{"anonymous"}
 	}
@@ -51,3 +124,12 @@ func (d Datasource) buildSecureJSONData(organization Organization) map[string]st
 		"httpHeaderValue1": strings.Join(tenantIDs, "|"),
 	}
 }
+
+// hashSecureJSONData returns a stable hash of the secure json data.
+func hashSecureJSONData(secureJSONData map[string]string) string {
+	hash := sha256.New()
+	for _, key := range slices.Sorted(maps.Keys(secureJSONData)) {
+		hash.Write([]byte(key + "=" + secureJSONData[key] + "\n"))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -10,10 +10,22 @@ var (
 		Name: "observability_operator_mimir_head_series_query_errors_total",
 		Help: "Total number of reconciliations error",
 	}, nil)
+
+	GrafanaOrganizationTenants = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "observability_operator_grafana_organization_tenants",
+		Help: "Number of tenants of the Grafana organization, labeled by the name of the GrafanaOrganization",
+	}, []string{"organization"})
+
+	ClusterMonitoringAgent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "observability_operator_cluster_monitoring_agent",
+		Help: "Monitoring agent configured for the cluster, set to 1 for the active agent",
+	}, []string{"cluster", "namespace", "monitoring_agent"})
 )
 
 func init() {
 	metrics.Registry.MustRegister(
 		MimirQueryErrors,
+		GrafanaOrganizationTenants,
+		ClusterMonitoringAgent,
 	)
 }
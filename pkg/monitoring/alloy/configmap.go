@@ -5,6 +5,9 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"maps"
+	"regexp"
+	"strings"
 	"text/template"
 
 	v1 "k8s.io/api/core/v1"
@@ -14,17 +17,22 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/Masterminds/sprig/v3"
+	"github.com/blang/semver"
 	"github.com/pkg/errors"
 
 	"github.com/giantswarm/observability-operator/pkg/common"
 	"github.com/giantswarm/observability-operator/pkg/common/labels"
 	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
 	"github.com/giantswarm/observability-operator/pkg/metrics"
+	"github.com/giantswarm/observability-operator/pkg/monitoring"
 	"github.com/giantswarm/observability-operator/pkg/monitoring/mimir/querier"
 	"github.com/giantswarm/observability-operator/pkg/monitoring/prometheusagent/sharding"
 )
 
 var (
+	observabilityBundleVersionSupportScrapeTimeout     = semver.MustParse("2.2.0")
+	observabilityBundleVersionSupportDroppedScrapeJobs = semver.MustParse("2.2.0")
+
 	//go:embed templates/alloy-config.alloy.template
 	alloyConfig         string
 	alloyConfigTemplate *template.Template
@@ -39,22 +47,11 @@ func init() {
 	alloyMonitoringConfigTemplate = template.Must(template.New("monitoring-config.yaml").Funcs(sprig.FuncMap()).Parse(alloyMonitoringConfig))
 }
 
-func (a *Service) GenerateAlloyMonitoringConfigMapData(ctx context.Context, currentState *v1.ConfigMap, cluster *clusterv1.Cluster) (map[string]string, error) {
+// GenerateAlloyMonitoringConfigMapData generates the data of the Alloy monitoring configmap and returns the scaling of its shards which is still pending.
+func (a *Service) GenerateAlloyMonitoringConfigMapData(ctx context.Context, currentState *v1.ConfigMap, cluster *clusterv1.Cluster, observabilityBundleVersion semver.Version) (map[string]string, sharding.PendingScaling, error) {
 	logger := log.FromContext(ctx)
 
-	// Get current number of shards from Alloy's config.
-	// Shards here is equivalent to replicas in the Alloy controller deployment.
-	var currentShards = sharding.DefaultShards
-	if currentState != nil && currentState.Data != nil && currentState.Data["values"] != "" {
-		var monitoringConfig monitoringConfig
-		err := yaml.Unmarshal([]byte(currentState.Data["values"]), &monitoringConfig)
-		if err != nil {
-			logger.Info("alloy-service - failed to unmarshal current monitoring config", "error", err)
-		} else {
-			currentShards = monitoringConfig.Alloy.Controller.Replicas
-			logger.Info("alloy-service - current number of shards", "shards", currentShards)
-		}
-	}
+	currentShards := getCurrentShards(ctx, currentState)
 
 	// Compute the number of shards based on the number of series.
 	query := fmt.Sprintf(`sum(max_over_time((sum(prometheus_remote_write_wal_storage_active_series{cluster_id="%s", component_id="prometheus.remote_write.default", service="%s"})by(pod))[6h:1h]))`, cluster.Name, commonmonitoring.AlloyMonitoringAgentAppName)
@@ -66,15 +63,15 @@ func (a *Service) GenerateAlloyMonitoringConfigMapData(ctx context.Context, curr
 
 	clusterShardingStrategy, err := commonmonitoring.GetClusterShardingStrategy(cluster)
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, sharding.PendingScaling{}, errors.WithStack(err)
 	}
 
 	shardingStrategy := a.MonitoringConfig.DefaultShardingStrategy.Merge(clusterShardingStrategy)
-	shards := shardingStrategy.ComputeShards(currentShards, headSeries)
+	shards, pending := commonmonitoring.ComputeClusterShards(ctx, cluster, shardingStrategy, currentShards, headSeries)
 
-	alloyConfig, err := a.generateAlloyConfig(ctx, cluster)
+	alloyConfig, err := a.generateAlloyConfig(ctx, cluster, shards, observabilityBundleVersion)
 	if err != nil {
-		return nil, err
+		return nil, sharding.PendingScaling{}, err
 	}
 
 	data := struct {
@@ -82,38 +79,119 @@ func (a *Service) GenerateAlloyMonitoringConfigMapData(ctx context.Context, curr
 		PriorityClassName string
 		Replicas          int
 		SecretName        string
+
+		OTLPReceiverEnabled  bool
+		OTLPReceiverGRPCPort int
+		OTLPReceiverHTTPPort int
 	}{
 		AlloyConfig:       alloyConfig,
 		PriorityClassName: commonmonitoring.PriorityClassName,
 		Replicas:          shards,
 		SecretName:        commonmonitoring.AlloyMonitoringAgentAppName,
+
+		OTLPReceiverEnabled:  a.MonitoringConfig.OTLPReceiverEnabled,
+		OTLPReceiverGRPCPort: a.MonitoringConfig.OTLPReceiverGRPCPort,
+		OTLPReceiverHTTPPort: a.MonitoringConfig.OTLPReceiverHTTPPort,
 	}
 
 	var values bytes.Buffer
 	err = alloyMonitoringConfigTemplate.Execute(&values, data)
 	if err != nil {
-		return nil, err
+		return nil, sharding.PendingScaling{}, err
 	}
 
 	configMapData := make(map[string]string)
 	configMapData["values"] = values.String()
 
-	return configMapData, nil
+	return configMapData, pending, nil
 }
 
-func (a *Service) generateAlloyConfig(ctx context.Context, cluster *clusterv1.Cluster) (string, error) {
+// getCurrentShards returns the current number of shards from Alloy's config.
+// Shards here is equivalent to replicas in the Alloy controller deployment.
+func getCurrentShards(ctx context.Context, currentState *v1.ConfigMap) int {
+	logger := log.FromContext(ctx)
+
+	var currentShards = sharding.DefaultShards
+	if currentState != nil && currentState.Data != nil && currentState.Data["values"] != "" {
+		var monitoringConfig monitoringConfig
+		err := yaml.Unmarshal([]byte(currentState.Data["values"]), &monitoringConfig)
+		if err != nil {
+			logger.Info("alloy-service - failed to unmarshal current monitoring config", "error", err)
+		} else {
+			currentShards = monitoringConfig.Alloy.Controller.Replicas
+			logger.Info("alloy-service - current number of shards", "shards", currentShards)
+		}
+	}
+
+	return currentShards
+}
+
+func (a *Service) generateAlloyConfig(ctx context.Context, cluster *clusterv1.Cluster, shards int, observabilityBundleVersion semver.Version) (string, error) {
 	var values bytes.Buffer
 
+	queueConfigTier := a.MonitoringConfig.QueueConfigTier(shards)
+
+	var queueConfigSampleAgeLimit string
+	if queueConfigTier.SampleAgeLimit > 0 {
+		queueConfigSampleAgeLimit = queueConfigTier.SampleAgeLimit.String()
+	}
+
+	var scrapeTimeout string
+	if observabilityBundleVersion.GTE(observabilityBundleVersionSupportScrapeTimeout) {
+		timeout, err := a.MonitoringConfig.ClusterScrapeTimeout(cluster)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		if timeout > 0 {
+			scrapeTimeout = timeout.String()
+		}
+	}
+
+	// The dropped scrape jobs are matched as a whole against the job label
+	var droppedScrapeJobsRegex string
+	if observabilityBundleVersion.GTE(observabilityBundleVersionSupportDroppedScrapeJobs) {
+		droppedScrapeJobs := a.MonitoringConfig.ClusterDroppedScrapeJobs(cluster)
+		quotedJobs := make([]string, len(droppedScrapeJobs))
+		for i, job := range droppedScrapeJobs {
+			quotedJobs[i] = regexp.QuoteMeta(job)
+		}
+		droppedScrapeJobsRegex = strings.Join(quotedJobs, "|")
+	}
+
 	organization, err := a.OrganizationRepository.Read(ctx, cluster)
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
 
-	provider, err := common.GetClusterProvider(cluster)
+	provider, err := a.MonitoringConfig.ClusterProvider(cluster)
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
 
+	// The labels set by the operator take precedence over the ones copied from the cluster labels.
+	externalLabels := a.MonitoringConfig.ClusterExternalLabels(cluster)
+	maps.Copy(externalLabels, map[string]string{
+		"cluster_id":       cluster.Name,
+		"cluster_type":     common.GetClusterType(cluster, a.ManagementCluster),
+		"customer":         a.ManagementCluster.Customer,
+		"installation":     a.ManagementCluster.Name,
+		"organization":     organization,
+		"pipeline":         a.ManagementCluster.Pipeline,
+		"provider":         provider,
+		"region":           a.ManagementCluster.Region,
+		"service_priority": commonmonitoring.GetServicePriority(cluster),
+	})
+
+	// The tenant header takes precedence over the static headers, whatever their case. The headers are rendered sorted by name.
+	remoteWriteHeaders := maps.Clone(a.MonitoringConfig.RemoteWriteHeaders)
+	if remoteWriteHeaders == nil {
+		remoteWriteHeaders = make(map[string]string)
+	}
+	maps.DeleteFunc(remoteWriteHeaders, func(name string, _ string) bool {
+		return strings.EqualFold(name, a.MonitoringConfig.OrgIDHeaderName())
+	})
+	remoteWriteHeaders[a.MonitoringConfig.OrgIDHeaderName()] = a.MonitoringConfig.ClusterWriteTenant(cluster, a.ManagementCluster)
+
 	data := struct {
 		RemoteWriteURLEnvVarName               string
 		RemoteWriteNameEnvVarName              string
@@ -121,14 +199,32 @@ func (a *Service) generateAlloyConfig(ctx context.Context, cluster *clusterv1.Cl
 		RemoteWriteBasicAuthPasswordEnvVarName string
 		RemoteWriteTimeout                     string
 		RemoteWriteTLSInsecureSkipVerify       bool
+		RemoteWriteHeaders                     map[string]string
+
+		RemoteWriteTLSClientCertificate bool
+		RemoteWriteTLSCertEnvVarName    string
+		RemoteWriteTLSKeyEnvVarName     string
+
+		ScrapeInterval string
+		ScrapeTimeout  string
+
+		DroppedScrapeJobsRegex string
 
 		QueueConfigCapacity          int
 		QueueConfigMaxSamplesPerSend int
 		QueueConfigMaxShards         int
+		QueueConfigSampleAgeLimit    string
+		QueueConfigBatchSendDeadline string
 
 		WALTruncateFrequency string
 
+		OTLPReceiverEnabled  bool
+		OTLPReceiverGRPCPort int
+		OTLPReceiverHTTPPort int
+
 		ExternalLabels map[string]string
+
+		MetricRelabelRules []monitoring.MetricRelabelRule
 	}{
 		RemoteWriteURLEnvVarName:               AlloyRemoteWriteURLEnvVarName,
 		RemoteWriteNameEnvVarName:              AlloyRemoteWriteNameEnvVarName,
@@ -136,24 +232,32 @@ func (a *Service) generateAlloyConfig(ctx context.Context, cluster *clusterv1.Cl
 		RemoteWriteBasicAuthPasswordEnvVarName: AlloyRemoteWriteBasicAuthPasswordEnvVarName,
 		RemoteWriteTimeout:                     commonmonitoring.RemoteWriteTimeout,
 		RemoteWriteTLSInsecureSkipVerify:       a.ManagementCluster.InsecureCA,
+		RemoteWriteHeaders:                     remoteWriteHeaders,
+
+		RemoteWriteTLSClientCertificate: a.MonitoringConfig.RemoteWriteAuthMethod == monitoring.RemoteWriteAuthMethodTLS,
+		RemoteWriteTLSCertEnvVarName:    AlloyRemoteWriteTLSCertEnvVarName,
+		RemoteWriteTLSKeyEnvVarName:     AlloyRemoteWriteTLSKeyEnvVarName,
+
+		ScrapeInterval: commonmonitoring.ScrapeInterval,
+		ScrapeTimeout:  scrapeTimeout,
+
+		DroppedScrapeJobsRegex: droppedScrapeJobsRegex,
 
 		QueueConfigCapacity:          commonmonitoring.QueueConfigCapacity,
 		QueueConfigMaxSamplesPerSend: commonmonitoring.QueueConfigMaxSamplesPerSend,
 		QueueConfigMaxShards:         commonmonitoring.QueueConfigMaxShards,
+		QueueConfigSampleAgeLimit:    queueConfigSampleAgeLimit,
+		QueueConfigBatchSendDeadline: queueConfigTier.BatchSendDeadline.String(),
 
 		WALTruncateFrequency: a.MonitoringConfig.WALTruncateFrequency.String(),
 
-		ExternalLabels: map[string]string{
-			"cluster_id":       cluster.Name,
-			"cluster_type":     common.GetClusterType(cluster, a.ManagementCluster),
-			"customer":         a.ManagementCluster.Customer,
-			"installation":     a.ManagementCluster.Name,
-			"organization":     organization,
-			"pipeline":         a.ManagementCluster.Pipeline,
-			"provider":         provider,
-			"region":           a.ManagementCluster.Region,
-			"service_priority": commonmonitoring.GetServicePriority(cluster),
-		},
+		OTLPReceiverEnabled:  a.MonitoringConfig.OTLPReceiverEnabled,
+		OTLPReceiverGRPCPort: a.MonitoringConfig.OTLPReceiverGRPCPort,
+		OTLPReceiverHTTPPort: a.MonitoringConfig.OTLPReceiverHTTPPort,
+
+		ExternalLabels: externalLabels,
+
+		MetricRelabelRules: a.MonitoringConfig.MetricRelabelRules,
 	}
 
 	err = alloyConfigTemplate.Execute(&values, data)
@@ -169,7 +273,7 @@ func ConfigMap(cluster *clusterv1.Cluster) *v1.ConfigMap {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-%s", cluster.Name, ConfigMapName),
 			Namespace: cluster.Namespace,
-			Labels:    labels.Common,
+			Labels:    maps.Clone(labels.Common),
 		},
 	}
 
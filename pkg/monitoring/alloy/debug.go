@@ -0,0 +1,115 @@
+package alloy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
+)
+
+// DebugConfigPath is the path of the debug endpoint serving the Alloy configuration generated for a cluster.
+const DebugConfigPath = "/debug/alloy-config"
+
+var errAmbiguousCluster = errors.New("several clusters match the name, the namespace query parameter is required")
+
+// DebugConfigHandler returns a handler serving the Alloy configuration generated for the cluster named in the cluster query parameter.
+// The namespace query parameter is only required when several clusters share the same name.
+// The configuration is generated with the current number of shards of the cluster.
+func (a *Service) DebugConfigHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := log.FromContext(ctx)
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := r.URL.Query().Get("cluster")
+		if name == "" {
+			http.Error(w, "missing cluster query parameter", http.StatusBadRequest)
+			return
+		}
+
+		cluster, err := a.findCluster(ctx, name, r.URL.Query().Get("namespace"))
+		if apierrors.IsNotFound(err) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if errors.Is(err, errAmbiguousCluster) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			logger.Error(err, "alloy-service - failed to get cluster", "cluster", name)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		config, err := a.generateCurrentAlloyConfig(ctx, cluster)
+		if err != nil {
+			logger.Error(err, "alloy-service - failed to generate alloy config", "cluster", name)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(config))
+	})
+}
+
+// findCluster returns the cluster with the given name, looking it up in all namespaces when the namespace is empty.
+func (a *Service) findCluster(ctx context.Context, name string, namespace string) (*clusterv1.Cluster, error) {
+	if namespace != "" {
+		cluster := &clusterv1.Cluster{}
+		err := a.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, cluster)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return cluster, nil
+	}
+
+	clusters := &clusterv1.ClusterList{}
+	err := a.Client.List(ctx, clusters)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var found *clusterv1.Cluster
+	for i, cluster := range clusters.Items {
+		if cluster.Name != name {
+			continue
+		}
+		if found != nil {
+			return nil, errAmbiguousCluster
+		}
+		found = &clusters.Items[i]
+	}
+	if found == nil {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: clusterv1.GroupVersion.Group, Resource: "clusters"}, name)
+	}
+
+	return found, nil
+}
+
+// generateCurrentAlloyConfig generates the Alloy configuration of the cluster with its current number of shards and observability-bundle version.
+func (a *Service) generateCurrentAlloyConfig(ctx context.Context, cluster *clusterv1.Cluster) (string, error) {
+	observabilityBundleVersion, err := commonmonitoring.GetObservabilityBundleAppVersion(cluster, a.Client, ctx)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	currentState := &v1.ConfigMap{}
+	err = a.Client.Get(ctx, client.ObjectKeyFromObject(ConfigMap(cluster)), currentState)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", errors.WithStack(err)
+	}
+
+	return a.generateAlloyConfig(ctx, cluster, getCurrentShards(ctx, currentState), observabilityBundleVersion)
+}
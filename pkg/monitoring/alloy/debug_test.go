@@ -0,0 +1,119 @@
+package alloy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	appv1 "github.com/giantswarm/apiextensions-application/api/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/giantswarm/observability-operator/pkg/common"
+	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
+	"github.com/giantswarm/observability-operator/pkg/monitoring"
+)
+
+func TestDebugConfigHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, clusterv1.AddToScheme, appv1.AddToScheme} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	newCluster := func(namespace string) *clusterv1.Cluster {
+		return &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: namespace},
+			Spec: clusterv1.ClusterSpec{
+				InfrastructureRef: &v1.ObjectReference{Kind: common.AWSClusterKind},
+			},
+		}
+	}
+	cluster := newCluster("org-test")
+	otherCluster := newCluster("org-other")
+
+	objects := []runtime.Object{cluster, otherCluster}
+	for _, c := range []*clusterv1.Cluster{cluster, otherCluster} {
+		objects = append(objects, &appv1.App{
+			ObjectMeta: commonmonitoring.ObservabilityBundleAppMeta(c),
+			Spec:       appv1.AppSpec{Version: "2.2.0"},
+		})
+	}
+	currentState := ConfigMap(cluster)
+	currentState.Data = map[string]string{"values": "alloy:\n  controller:\n    replicas: 3\n"}
+	objects = append(objects, currentState)
+
+	a := &Service{
+		Client:                 fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build(),
+		OrganizationRepository: fakeOrganizationRepository{},
+		ManagementCluster:      common.ManagementCluster{Name: "test-installation"},
+		MonitoringConfig:       monitoring.Config{DefaultWriteTenant: "anonymous"},
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		query          string
+		expectedStatus int
+		expected       []string
+	}{
+		{
+			name:           "cluster config",
+			method:         http.MethodGet,
+			query:          "cluster=test-cluster&namespace=org-test",
+			expectedStatus: http.StatusOK,
+			expected: []string{
+				`prometheus.remote_write "default" {`,
+				`"cluster_id" = "test-cluster",`,
+				`sample_age_limit = "30m0s"`,
+			},
+		},
+		{
+			name:           "missing cluster",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "ambiguous cluster name",
+			method:         http.MethodGet,
+			query:          "cluster=test-cluster",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown cluster",
+			method:         http.MethodGet,
+			query:          "cluster=unknown",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "unsupported method",
+			method:         http.MethodPost,
+			query:          "cluster=test-cluster&namespace=org-test",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(tt.method, DebugConfigPath+"?"+tt.query, nil)
+
+			a.DebugConfigHandler().ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(recorder.Body.String(), expected) {
+					t.Errorf("expected the config to contain %q, got:\n%s", expected, recorder.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -5,17 +5,20 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"maps"
 	"text/template"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/pkg/errors"
 
 	"github.com/giantswarm/observability-operator/pkg/common/labels"
 	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
+	"github.com/giantswarm/observability-operator/pkg/monitoring"
 )
 
 const (
@@ -23,8 +26,15 @@ const (
 	AlloyRemoteWriteNameEnvVarName              = "REMOTE_WRITE_NAME"
 	AlloyRemoteWriteBasicAuthUsernameEnvVarName = "BASIC_AUTH_USERNAME"
 	AlloyRemoteWriteBasicAuthPasswordEnvVarName = "BASIC_AUTH_PASSWORD" // #nosec G101
+	AlloyRemoteWriteTLSCertEnvVarName           = "REMOTE_WRITE_TLS_CERT"
+	AlloyRemoteWriteTLSKeyEnvVarName            = "REMOTE_WRITE_TLS_KEY"
 )
 
+type secretEnv struct {
+	Name  string
+	Value string
+}
+
 var (
 	//go:embed templates/monitoring-secret.yaml.template
 	alloyMonitoringSecret         string
@@ -37,21 +47,18 @@ func init() {
 
 func (a *Service) GenerateAlloyMonitoringSecretData(ctx context.Context, cluster *clusterv1.Cluster) (map[string][]byte, error) {
 	url := fmt.Sprintf(commonmonitoring.RemoteWriteEndpointTemplateURL, a.ManagementCluster.BaseDomain)
-	password, err := commonmonitoring.GetMimirIngressPassword(ctx)
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
 
-	data := []struct {
-		Name  string
-		Value string
-	}{
+	data := []secretEnv{
 		{Name: AlloyRemoteWriteURLEnvVarName, Value: url},
 		{Name: AlloyRemoteWriteNameEnvVarName, Value: commonmonitoring.RemoteWriteName},
-		{Name: AlloyRemoteWriteBasicAuthUsernameEnvVarName, Value: a.ManagementCluster.Name},
-		{Name: AlloyRemoteWriteBasicAuthPasswordEnvVarName, Value: password},
 	}
 
+	authData, err := a.remoteWriteAuthSecretEnv(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	data = append(data, authData...)
+
 	var values bytes.Buffer
 	err = alloyMonitoringSecretTemplate.Execute(&values, data)
 	if err != nil {
@@ -64,12 +71,52 @@ func (a *Service) GenerateAlloyMonitoringSecretData(ctx context.Context, cluster
 	return secretData, nil
 }
 
+// remoteWriteAuthSecretEnv returns the environment variables holding the credentials of the configured remote write authentication method.
+func (a *Service) remoteWriteAuthSecretEnv(ctx context.Context) ([]secretEnv, error) {
+	if a.MonitoringConfig.RemoteWriteAuthMethod == monitoring.RemoteWriteAuthMethodTLS {
+		tlsSecret := &v1.Secret{}
+		// The secret is read without the cache, like the basic auth credentials, so a renewed certificate is used right away
+		err := a.APIReader.Get(ctx, client.ObjectKey{
+			Name:      a.MonitoringConfig.RemoteWriteTLSSecretName,
+			Namespace: a.MonitoringConfig.MimirNamespace,
+		}, tlsSecret)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		cert, ok := tlsSecret.Data[v1.TLSCertKey]
+		if !ok {
+			return nil, errors.Errorf("%s key not found in secret %s/%s", v1.TLSCertKey, tlsSecret.Namespace, tlsSecret.Name)
+		}
+		key, ok := tlsSecret.Data[v1.TLSPrivateKeyKey]
+		if !ok {
+			return nil, errors.Errorf("%s key not found in secret %s/%s", v1.TLSPrivateKeyKey, tlsSecret.Namespace, tlsSecret.Name)
+		}
+
+		return []secretEnv{
+			{Name: AlloyRemoteWriteTLSCertEnvVarName, Value: string(cert)},
+			{Name: AlloyRemoteWriteTLSKeyEnvVarName, Value: string(key)},
+		}, nil
+	}
+
+	username, password, err := commonmonitoring.GetMimirIngressCredentials(ctx, a.APIReader,
+		a.MonitoringConfig.MimirAuthSecretName, a.MonitoringConfig.MimirNamespace, a.ManagementCluster.Name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return []secretEnv{
+		{Name: AlloyRemoteWriteBasicAuthUsernameEnvVarName, Value: username},
+		{Name: AlloyRemoteWriteBasicAuthPasswordEnvVarName, Value: password},
+	}, nil
+}
+
 func Secret(cluster *clusterv1.Cluster) *v1.Secret {
 	secret := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-%s", cluster.Name, SecretName),
 			Namespace: cluster.Namespace,
-			Labels:    labels.Common,
+			Labels:    maps.Clone(labels.Common),
 		},
 	}
 
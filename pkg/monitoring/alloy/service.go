@@ -5,17 +5,21 @@ import (
 	_ "embed"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/blang/semver"
 	"github.com/pkg/errors"
 
 	"github.com/giantswarm/observability-operator/pkg/common"
+	"github.com/giantswarm/observability-operator/pkg/common/labels"
 	"github.com/giantswarm/observability-operator/pkg/common/organization"
 	"github.com/giantswarm/observability-operator/pkg/common/password"
 	"github.com/giantswarm/observability-operator/pkg/monitoring"
+	"github.com/giantswarm/observability-operator/pkg/monitoring/prometheusagent/sharding"
 )
 
 const (
@@ -25,30 +29,37 @@ const (
 
 type Service struct {
 	client.Client
+	// APIReader reads the Mimir authentication and remote write TLS secrets without going through the cache.
+	APIReader client.Reader
 	organization.OrganizationRepository
 	PasswordManager password.Manager
 	common.ManagementCluster
 	MonitoringConfig monitoring.Config
 }
 
-func (a *Service) ReconcileCreate(ctx context.Context, cluster *clusterv1.Cluster) error {
+// ReconcileCreate ensures the Alloy monitoring configmap and secret of the cluster are up to date.
+// It returns the scaling of the shards which is still pending, for the caller to record on the cluster.
+func (a *Service) ReconcileCreate(ctx context.Context, cluster *clusterv1.Cluster, observabilityBundleVersion semver.Version) (sharding.PendingScaling, error) {
 	logger := log.FromContext(ctx)
 	logger.Info("alloy-service - ensuring alloy is configured")
 
+	var pending sharding.PendingScaling
 	configmap := ConfigMap(cluster)
 	_, err := controllerutil.CreateOrUpdate(ctx, a.Client, configmap, func() error {
-		data, err := a.GenerateAlloyMonitoringConfigMapData(ctx, configmap, cluster)
+		data, pendingScaling, err := a.GenerateAlloyMonitoringConfigMapData(ctx, configmap, cluster, observabilityBundleVersion)
 		if err != nil {
 			logger.Error(err, "alloy-service - failed to generate alloy monitoring configmap")
 			return errors.WithStack(err)
 		}
 		configmap.Data = data
+		pending = pendingScaling
+		ensureLabels(configmap, labels.Common)
 
 		return nil
 	})
 	if err != nil {
 		logger.Error(err, "alloy-service - failed to create or update alloy monitoring configmap")
-		return errors.WithStack(err)
+		return sharding.PendingScaling{}, errors.WithStack(err)
 	}
 
 	secret := Secret(cluster)
@@ -59,17 +70,18 @@ func (a *Service) ReconcileCreate(ctx context.Context, cluster *clusterv1.Cluste
 			return errors.WithStack(err)
 		}
 		secret.Data = data
+		ensureLabels(secret, labels.Common)
 
 		return nil
 	})
 	if err != nil {
 		logger.Error(err, "alloy-service - failed to create or update alloy monitoring secret")
-		return errors.WithStack(err)
+		return sharding.PendingScaling{}, errors.WithStack(err)
 	}
 
 	logger.Info("alloy-service - ensured alloy is configured")
 
-	return nil
+	return pending, nil
 }
 
 func (a *Service) ReconcileDelete(ctx context.Context, cluster *clusterv1.Cluster) error {
@@ -91,3 +103,16 @@ func (a *Service) ReconcileDelete(ctx context.Context, cluster *clusterv1.Cluste
 	logger.Info("alloy-service - ensured alloy is removed")
 	return nil
 }
+
+// ensureLabels restores the desired labels on the object in case they were changed or removed.
+// Labels which are not managed by the operator are kept.
+func ensureLabels(object metav1.Object, desired map[string]string) {
+	current := object.GetLabels()
+	if current == nil {
+		current = make(map[string]string, len(desired))
+	}
+	for key, value := range desired {
+		current[key] = value
+	}
+	object.SetLabels(current)
+}
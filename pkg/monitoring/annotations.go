@@ -0,0 +1,16 @@
+package monitoring
+
+const (
+	// LastReconcileTimeAnnotation is set on the clusters with the time of the last successful monitoring reconciliation which changed the monitoring status.
+	LastReconcileTimeAnnotation = "observability.giantswarm.io/monitoring-last-reconcile-time"
+	// MonitoringAgentAnnotation is set on the clusters with the monitoring agent configured by the operator.
+	MonitoringAgentAnnotation = "observability.giantswarm.io/monitoring-agent"
+	// ReconcileErrorAnnotation is set on the clusters with the error of the last failed monitoring reconciliation.
+	ReconcileErrorAnnotation = "observability.giantswarm.io/monitoring-error"
+	// MonitoringEnabledAnnotation is set on the clusters with whether their monitoring was enabled by the last successful monitoring reconciliation.
+	MonitoringEnabledAnnotation = "observability.giantswarm.io/monitoring-enabled"
+	// MonitoringDisabledTimeAnnotation is set on the clusters with the time their monitoring was disabled, from which the teardown grace period starts.
+	MonitoringDisabledTimeAnnotation = "observability.giantswarm.io/monitoring-disabled-time"
+	// ObservabilityBundleNotFoundAttemptsAnnotation is set on the clusters with the number of consecutive reconciliations which did not find their observability-bundle app.
+	ObservabilityBundleNotFoundAttemptsAnnotation = "observability.giantswarm.io/monitoring-bundle-not-found-attempts"
+)
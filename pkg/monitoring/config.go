@@ -1,37 +1,205 @@
 package monitoring
 
 import (
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 
+	"github.com/giantswarm/observability-operator/pkg/common"
+	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
 	"github.com/giantswarm/observability-operator/pkg/monitoring/prometheusagent/sharding"
 )
 
 const MonitoringLabel = "giantswarm.io/monitoring"
 
+const (
+	MetricRelabelActionDrop = "drop"
+	MetricRelabelActionKeep = "keep"
+)
+
+const (
+	RemoteWriteAuthMethodBasicAuth = "basic-auth"
+	RemoteWriteAuthMethodTLS       = "tls"
+)
+
 // Config represents the configuration used by the monitoring package.
 type Config struct {
 	Enabled bool
+	// UnmonitoredGracePeriod delays the teardown of the monitoring of a cluster once it is disabled, so enabling it again within the period is a no-op.
+	// Monitoring is torn down immediately when it is 0.
+	UnmonitoredGracePeriod time.Duration
+	// SkipObservabilityBundleManagement disables the configuration of the observability-bundle app of the clusters, e.g. when the bundle is configured by another tool.
+	SkipObservabilityBundleManagement bool
+	// ObservabilityBundleNotFoundRequeueAfter is the delay after which clusters whose observability-bundle app is not found yet are reconciled again.
+	ObservabilityBundleNotFoundRequeueAfter time.Duration
+	// ObservabilityBundleNotFoundMaxAttempts is the number of consecutive reconciliations after which clusters without an observability-bundle app are not requeued anymore.
+	// They are requeued until the app is found when it is 0.
+	ObservabilityBundleNotFoundMaxAttempts int
 
 	AlertmanagerSecretName string
-	AlertmanagerURL        string
-	AlertmanagerEnabled    bool
+	// AlertmanagerConfigMapName is the name of the configmap holding the Alertmanager configuration, used instead of the secret when set.
+	AlertmanagerConfigMapName string
+	AlertmanagerURL           string
+	AlertmanagerEnabled       bool
+
+	// HeartbeatInterval is the interval after which the management cluster heartbeat expires if it was not pinged.
+	HeartbeatInterval time.Duration
+	// HeartbeatFailureThreshold is the number of consecutive heartbeat failures after which the failure webhook is notified.
+	HeartbeatFailureThreshold int
+	// HeartbeatRetryCount is the number of times a heartbeat API call failing with a network error or a 5xx response is retried.
+	HeartbeatRetryCount int
+	// HeartbeatOpsgenieRegion is the region of the Opsgenie account the heartbeats are sent to, us or eu.
+	HeartbeatOpsgenieRegion string
+
+	// MimirNamespace is the namespace where Mimir is deployed.
+	MimirNamespace string
+	// MimirAuthSecretName is the name of the secret holding the password the monitoring agents use to authenticate against Mimir.
+	MimirAuthSecretName string
+	// MimirIngressAuthSecretName is the name of the secret holding the htpasswd used by the Mimir gateway ingress.
+	MimirIngressAuthSecretName string
+	// MimirPasswordMaxAge is the age after which the Mimir password is rotated. Rotation is disabled when it is 0.
+	MimirPasswordMaxAge time.Duration
+	// MimirPasswordRotationGracePeriod is how long the ingress keeps accepting the previous Mimir password after a rotation.
+	MimirPasswordRotationGracePeriod time.Duration
+	// MimirRuntimeOverridesConfigMapName is the name of the Mimir runtime overrides configmap. The overrides are not managed when it is empty.
+	MimirRuntimeOverridesConfigMapName string
+	// MimirRuntimeOverrides are the limits merged into the Mimir runtime overrides, indexed by tenant.
+	MimirRuntimeOverrides map[string]map[string]any
+
+	// RemoteWriteAuthMethod is the method the Alloy monitoring agents use to authenticate against Mimir, basic-auth or tls.
+	RemoteWriteAuthMethod string
+	// RemoteWriteTLSSecretName is the name of the kubernetes.io/tls secret in the Mimir namespace holding the client certificate used with the tls authentication method.
+	RemoteWriteTLSSecretName string
+
+	// DefaultWriteTenant is the tenant the monitoring agents write metrics to.
+	DefaultWriteTenant string
+	// ManagementClusterWriteTenant is the tenant the monitoring agent of the management cluster writes metrics to, isolating its self-monitoring. Defaults to DefaultWriteTenant.
+	ManagementClusterWriteTenant string
+	// OrgIDHeader is the name of the header carrying the Mimir tenant, gateways in front of Mimir can expect another name than X-Scope-OrgID.
+	OrgIDHeader string
+	// RemoteWriteHeaders are static headers added to the remote write requests of the monitoring agents, e.g. for routing in a gateway in front of Mimir.
+	RemoteWriteHeaders map[string]string
+
+	// OTLPReceiverEnabled enables the OTLP receiver in the Alloy monitoring agent so applications can push metrics to it.
+	OTLPReceiverEnabled bool
+	// OTLPReceiverGRPCPort is the port the OTLP receiver listens on for gRPC.
+	OTLPReceiverGRPCPort int
+	// OTLPReceiverHTTPPort is the port the OTLP receiver listens on for HTTP.
+	OTLPReceiverHTTPPort int
+
+	// ClusterProviders maps the kinds of the infrastructure of the clusters to their provider, on top of the kinds supported by default.
+	ClusterProviders map[string]string
+	// ExternalLabelsFromClusterLabels maps the cluster labels copied into the external labels of the monitoring agents to the name of the external label.
+	ExternalLabelsFromClusterLabels map[string]string
+	// MetricRelabelRules are the rules applied in order to the metric names before the monitoring agents send them to Mimir.
+	MetricRelabelRules []MetricRelabelRule
 
 	MonitoringAgent         string
 	DefaultShardingStrategy sharding.Strategy
 	// WALTruncateFrequency is the frequency at which the WAL segments should be truncated.
 	WALTruncateFrequency time.Duration
+	// QueueConfigSampleAgeLimit overrides the remote write sample age limit derived from the number of shards when set.
+	QueueConfigSampleAgeLimit time.Duration
+	// QueueConfigBatchSendDeadline overrides the remote write batch send deadline derived from the number of shards when set.
+	QueueConfigBatchSendDeadline time.Duration
+	// AlloyConfigDebugEndpointEnabled serves the Alloy configuration generated for a cluster on the metrics address for debugging purposes.
+	AlloyConfigDebugEndpointEnabled bool
+	// ScrapeTimeout is the scrape timeout of the Alloy monitoring agent. The default of Alloy is used when it is 0.
+	ScrapeTimeout time.Duration
+	// DroppedScrapeJobs are the names of the scrape jobs dropped by the Alloy monitoring agent.
+	DroppedScrapeJobs []string
 	// TODO(atlas): validate prometheus version using SemVer
 	PrometheusVersion string
 	MetricsQueryURL   string
 }
 
-// Monitoring should be enabled when all conditions are met:
+// MetricRelabelRule drops or keeps the metrics whose name matches the regex.
+type MetricRelabelRule struct {
+	Action string `json:"action"`
+	Regex  string `json:"regex"`
+}
+
+// Validate ensures the action is supported and the regex compiles.
+func (r MetricRelabelRule) Validate() error {
+	if !slices.Contains([]string{MetricRelabelActionDrop, MetricRelabelActionKeep}, r.Action) {
+		return errors.Errorf("unsupported metric relabel action %q, must be %s or %s", r.Action, MetricRelabelActionDrop, MetricRelabelActionKeep)
+	}
+	if _, err := regexp.Compile(r.Regex); err != nil {
+		return errors.Wrapf(err, "invalid metric relabel regex %q", r.Regex)
+	}
+	return nil
+}
+
+// ValidateRemoteWriteAuth ensures exactly one remote write authentication method is configured.
+func (c Config) ValidateRemoteWriteAuth() error {
+	switch c.RemoteWriteAuthMethod {
+	case RemoteWriteAuthMethodBasicAuth:
+		if c.RemoteWriteTLSSecretName != "" {
+			return errors.Errorf("remote write TLS secret %q cannot be used with the %s authentication method", c.RemoteWriteTLSSecretName, RemoteWriteAuthMethodBasicAuth)
+		}
+	case RemoteWriteAuthMethodTLS:
+		if c.RemoteWriteTLSSecretName == "" {
+			return errors.Errorf("remote write TLS secret name is required by the %s authentication method", RemoteWriteAuthMethodTLS)
+		}
+	default:
+		return errors.Errorf("unsupported remote write authentication method %q, must be %s or %s", c.RemoteWriteAuthMethod, RemoteWriteAuthMethodBasicAuth, RemoteWriteAuthMethodTLS)
+	}
+	return c.ValidateMonitoringAgentRemoteWriteAuth(c.MonitoringAgent)
+}
+
+// ValidateMonitoringAgentRemoteWriteAuth ensures the monitoring agent supports the remote write authentication method.
+// prometheus-agent only authenticates with basic auth.
+func (c Config) ValidateMonitoringAgentRemoteWriteAuth(monitoringAgent string) error {
+	if c.RemoteWriteAuthMethod == RemoteWriteAuthMethodTLS && monitoringAgent == commonmonitoring.MonitoringAgentPrometheus {
+		return errors.Errorf("the %s remote write authentication method is not supported by %s", RemoteWriteAuthMethodTLS, commonmonitoring.MonitoringAgentPrometheus)
+	}
+	return nil
+}
+
+// ValidateScrapeTimeout ensures the configured scrape timeout does not exceed the scrape interval.
+func (c Config) ValidateScrapeTimeout() error {
+	interval, err := time.ParseDuration(commonmonitoring.ScrapeInterval)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if c.ScrapeTimeout < 0 || c.ScrapeTimeout > interval {
+		return errors.Errorf("scrape timeout %s must be between 0 and the scrape interval %s", c.ScrapeTimeout, interval)
+	}
+	return nil
+}
+
+// IsMonitored returns true when the monitoring of the cluster is requested or was disabled less than the grace period ago.
+func (c Config) IsMonitored(cluster *clusterv1.Cluster) bool {
+	return c.IsMonitoringRequested(cluster) || (c.Enabled && c.UnmonitoredGracePeriodRemaining(cluster) > 0)
+}
+
+// UnmonitoredGracePeriodRemaining returns the time left before the monitoring of the cluster is torn down since it was disabled.
+func (c Config) UnmonitoredGracePeriodRemaining(cluster *clusterv1.Cluster) time.Duration {
+	if c.UnmonitoredGracePeriod <= 0 {
+		return 0
+	}
+
+	value, ok := cluster.GetAnnotations()[MonitoringDisabledTimeAnnotation]
+	if !ok {
+		return 0
+	}
+	disabledTime, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0
+	}
+
+	return max(time.Until(disabledTime.Add(c.UnmonitoredGracePeriod)), 0)
+}
+
+// Monitoring is requested when all conditions are met:
 //   - global monitoring flag is enabled
 //   - monitoring label is not set or is set to true on the cluster object
-func (c Config) IsMonitored(cluster *clusterv1.Cluster) bool {
+func (c Config) IsMonitoringRequested(cluster *clusterv1.Cluster) bool {
 	if !c.Enabled {
 		return false
 	}
@@ -50,3 +218,111 @@ func (c Config) IsMonitored(cluster *clusterv1.Cluster) bool {
 	}
 	return monitoringEnabled
 }
+
+// OrgIDHeaderName returns the name of the header carrying the Mimir tenant, X-Scope-OrgID when it is not configured.
+func (c Config) OrgIDHeaderName() string {
+	if c.OrgIDHeader == "" {
+		return commonmonitoring.OrgIDHeader
+	}
+	return c.OrgIDHeader
+}
+
+// ValidateRemoteWriteHeaders ensures the static remote write headers do not override the tenant header.
+// Header names are case-insensitive so they are compared regardless of their case.
+func (c Config) ValidateRemoteWriteHeaders() error {
+	for name := range c.RemoteWriteHeaders {
+		if strings.EqualFold(name, c.OrgIDHeaderName()) {
+			return errors.Errorf("remote write header %q overrides the tenant header %q", name, c.OrgIDHeaderName())
+		}
+	}
+	return nil
+}
+
+// QueueConfigTier returns the remote write queue settings of a cluster running the given number of shards.
+// The sample age limit and batch send deadline configured explicitly take precedence over the ones of the tier.
+func (c Config) QueueConfigTier(shards int) commonmonitoring.QueueConfigTier {
+	tier := commonmonitoring.GetQueueConfigTier(shards)
+	if c.QueueConfigSampleAgeLimit > 0 {
+		tier.SampleAgeLimit = c.QueueConfigSampleAgeLimit
+	}
+	if c.QueueConfigBatchSendDeadline > 0 {
+		tier.BatchSendDeadline = c.QueueConfigBatchSendDeadline
+	}
+	return tier
+}
+
+// ClusterScrapeTimeout returns the scrape timeout of the cluster, the one set on the cluster annotation takes precedence over the configured one.
+// The scrape timeout cannot exceed the scrape interval.
+func (c Config) ClusterScrapeTimeout(cluster *clusterv1.Cluster) (time.Duration, error) {
+	timeout, err := commonmonitoring.GetClusterScrapeTimeout(cluster)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s annotation", commonmonitoring.ScrapeTimeoutAnnotation)
+	}
+	if timeout == 0 {
+		timeout = c.ScrapeTimeout
+	}
+
+	interval, err := time.ParseDuration(commonmonitoring.ScrapeInterval)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if timeout > interval {
+		return 0, errors.Errorf("scrape timeout %s exceeds the scrape interval %s", timeout, interval)
+	}
+
+	return timeout, nil
+}
+
+// ClusterMonitoringAgent returns the monitoring agent of the cluster, the one set on the cluster annotation takes precedence over the configured one.
+func (c Config) ClusterMonitoringAgent(cluster *clusterv1.Cluster) (string, error) {
+	monitoringAgent, ok := cluster.GetAnnotations()[commonmonitoring.MonitoringAgentOverrideAnnotation]
+	if !ok {
+		return c.MonitoringAgent, nil
+	}
+
+	switch monitoringAgent {
+	case commonmonitoring.MonitoringAgentPrometheus, commonmonitoring.MonitoringAgentAlloy:
+		return monitoringAgent, nil
+	default:
+		return "", errors.Errorf("invalid %s annotation: unsupported monitoring agent %q", commonmonitoring.MonitoringAgentOverrideAnnotation, monitoringAgent)
+	}
+}
+
+// ClusterDroppedScrapeJobs returns the scrape jobs dropped for the cluster, the ones set on the cluster annotation take precedence over the configured ones.
+func (c Config) ClusterDroppedScrapeJobs(cluster *clusterv1.Cluster) []string {
+	if jobs, ok := commonmonitoring.GetClusterDroppedScrapeJobs(cluster); ok {
+		return jobs
+	}
+	return c.DroppedScrapeJobs
+}
+
+// ClusterWriteTenant returns the tenant the monitoring agent of the cluster writes metrics to.
+func (c Config) ClusterWriteTenant(cluster *clusterv1.Cluster, managementCluster common.ManagementCluster) string {
+	if cluster.Name == managementCluster.Name && c.ManagementClusterWriteTenant != "" {
+		return c.ManagementClusterWriteTenant
+	}
+	return c.DefaultWriteTenant
+}
+
+// ClusterProvider returns the provider of the cluster, derived from the kind of its infrastructure.
+// The configured providers take precedence over the default ones.
+func (c Config) ClusterProvider(cluster *clusterv1.Cluster) (string, error) {
+	if cluster.Spec.InfrastructureRef != nil {
+		if provider, ok := c.ClusterProviders[cluster.Spec.InfrastructureRef.Kind]; ok {
+			return provider, nil
+		}
+	}
+	return common.GetClusterProvider(cluster)
+}
+
+// ClusterExternalLabels returns the external labels copied from the labels of the cluster.
+// Cluster labels which are not set are skipped.
+func (c Config) ClusterExternalLabels(cluster *clusterv1.Cluster) map[string]string {
+	externalLabels := make(map[string]string)
+	for clusterLabel, externalLabel := range c.ExternalLabelsFromClusterLabels {
+		if value, ok := cluster.GetLabels()[clusterLabel]; ok {
+			externalLabels[externalLabel] = value
+		}
+	}
+	return externalLabels
+}
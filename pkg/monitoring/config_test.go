@@ -0,0 +1,126 @@
+package monitoring
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"github.com/giantswarm/observability-operator/pkg/common"
+	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
+)
+
+func TestValidateRemoteWriteAuth(t *testing.T) {
+	tests := []struct {
+		name            string
+		authMethod      string
+		tlsSecretName   string
+		monitoringAgent string
+		expectError     bool
+	}{
+		{
+			name:       "basic auth",
+			authMethod: RemoteWriteAuthMethodBasicAuth,
+		},
+		{
+			name:            "basic auth with prometheus-agent",
+			authMethod:      RemoteWriteAuthMethodBasicAuth,
+			monitoringAgent: commonmonitoring.MonitoringAgentPrometheus,
+		},
+		{
+			name:          "tls client certificate",
+			authMethod:    RemoteWriteAuthMethodTLS,
+			tlsSecretName: "mimir-client-certificate",
+		},
+		{
+			name:            "tls client certificate with prometheus-agent",
+			authMethod:      RemoteWriteAuthMethodTLS,
+			tlsSecretName:   "mimir-client-certificate",
+			monitoringAgent: commonmonitoring.MonitoringAgentPrometheus,
+			expectError:     true,
+		},
+		{
+			name:          "basic auth with a tls secret",
+			authMethod:    RemoteWriteAuthMethodBasicAuth,
+			tlsSecretName: "mimir-client-certificate",
+			expectError:   true,
+		},
+		{
+			name:        "tls without a secret",
+			authMethod:  RemoteWriteAuthMethodTLS,
+			expectError: true,
+		},
+		{
+			name:        "unsupported method",
+			authMethod:  "oauth2",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{MonitoringAgent: tt.monitoringAgent, RemoteWriteAuthMethod: tt.authMethod, RemoteWriteTLSSecretName: tt.tlsSecretName}
+
+			err := c.ValidateRemoteWriteAuth()
+			if tt.expectError && err == nil {
+				t.Error("expected an error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestClusterProvider(t *testing.T) {
+	tests := []struct {
+		name             string
+		kind             string
+		clusterProviders map[string]string
+		expected         string
+		expectError      bool
+	}{
+		{
+			name:     "default kind",
+			kind:     common.AWSClusterKind,
+			expected: common.AWSClusterKindProvider,
+		},
+		{
+			name:             "custom kind",
+			kind:             "MetalCluster",
+			clusterProviders: map[string]string{"MetalCluster": "metal"},
+			expected:         "metal",
+		},
+		{
+			name:             "default kind mapped to another provider",
+			kind:             common.VSphereClusterKind,
+			clusterProviders: map[string]string{common.VSphereClusterKind: "on-prem"},
+			expected:         "on-prem",
+		},
+		{
+			name:        "unknown kind",
+			kind:        "MetalCluster",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{ClusterProviders: tt.clusterProviders}
+			cluster := &clusterv1.Cluster{
+				Spec: clusterv1.ClusterSpec{InfrastructureRef: &v1.ObjectReference{Kind: tt.kind}},
+			}
+
+			provider, err := c.ClusterProvider(cluster)
+			if tt.expectError && err == nil {
+				t.Error("expected an error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if provider != tt.expected {
+				t.Errorf("expected provider %q, got %q", tt.expected, provider)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package heartbeat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/observability-operator/pkg/common"
+)
+
+// DefaultFailureThreshold is the default number of consecutive heartbeat failures after which a notification is sent.
+const DefaultFailureThreshold = 3
+
+// Notification is the alert sent when the heartbeat could not be configured repeatedly.
+type Notification struct {
+	Installation        string `json:"installation"`
+	Pipeline            string `json:"pipeline"`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	Error               string `json:"error"`
+	// Text is a human readable summary of the failure, compatible with Slack incoming webhooks.
+	Text string `json:"text"`
+}
+
+// Notifier is the interface for the sinks notified about repeated heartbeat failures.
+type Notifier interface {
+	// Notify sends the notification to the sink.
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// WebhookNotifier is a Notifier posting the notification as JSON to a webhook URL.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier creates a new WebhookNotifier.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook returned unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// NotifyingHeartbeatRepository wraps a HeartbeatRepository and notifies when the heartbeat fails to be created or updated
+// for Threshold consecutive times. It notifies once per failure streak, the streak is reset by a successful call.
+type NotifyingHeartbeatRepository struct {
+	HeartbeatRepository
+	common.ManagementCluster
+	Notifier  Notifier
+	Threshold int
+
+	mu                  sync.Mutex
+	consecutiveFailures int
+}
+
+// NewNotifyingHeartbeatRepository creates a new NotifyingHeartbeatRepository.
+func NewNotifyingHeartbeatRepository(repository HeartbeatRepository, mc common.ManagementCluster, notifier Notifier, threshold int) (*NotifyingHeartbeatRepository, error) {
+	if threshold < 1 {
+		return nil, errors.Errorf("heartbeat failure threshold must be at least 1, got %d", threshold)
+	}
+
+	return &NotifyingHeartbeatRepository{
+		HeartbeatRepository: repository,
+		ManagementCluster:   mc,
+		Notifier:            notifier,
+		Threshold:           threshold,
+	}, nil
+}
+
+func (r *NotifyingHeartbeatRepository) CreateOrUpdate(ctx context.Context) error {
+	err := r.HeartbeatRepository.CreateOrUpdate(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		r.consecutiveFailures = 0
+		return nil
+	}
+
+	r.consecutiveFailures++
+	if r.consecutiveFailures == r.Threshold {
+		r.notify(ctx, err)
+	}
+
+	return err
+}
+
+// notify sends the failure notification. Notification errors are only logged so they do not hide the heartbeat error.
+func (r *NotifyingHeartbeatRepository) notify(ctx context.Context, heartbeatErr error) {
+	logger := log.FromContext(ctx)
+
+	notification := Notification{
+		Installation:        r.ManagementCluster.Name,
+		Pipeline:            r.ManagementCluster.Pipeline,
+		ConsecutiveFailures: r.consecutiveFailures,
+		Error:               heartbeatErr.Error(),
+	}
+	notification.Text = fmt.Sprintf("Heartbeat for installation %s failed to be configured %d times in a row: %s",
+		notification.Installation, notification.ConsecutiveFailures, notification.Error)
+
+	logger.Info("notifying about heartbeat failures", "failures", r.consecutiveFailures)
+	if err := r.Notifier.Notify(ctx, notification); err != nil {
+		logger.Error(err, "failed to notify about heartbeat failures")
+	}
+}
@@ -0,0 +1,82 @@
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/giantswarm/observability-operator/pkg/common"
+)
+
+type fakeHeartbeatRepository struct {
+	HeartbeatRepository
+	err error
+}
+
+func (f *fakeHeartbeatRepository) CreateOrUpdate(ctx context.Context) error {
+	return f.err
+}
+
+func TestNotifyingHeartbeatRepository(t *testing.T) {
+	managementCluster := common.ManagementCluster{
+		Name:     "test-installation",
+		Pipeline: "testing",
+	}
+
+	var received []Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification Notification
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			t.Errorf("failed to decode notification: %v", err)
+		}
+		received = append(received, notification)
+	}))
+	defer server.Close()
+
+	fake := &fakeHeartbeatRepository{err: errors.New("opsgenie is down")}
+	repository, err := NewNotifyingHeartbeatRepository(fake, managementCluster, NewWebhookNotifier(server.URL), 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 1; i < 3; i++ {
+		if err := repository.CreateOrUpdate(context.Background()); err == nil {
+			t.Fatalf("expected heartbeat error")
+		}
+		if len(received) != 0 {
+			t.Fatalf("expected no notification after %d failures, got %d", i, len(received))
+		}
+	}
+
+	if err := repository.CreateOrUpdate(context.Background()); err == nil {
+		t.Fatalf("expected heartbeat error")
+	}
+	if len(received) != 1 {
+		t.Fatalf("expected 1 notification after reaching the threshold, got %d", len(received))
+	}
+	if received[0].Installation != managementCluster.Name || received[0].ConsecutiveFailures != 3 || received[0].Error != "opsgenie is down" {
+		t.Errorf("unexpected notification %+v", received[0])
+	}
+
+	// Further failures of the same streak do not notify again.
+	_ = repository.CreateOrUpdate(context.Background())
+	if len(received) != 1 {
+		t.Fatalf("expected no new notification past the threshold, got %d", len(received))
+	}
+
+	// A success resets the streak.
+	fake.err = nil
+	if err := repository.CreateOrUpdate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fake.err = errors.New("opsgenie is down again")
+	for i := 0; i < 3; i++ {
+		_ = repository.CreateOrUpdate(context.Background())
+	}
+	if len(received) != 2 {
+		t.Fatalf("expected a new notification after a new failure streak, got %d", len(received))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"reflect"
 	"sort"
+	"time"
 
 	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
 	"github.com/opsgenie/opsgenie-go-sdk-v2/heartbeat"
@@ -17,23 +18,96 @@ import (
 	"github.com/giantswarm/observability-operator/pkg/common"
 )
 
+// DefaultInterval is the default interval after which a heartbeat expires if it was not pinged.
+const DefaultInterval = 60 * time.Minute
+
+// DefaultRetryCount is the default number of times a failed Opsgenie API call is retried.
+const DefaultRetryCount = 3
+
+const (
+	// OpsgenieRegionUS is the region of the Opsgenie accounts hosted in the US.
+	OpsgenieRegionUS = "us"
+	// OpsgenieRegionEU is the region of the Opsgenie accounts hosted in the EU.
+	OpsgenieRegionEU = "eu"
+)
+
+// opsgenieAPIURLs are the hosts of the Opsgenie API, indexed by region.
+var opsgenieAPIURLs = map[string]client.ApiUrl{
+	OpsgenieRegionUS: client.API_URL,
+	OpsgenieRegionEU: client.API_URL_EU,
+}
+
 // OpsgenieHeartbeatRepository is a repository for managing heartbeats in Opsgenie.
 type OpsgenieHeartbeatRepository struct {
 	*heartbeat.Client
 	common.ManagementCluster
+	// Interval is the interval after which the heartbeat expires if it was not pinged.
+	Interval time.Duration
+}
+
+// NewOpsgenieHeartbeatRepository creates a new OpsgenieHeartbeatRepository calling the Opsgenie API of the given region.
+// Opsgenie API calls failing with a network error or a 5xx response are retried up to retryCount times with an exponential backoff, 0 disables the retries.
+func NewOpsgenieHeartbeatRepository(apiKey string, region string, mc common.ManagementCluster, interval time.Duration, retryCount int) (HeartbeatRepository, error) {
+	c, err := newOpsgenieConfig(apiKey, region, retryCount)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return newOpsgenieHeartbeatRepository(c, mc, interval)
 }
 
-// NewOpsgenieHeartbeatRepository creates a new OpsgenieHeartbeatRepository.
-func NewOpsgenieHeartbeatRepository(apiKey string, mc common.ManagementCluster) (HeartbeatRepository, error) {
-	c := &client.Config{
+func newOpsgenieConfig(apiKey string, region string, retryCount int) (*client.Config, error) {
+	if retryCount < 0 {
+		return nil, errors.Errorf("heartbeat retry count must not be negative, got %d", retryCount)
+	}
+
+	// The Opsgenie client replaces a retry count of 0 with its own default, so retries are disabled through the policy instead
+	policy := retryPolicy
+	if retryCount == 0 {
+		policy = noRetryPolicy
+	}
+
+	apiURL, ok := opsgenieAPIURLs[region]
+	if !ok {
+		return nil, errors.Errorf("unsupported opsgenie region %q, must be %s or %s", region, OpsgenieRegionUS, OpsgenieRegionEU)
+	}
+
+	return &client.Config{
 		ApiKey:         apiKey,
-		OpsGenieAPIURL: client.API_URL,
-		RetryCount:     1,
+		OpsGenieAPIURL: apiURL,
+		RetryCount:     retryCount,
+		RetryPolicy:    policy,
 		LogLevel:       logrus.FatalLevel,
+	}, nil
+}
+
+func newOpsgenieHeartbeatRepository(c *client.Config, mc common.ManagementCluster, interval time.Duration) (HeartbeatRepository, error) {
+	if interval == 0 {
+		interval = DefaultInterval
+	} else if interval < time.Minute {
+		return nil, errors.Errorf("heartbeat interval must be at least one minute, got %s", interval)
 	}
 
 	client, err := heartbeat.NewClient(c)
-	return &OpsgenieHeartbeatRepository{client, mc}, err
+	return &OpsgenieHeartbeatRepository{client, mc, interval}, err
+}
+
+// retryPolicy retries Opsgenie API calls on network errors and 5xx responses only.
+// Unlike the default policy of the Opsgenie client, 4xx responses (including 429) are never retried.
+func retryPolicy(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if err != nil {
+		return true, err
+	}
+
+	return resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusNotImplemented, nil
+}
+
+// noRetryPolicy never retries Opsgenie API calls.
+func noRetryPolicy(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	return false, nil
 }
 
 // makeHeartbeat creates a new heartbeat for the management cluster.
@@ -50,7 +124,7 @@ func (r OpsgenieHeartbeatRepository) makeHeartbeat() *heartbeat.Heartbeat {
 	return &heartbeat.Heartbeat{
 		Name:         r.ManagementCluster.Name,
 		Description:  "📗 Runbook: https://intranet.giantswarm.io/docs/support-and-ops/ops-recipes/heartbeat-expired/",
-		Interval:     60,
+		Interval:     int(r.Interval.Minutes()),
 		IntervalUnit: string(heartbeat.Minutes),
 		Enabled:      true,
 		Expired:      false,
@@ -0,0 +1,243 @@
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opsgenie/opsgenie-go-sdk-v2/client"
+
+	"github.com/giantswarm/observability-operator/pkg/common"
+)
+
+func TestMakeHeartbeat(t *testing.T) {
+	managementCluster := common.ManagementCluster{
+		Name:     "test-installation",
+		Pipeline: "testing",
+	}
+
+	tests := []struct {
+		name             string
+		interval         time.Duration
+		expectedInterval int
+	}{
+		{
+			name:             "default interval",
+			interval:         DefaultInterval,
+			expectedInterval: 60,
+		},
+		{
+			name:             "custom interval",
+			interval:         3 * time.Hour,
+			expectedInterval: 180,
+		},
+		{
+			name:             "custom interval is rounded down to the minute",
+			interval:         90*time.Second + 5*time.Minute,
+			expectedInterval: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := OpsgenieHeartbeatRepository{
+				ManagementCluster: managementCluster,
+				Interval:          tt.interval,
+			}
+
+			hb := repository.makeHeartbeat()
+			if hb.Interval != tt.expectedInterval {
+				t.Errorf("expected interval %d, got %d", tt.expectedInterval, hb.Interval)
+			}
+			if hb.Name != managementCluster.Name {
+				t.Errorf("expected name %q, got %q", managementCluster.Name, hb.Name)
+			}
+		})
+	}
+}
+
+func TestHasChanged(t *testing.T) {
+	managementCluster := common.ManagementCluster{
+		Name:     "test-installation",
+		Pipeline: "testing",
+	}
+
+	current := OpsgenieHeartbeatRepository{ManagementCluster: managementCluster, Interval: DefaultInterval}.makeHeartbeat()
+	current.Enabled = false
+	current.Expired = true
+	current.OwnerTeam.Id = "team-id"
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		expected bool
+	}{
+		{
+			name:     "same interval",
+			interval: DefaultInterval,
+			expected: false,
+		},
+		{
+			name:     "custom interval",
+			interval: 2 * time.Hour,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := OpsgenieHeartbeatRepository{ManagementCluster: managementCluster, Interval: tt.interval}.makeHeartbeat()
+
+			if result := hasChanged(*current, *desired); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestOpsgenieHeartbeatRepositoryRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		retryCount       int
+		statuses         []int
+		expectedError    bool
+		expectedAttempts int
+	}{
+		{
+			name:             "server error is retried",
+			retryCount:       DefaultRetryCount,
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedAttempts: 2,
+		},
+		{
+			name:             "client error fails immediately",
+			retryCount:       DefaultRetryCount,
+			statuses:         []int{http.StatusBadRequest, http.StatusOK},
+			expectedError:    true,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "server error is not retried when retries are disabled",
+			retryCount:       0,
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedError:    true,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[attempts]
+				attempts++
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					_, _ = w.Write([]byte(`{"data":{"name":"test-installation"},"took":0.1,"requestId":"id"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"message":"error","took":0.1,"requestId":"id"}`))
+			}))
+			defer server.Close()
+
+			c, err := newOpsgenieConfig("api-key", OpsgenieRegionUS, tt.retryCount)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			c.OpsGenieAPIURL = client.ApiUrl(strings.TrimPrefix(server.URL, "http://"))
+			c.Backoff = func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+				return 0
+			}
+
+			r, err := newOpsgenieHeartbeatRepository(c, common.ManagementCluster{Name: "test-installation"}, DefaultInterval)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err = r.(*OpsgenieHeartbeatRepository).Client.Get(context.Background(), "test-installation")
+			if tt.expectedError && err == nil {
+				t.Errorf("expected an error")
+			} else if !tt.expectedError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if attempts != tt.expectedAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.expectedAttempts, attempts)
+			}
+		})
+	}
+}
+
+// recordingTransport records the hosts of the requests and fails them so no request leaves the test.
+type recordingTransport struct {
+	hosts []string
+}
+
+func (t *recordingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.hosts = append(t.hosts, r.URL.Host)
+	return nil, errors.New("request recorded")
+}
+
+func TestOpsgenieHeartbeatRepositoryRegion(t *testing.T) {
+	tests := []struct {
+		name          string
+		region        string
+		expectedHost  string
+		expectedError bool
+	}{
+		{
+			name:         "us region",
+			region:       OpsgenieRegionUS,
+			expectedHost: "api.opsgenie.com",
+		},
+		{
+			name:         "eu region",
+			region:       OpsgenieRegionEU,
+			expectedHost: "api.eu.opsgenie.com",
+		},
+		{
+			name:          "unsupported region",
+			region:        "apac",
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newOpsgenieConfig("api-key", tt.region, 1)
+			if tt.expectedError {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			transport := &recordingTransport{}
+			c.HttpClient = &http.Client{Transport: transport}
+			c.Backoff = func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+				return 0
+			}
+
+			r, err := newOpsgenieHeartbeatRepository(c, common.ManagementCluster{Name: "test-installation"}, DefaultInterval)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, _ = r.(*OpsgenieHeartbeatRepository).Client.Get(context.Background(), "test-installation")
+			if len(transport.hosts) == 0 {
+				t.Fatal("expected a request to the opsgenie API")
+			}
+			for _, host := range transport.hosts {
+				if host != tt.expectedHost {
+					t.Errorf("expected requests to %s, got %s", tt.expectedHost, host)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package mimir
+
+import (
+	"context"
+	"maps"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// RuntimeOverridesConfigMapKey is the key of the runtime configuration in the Mimir runtime overrides configmap.
+	RuntimeOverridesConfigMapKey = "runtime.yaml"
+)
+
+// ConfigureRuntimeOverrides merges the per-tenant limits managed by the operator into the Mimir runtime overrides configmap.
+// The configmap is owned by the Mimir deployment so it is only updated and never created, and it is skipped when it does not exist.
+// A Helm upgrade of Mimir resets the configmap, the overrides are merged again on the next reconciliation of the management cluster.
+func (ms *MimirService) ConfigureRuntimeOverrides(ctx context.Context) error {
+	if ms.MonitoringConfig.MimirRuntimeOverridesConfigMapName == "" || len(ms.MonitoringConfig.MimirRuntimeOverrides) == 0 {
+		return nil
+	}
+
+	logger := log.FromContext(ctx)
+	logger.Info("configuring mimir runtime overrides")
+
+	configMap := &corev1.ConfigMap{}
+	err := ms.Client.Get(ctx, client.ObjectKey{
+		Name:      ms.MonitoringConfig.MimirRuntimeOverridesConfigMapName,
+		Namespace: ms.MonitoringConfig.MimirNamespace,
+	}, configMap)
+	if apierrors.IsNotFound(err) {
+		// Mimir may not be deployed yet, the overrides are merged once the configmap exists.
+		logger.Info("mimir runtime overrides configmap not found, skipping", "configmap", ms.MonitoringConfig.MimirRuntimeOverridesConfigMapName)
+		return nil
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	runtimeConfig, err := mergeRuntimeOverrides(configMap.Data[RuntimeOverridesConfigMapKey], ms.MonitoringConfig.MimirRuntimeOverrides)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if configMap.Data[RuntimeOverridesConfigMapKey] == runtimeConfig {
+		logger.Info("mimir runtime overrides are up to date")
+		return nil
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[RuntimeOverridesConfigMapKey] = runtimeConfig
+	err = ms.Client.Update(ctx, configMap)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	logger.Info("configured mimir runtime overrides")
+
+	return nil
+}
+
+// mergeRuntimeOverrides merges the managed per-tenant limits into the current runtime configuration.
+// Tenants, limits and top-level settings which are not managed by the operator are preserved.
+func mergeRuntimeOverrides(current string, managed map[string]map[string]any) (string, error) {
+	runtimeConfig := map[string]any{}
+	if err := yaml.Unmarshal([]byte(current), &runtimeConfig); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if runtimeConfig == nil {
+		runtimeConfig = map[string]any{}
+	}
+
+	overrides, ok := runtimeConfig["overrides"].(map[string]any)
+	if !ok || overrides == nil {
+		overrides = map[string]any{}
+	}
+
+	for tenant, limits := range managed {
+		tenantLimits, ok := overrides[tenant].(map[string]any)
+		if !ok || tenantLimits == nil {
+			tenantLimits = map[string]any{}
+		}
+		maps.Copy(tenantLimits, limits)
+		overrides[tenant] = tenantLimits
+	}
+	runtimeConfig["overrides"] = overrides
+
+	merged, err := yaml.Marshal(runtimeConfig)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return string(merged), nil
+}
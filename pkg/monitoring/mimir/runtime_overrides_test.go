@@ -0,0 +1,156 @@
+package mimir
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/observability-operator/pkg/monitoring"
+)
+
+func TestMergeRuntimeOverrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		managed  map[string]map[string]any
+		expected map[string]any
+	}{
+		{
+			name:    "empty runtime configuration",
+			current: "",
+			managed: map[string]map[string]any{
+				"giantswarm": {"ingestion_rate": 100000},
+			},
+			expected: map[string]any{
+				"overrides": map[string]any{
+					"giantswarm": map[string]any{"ingestion_rate": float64(100000)},
+				},
+			},
+		},
+		{
+			name: "unmanaged tenants, limits and settings are preserved",
+			current: `
+multi_kv_config:
+  primary: consul
+overrides:
+  giantswarm:
+    ingestion_rate: 50000
+    max_global_series_per_user: 1000000
+  other:
+    ingestion_rate: 10000
+`,
+			managed: map[string]map[string]any{
+				"giantswarm": {"ingestion_rate": 100000},
+				"new":        {"ingestion_burst_size": 200000},
+			},
+			expected: map[string]any{
+				"multi_kv_config": map[string]any{"primary": "consul"},
+				"overrides": map[string]any{
+					"giantswarm": map[string]any{
+						"ingestion_rate":             float64(100000),
+						"max_global_series_per_user": float64(1000000),
+					},
+					"other": map[string]any{"ingestion_rate": float64(10000)},
+					"new":   map[string]any{"ingestion_burst_size": float64(200000)},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := mergeRuntimeOverrides(tt.current, tt.managed)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got map[string]any
+			if err := yaml.Unmarshal([]byte(merged), &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestConfigureRuntimeOverrides(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "mimir-runtime", Namespace: DefaultNamespace},
+		Data: map[string]string{
+			RuntimeOverridesConfigMapKey: "overrides:\n  other:\n    ingestion_rate: 10000\n",
+			"other.yaml":                 "unmanaged",
+		},
+	}
+
+	ms := MimirService{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap).Build(),
+		MonitoringConfig: monitoring.Config{
+			MimirNamespace:                     DefaultNamespace,
+			MimirRuntimeOverridesConfigMapName: "mimir-runtime",
+			MimirRuntimeOverrides: map[string]map[string]any{
+				"giantswarm": {"ingestion_rate": 100000},
+			},
+		},
+	}
+
+	if err := ms.ConfigureRuntimeOverrides(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	current := &corev1.ConfigMap{}
+	if err := ms.Client.Get(context.Background(), client.ObjectKeyFromObject(configMap), current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "overrides:\n  giantswarm:\n    ingestion_rate: 100000\n  other:\n    ingestion_rate: 10000\n"
+	if current.Data[RuntimeOverridesConfigMapKey] != expected {
+		t.Errorf("expected runtime overrides %q, got %q", expected, current.Data[RuntimeOverridesConfigMapKey])
+	}
+	if current.Data["other.yaml"] != "unmanaged" {
+		t.Errorf("expected unmanaged keys to be preserved, got %v", current.Data)
+	}
+}
+
+func TestConfigureRuntimeOverridesMissingConfigMap(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ms := MimirService{
+		Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+		MonitoringConfig: monitoring.Config{
+			MimirNamespace:                     DefaultNamespace,
+			MimirRuntimeOverridesConfigMapName: "mimir-runtime",
+			MimirRuntimeOverrides: map[string]map[string]any{
+				"giantswarm": {"ingestion_rate": 100000},
+			},
+		},
+	}
+
+	// The configmap is not created by the operator.
+	if err := ms.ConfigureRuntimeOverrides(context.Background()); err != nil {
+		t.Fatalf("expected a missing configmap to be skipped, got %v", err)
+	}
+
+	err := ms.Client.Get(context.Background(), client.ObjectKey{Name: "mimir-runtime", Namespace: DefaultNamespace}, &corev1.ConfigMap{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the configmap not to be created, got %v", err)
+	}
+}
@@ -2,6 +2,9 @@ package mimir
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -15,58 +18,83 @@ import (
 	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
 	"github.com/giantswarm/observability-operator/pkg/common/password"
 	"github.com/giantswarm/observability-operator/pkg/common/secret"
+	"github.com/giantswarm/observability-operator/pkg/monitoring"
 	"github.com/giantswarm/observability-operator/pkg/monitoring/prometheusagent"
 )
 
 const (
-	ingressAuthSecretName = "mimir-gateway-ingress-auth" // #nosec G101
-	mimirApiKey           = "mimir-basic-auth"           // #nosec G101
-	mimirNamespace        = "mimir"
+	// DefaultIngressAuthSecretName is the default name of the secret holding the htpasswd used by the Mimir gateway ingress.
+	DefaultIngressAuthSecretName = "mimir-gateway-ingress-auth" // #nosec G101
+	// DefaultAuthSecretName is the default name of the secret holding the password used to authenticate against Mimir.
+	DefaultAuthSecretName = "mimir-basic-auth" // #nosec G101
+	// DefaultNamespace is the default namespace where Mimir is deployed.
+	DefaultNamespace = "mimir"
+
+	// PasswordCreatedAtAnnotation records when the password stored in the auth secret was generated.
+	PasswordCreatedAtAnnotation = "observability.giantswarm.io/password-created-at" // #nosec G101
+	// PreviousPasswordExpiresAtAnnotation records until when the ingress htpasswd keeps accepting the password replaced by the last rotation.
+	PreviousPasswordExpiresAtAnnotation = "observability.giantswarm.io/previous-password-expires-at" // #nosec G101
 )
 
 type MimirService struct {
 	client.Client
+	// APIReader reads the Mimir secrets without going through the cache, so a stale cache cannot trigger a second password rotation.
+	APIReader       client.Reader
 	PasswordManager password.Manager
 	common.ManagementCluster
+	MonitoringConfig monitoring.Config
 }
 
 // ConfigureMimir configures the ingress and its authentication (basic auth)
-// to allow prometheus agents to send their data to Mimir
-func (ms *MimirService) ConfigureMimir(ctx context.Context) error {
+// to allow prometheus agents to send their data to Mimir.
+// It reports whether the password was rotated, in which case the monitoring agents of all clusters must be updated.
+func (ms *MimirService) ConfigureMimir(ctx context.Context) (bool, error) {
 	logger := log.FromContext(ctx)
 	logger.Info("configuring mimir ingress")
 
 	err := ms.CreateApiKey(ctx, logger)
 	if err != nil {
 		logger.Error(err, "failed to create mimir auth secret")
-		return errors.WithStack(err)
+		return false, errors.WithStack(err)
+	}
+
+	rotated, err := ms.rotatePasswordIfExpired(ctx, logger)
+	if err != nil {
+		logger.Error(err, "failed to rotate mimir password")
+		return false, errors.WithStack(err)
 	}
 
 	err = ms.CreateIngressAuthenticationSecret(ctx, logger)
 	if err != nil {
 		logger.Error(err, "failed to create mimir ingress secret")
-		return errors.WithStack(err)
+		return rotated, errors.WithStack(err)
+	}
+
+	err = ms.ConfigureRuntimeOverrides(ctx)
+	if err != nil {
+		logger.Error(err, "failed to configure mimir runtime overrides")
+		return rotated, errors.WithStack(err)
 	}
 
 	logger.Info("configured mimir ingress")
 
-	return nil
+	return rotated, nil
 }
 
 func (ms *MimirService) CreateApiKey(ctx context.Context, logger logr.Logger) error {
 	objectKey := client.ObjectKey{
-		Name:      mimirApiKey,
-		Namespace: mimirNamespace,
+		Name:      ms.MonitoringConfig.MimirAuthSecretName,
+		Namespace: ms.MonitoringConfig.MimirNamespace,
 	}
 
 	current := &corev1.Secret{}
-	err := ms.Client.Get(ctx, objectKey, current)
+	err := ms.APIReader.Get(ctx, objectKey, current)
 	if apierrors.IsNotFound(err) {
 		// First all secrets using the password from the mimirApiKey secret are deleted
 		// to ensure that they won't use an outdated password.
 		logger.Info("Deleting old secrets")
 
-		err := secret.DeleteSecret(ingressAuthSecretName, mimirNamespace, ctx, ms.Client)
+		err := secret.DeleteSecret(ms.MonitoringConfig.MimirIngressAuthSecretName, ms.MonitoringConfig.MimirNamespace, ctx, ms.Client)
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -94,7 +122,8 @@ func (ms *MimirService) CreateApiKey(ctx context.Context, logger logr.Logger) er
 		}
 
 		secret := secret.GenerateGenericSecret(
-			mimirApiKey, mimirNamespace, "credentials", password)
+			ms.MonitoringConfig.MimirAuthSecretName, ms.MonitoringConfig.MimirNamespace, "credentials", password)
+		secret.SetAnnotations(map[string]string{PasswordCreatedAtAnnotation: time.Now().UTC().Format(time.RFC3339)})
 
 		err = ms.Client.Create(ctx, secret)
 		if err != nil {
@@ -111,28 +140,168 @@ func (ms *MimirService) CreateApiKey(ctx context.Context, logger logr.Logger) er
 	return nil
 }
 
+// rotatePasswordIfExpired rotates the password once it is older than the configured maximum age and reports whether it did.
+func (ms *MimirService) rotatePasswordIfExpired(ctx context.Context, logger logr.Logger) (bool, error) {
+	if ms.MonitoringConfig.MimirPasswordMaxAge == 0 {
+		return false, nil
+	}
+
+	current := &corev1.Secret{}
+	err := ms.APIReader.Get(ctx, client.ObjectKey{
+		Name:      ms.MonitoringConfig.MimirAuthSecretName,
+		Namespace: ms.MonitoringConfig.MimirNamespace,
+	}, current)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	if !passwordExpired(current, ms.MonitoringConfig.MimirPasswordMaxAge, time.Now()) {
+		return false, nil
+	}
+
+	logger.Info("mimir password is expired, rotating it")
+	err = ms.RotatePassword(ctx, logger)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return true, nil
+}
+
+// passwordExpired returns true if the password stored in the auth secret is older than maxAge.
+// Secrets created before the creation time was recorded fall back to the secret creation time.
+func passwordExpired(authSecret *corev1.Secret, maxAge time.Duration, now time.Time) bool {
+	createdAt := authSecret.GetCreationTimestamp().Time
+	if value, ok := authSecret.GetAnnotations()[PasswordCreatedAtAnnotation]; ok {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err == nil {
+			createdAt = parsed
+		}
+	}
+
+	return now.Sub(createdAt) > maxAge
+}
+
+// RotatePassword generates a new password and updates the auth secret and the ingress authentication secret derived from it.
+// The new password comes with a new username because the ingress only checks the first htpasswd entry of a user.
+// The entry of the previous password is kept in the htpasswd for the rotation grace period so the monitoring agents
+// keep sending data until their remote write secrets are updated.
+func (ms *MimirService) RotatePassword(ctx context.Context, logger logr.Logger) error {
+	now := time.Now().UTC()
+	username := fmt.Sprintf("%s-%d", ms.ManagementCluster.Name, now.Unix())
+
+	password, err := ms.PasswordManager.GeneratePassword(32)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	htpasswd, err := ms.PasswordManager.GenerateHtpasswd(username, password)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	currentIngressSecret := &corev1.Secret{}
+	err = ms.APIReader.Get(ctx, client.ObjectKey{
+		Name:      ms.MonitoringConfig.MimirIngressAuthSecretName,
+		Namespace: ms.MonitoringConfig.MimirNamespace,
+	}, currentIngressSecret)
+	if client.IgnoreNotFound(err) != nil {
+		return errors.WithStack(err)
+	}
+
+	ingressAnnotations := map[string]string{}
+	previousEntry := firstHtpasswdEntry(currentIngressSecret.Data["auth"])
+	if previousEntry != "" && ms.MonitoringConfig.MimirPasswordRotationGracePeriod > 0 {
+		htpasswd = htpasswd + "\n" + previousEntry
+		ingressAnnotations[PreviousPasswordExpiresAtAnnotation] = now.Add(ms.MonitoringConfig.MimirPasswordRotationGracePeriod).Format(time.RFC3339)
+	}
+
+	// Both secrets are computed before any write so a failure to generate them leaves the current password in place.
+	authSecret := secret.GenerateGenericSecret(ms.MonitoringConfig.MimirAuthSecretName, ms.MonitoringConfig.MimirNamespace, "credentials", password)
+	authSecret.Data[commonmonitoring.MimirAuthUsernameKey] = []byte(username)
+	authSecret.SetAnnotations(map[string]string{PasswordCreatedAtAnnotation: now.Format(time.RFC3339)})
+	ingressSecret := secret.GenerateGenericSecret(ms.MonitoringConfig.MimirIngressAuthSecretName, ms.MonitoringConfig.MimirNamespace, "auth", htpasswd)
+	ingressSecret.SetAnnotations(ingressAnnotations)
+
+	for _, desired := range []*corev1.Secret{authSecret, ingressSecret} {
+		current := &corev1.Secret{}
+		err = ms.APIReader.Get(ctx, client.ObjectKeyFromObject(desired), current)
+		if apierrors.IsNotFound(err) {
+			err = ms.Client.Create(ctx, desired)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			continue
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		current.Data = desired.Data
+		current.SetAnnotations(desired.GetAnnotations())
+		err = ms.Client.Update(ctx, current)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	logger.Info("mimir password rotated", "previousPasswordExpiresAt", ingressAnnotations[PreviousPasswordExpiresAtAnnotation])
+
+	return nil
+}
+
+// firstHtpasswdEntry returns the first entry of the htpasswd, which is the one of the current password.
+func firstHtpasswdEntry(htpasswd []byte) string {
+	entry, _, _ := strings.Cut(string(htpasswd), "\n")
+	return strings.TrimSpace(entry)
+}
+
+// removeExpiredPassword removes the entry of the previous password from the ingress htpasswd once the rotation grace period is over.
+func (ms *MimirService) removeExpiredPassword(ctx context.Context, logger logr.Logger, ingressSecret *corev1.Secret) error {
+	value, ok := ingressSecret.GetAnnotations()[PreviousPasswordExpiresAtAnnotation]
+	if !ok {
+		return nil
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err == nil && time.Now().Before(expiresAt) {
+		return nil
+	}
+
+	logger.Info("removing the previous mimir password from the ingress secret")
+
+	ingressSecret.Data["auth"] = []byte(firstHtpasswdEntry(ingressSecret.Data["auth"]))
+	delete(ingressSecret.Annotations, PreviousPasswordExpiresAtAnnotation)
+	err = ms.Client.Update(ctx, ingressSecret)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
 func (ms *MimirService) CreateIngressAuthenticationSecret(ctx context.Context, logger logr.Logger) error {
 	objectKey := client.ObjectKey{
-		Name:      ingressAuthSecretName,
-		Namespace: mimirNamespace,
+		Name:      ms.MonitoringConfig.MimirIngressAuthSecretName,
+		Namespace: ms.MonitoringConfig.MimirNamespace,
 	}
 
 	current := &corev1.Secret{}
-	err := ms.Client.Get(ctx, objectKey, current)
+	err := ms.APIReader.Get(ctx, objectKey, current)
 	if apierrors.IsNotFound(err) {
 		logger.Info("building ingress secret")
 
-		password, err := commonmonitoring.GetMimirIngressPassword(ctx)
+		username, password, err := commonmonitoring.GetMimirIngressCredentials(ctx, ms.APIReader,
+			ms.MonitoringConfig.MimirAuthSecretName, ms.MonitoringConfig.MimirNamespace, ms.ManagementCluster.Name)
 		if err != nil {
 			return errors.WithStack(err)
 		}
 
-		htpasswd, err := ms.PasswordManager.GenerateHtpasswd(ms.ManagementCluster.Name, password)
+		htpasswd, err := ms.PasswordManager.GenerateHtpasswd(username, password)
 		if err != nil {
 			return errors.WithStack(err)
 		}
 
-		secret := secret.GenerateGenericSecret(ingressAuthSecretName, mimirNamespace, "auth", htpasswd)
+		secret := secret.GenerateGenericSecret(ms.MonitoringConfig.MimirIngressAuthSecretName, ms.MonitoringConfig.MimirNamespace, "auth", htpasswd)
 
 		err = ms.Client.Create(ctx, secret)
 		if err != nil {
@@ -146,16 +315,16 @@ func (ms *MimirService) CreateIngressAuthenticationSecret(ctx context.Context, l
 		return errors.WithStack(err)
 	}
 
-	return nil
+	return ms.removeExpiredPassword(ctx, logger, current)
 }
 
 func (ms *MimirService) DeleteMimirSecrets(ctx context.Context) error {
-	err := secret.DeleteSecret(ingressAuthSecretName, mimirNamespace, ctx, ms.Client)
+	err := secret.DeleteSecret(ms.MonitoringConfig.MimirIngressAuthSecretName, ms.MonitoringConfig.MimirNamespace, ctx, ms.Client)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	err = secret.DeleteSecret(mimirApiKey, mimirNamespace, ctx, ms.Client)
+	err = secret.DeleteSecret(ms.MonitoringConfig.MimirAuthSecretName, ms.MonitoringConfig.MimirNamespace, ctx, ms.Client)
 	if err != nil {
 		return errors.WithStack(err)
 	}
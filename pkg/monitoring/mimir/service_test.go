@@ -0,0 +1,332 @@
+package mimir
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/giantswarm/observability-operator/pkg/common"
+	"github.com/giantswarm/observability-operator/pkg/common/password"
+	"github.com/giantswarm/observability-operator/pkg/monitoring"
+)
+
+func TestConfigureMimirWithCustomNames(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	ms := MimirService{
+		Client:            k8sClient,
+		APIReader:         k8sClient,
+		PasswordManager:   password.SimpleManager{},
+		ManagementCluster: common.ManagementCluster{Name: "test-installation"},
+		MonitoringConfig: monitoring.Config{
+			MimirNamespace:             "custom-mimir",
+			MimirAuthSecretName:        "custom-basic-auth",
+			MimirIngressAuthSecretName: "custom-ingress-auth",
+		},
+	}
+
+	_, err := ms.ConfigureMimir(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	authSecret := &corev1.Secret{}
+	err = ms.Client.Get(context.Background(), client.ObjectKey{Name: "custom-basic-auth", Namespace: "custom-mimir"}, authSecret)
+	if err != nil {
+		t.Fatalf("expected auth secret to be created: %v", err)
+	}
+	if len(authSecret.Data["credentials"]) == 0 {
+		t.Errorf("expected auth secret to hold credentials")
+	}
+
+	ingressSecret := &corev1.Secret{}
+	err = ms.Client.Get(context.Background(), client.ObjectKey{Name: "custom-ingress-auth", Namespace: "custom-mimir"}, ingressSecret)
+	if err != nil {
+		t.Fatalf("expected ingress auth secret to be created: %v", err)
+	}
+	if !strings.HasPrefix(string(ingressSecret.Data["auth"]), "test-installation:") {
+		t.Errorf("expected ingress auth secret to hold the installation htpasswd, got %q", ingressSecret.Data["auth"])
+	}
+
+	err = ms.DeleteMimirSecrets(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := ms.Client.List(context.Background(), secrets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(secrets.Items) != 0 {
+		t.Errorf("expected all mimir secrets to be deleted, got %d", len(secrets.Items))
+	}
+}
+
+func TestPasswordExpired(t *testing.T) {
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		createdAt time.Time
+		annotated bool
+		expected  bool
+	}{
+		{
+			name:      "recent password",
+			createdAt: now.Add(-time.Hour),
+			annotated: true,
+			expected:  false,
+		},
+		{
+			name:      "expired password",
+			createdAt: now.Add(-48 * time.Hour),
+			annotated: true,
+			expected:  true,
+		},
+		{
+			name:      "secret without annotation falls back to the creation time",
+			createdAt: now.Add(-48 * time.Hour),
+			expected:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authSecret := &corev1.Secret{}
+			if tt.annotated {
+				// The creation timestamp is recent to check the annotation takes precedence.
+				authSecret.SetCreationTimestamp(metav1.NewTime(now))
+				authSecret.SetAnnotations(map[string]string{PasswordCreatedAtAnnotation: tt.createdAt.Format(time.RFC3339)})
+			} else {
+				authSecret.SetCreationTimestamp(metav1.NewTime(tt.createdAt))
+			}
+
+			if got := passwordExpired(authSecret, 24*time.Hour, now); got != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestConfigureMimirRotatesExpiredPassword(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	ms := MimirService{
+		Client:            k8sClient,
+		APIReader:         k8sClient,
+		PasswordManager:   password.SimpleManager{},
+		ManagementCluster: common.ManagementCluster{Name: "test-installation"},
+		MonitoringConfig: monitoring.Config{
+			MimirNamespace:                   DefaultNamespace,
+			MimirAuthSecretName:              DefaultAuthSecretName,
+			MimirIngressAuthSecretName:       DefaultIngressAuthSecretName,
+			MimirPasswordMaxAge:              24 * time.Hour,
+			MimirPasswordRotationGracePeriod: time.Hour,
+		},
+	}
+
+	getSecrets := func() (*corev1.Secret, *corev1.Secret) {
+		authSecret := &corev1.Secret{}
+		err := ms.Client.Get(context.Background(), client.ObjectKey{Name: DefaultAuthSecretName, Namespace: DefaultNamespace}, authSecret)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ingressSecret := &corev1.Secret{}
+		err = ms.Client.Get(context.Background(), client.ObjectKey{Name: DefaultIngressAuthSecretName, Namespace: DefaultNamespace}, ingressSecret)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return authSecret, ingressSecret
+	}
+
+	configureMimir := func(expectedRotated bool) {
+		rotated, err := ms.ConfigureMimir(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if rotated != expectedRotated {
+			t.Fatalf("expected rotated to be %t, got %t", expectedRotated, rotated)
+		}
+	}
+
+	// htpasswdMatches returns true if the htpasswd holds an entry for the username and password.
+	htpasswdMatches := func(htpasswd []byte, username, password string) bool {
+		for _, entry := range strings.Split(string(htpasswd), "\n") {
+			user, hash, _ := strings.Cut(entry, ":")
+			if user == username && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+				return true
+			}
+		}
+		return false
+	}
+
+	configureMimir(false)
+	authSecret, ingressSecret := getSecrets()
+	initialPassword := string(authSecret.Data["credentials"])
+	initialHtpasswd := string(ingressSecret.Data["auth"])
+
+	// A fresh password is kept.
+	configureMimir(false)
+	authSecret, _ = getSecrets()
+	if string(authSecret.Data["credentials"]) != initialPassword {
+		t.Fatalf("expected the password not to be rotated")
+	}
+
+	// Age the password past the maximum age.
+	authSecret.Annotations[PasswordCreatedAtAnnotation] = time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	if err := ms.Client.Update(context.Background(), authSecret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	configureMimir(true)
+	authSecret, ingressSecret = getSecrets()
+	rotatedPassword := string(authSecret.Data["credentials"])
+	rotatedUsername := string(authSecret.Data["username"])
+	if rotatedPassword == initialPassword {
+		t.Errorf("expected the password to be rotated")
+	}
+	if !strings.HasPrefix(rotatedUsername, "test-installation-") {
+		t.Errorf("expected a new username, got %q", rotatedUsername)
+	}
+	if string(ingressSecret.Data["auth"]) == initialHtpasswd {
+		t.Errorf("expected the ingress htpasswd to be rotated")
+	}
+	if !htpasswdMatches(ingressSecret.Data["auth"], rotatedUsername, rotatedPassword) {
+		t.Errorf("expected the ingress htpasswd to match the rotated password")
+	}
+	if !htpasswdMatches(ingressSecret.Data["auth"], "test-installation", initialPassword) {
+		t.Errorf("expected the ingress htpasswd to keep the previous password during the grace period")
+	}
+
+	// The previous password is removed once the grace period is over.
+	ingressSecret.Annotations[PreviousPasswordExpiresAtAnnotation] = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	if err := ms.Client.Update(context.Background(), ingressSecret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	configureMimir(false)
+	authSecret, ingressSecret = getSecrets()
+	if htpasswdMatches(ingressSecret.Data["auth"], "test-installation", initialPassword) {
+		t.Errorf("expected the previous password to be removed from the ingress htpasswd")
+	}
+	if !htpasswdMatches(ingressSecret.Data["auth"], rotatedUsername, rotatedPassword) {
+		t.Errorf("expected the ingress htpasswd to keep the rotated password")
+	}
+	if _, ok := ingressSecret.Annotations[PreviousPasswordExpiresAtAnnotation]; ok {
+		t.Errorf("expected the previous password expiry to be removed")
+	}
+	if passwordExpired(authSecret, ms.MonitoringConfig.MimirPasswordMaxAge, time.Now()) {
+		t.Errorf("expected the rotated password creation time to be recorded")
+	}
+}
+
+// staleCacheClient serves the reads from a snapshot of the cluster, like a cache which did not observe the latest writes yet.
+type staleCacheClient struct {
+	client.Client
+	cache client.Reader
+}
+
+func (c staleCacheClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	return c.cache.Get(ctx, key, obj, opts...)
+}
+
+func TestConfigureMimirRotatesPasswordOnceWithStaleCache(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	ms := MimirService{
+		Client:            k8sClient,
+		APIReader:         k8sClient,
+		PasswordManager:   password.SimpleManager{},
+		ManagementCluster: common.ManagementCluster{Name: "test-installation"},
+		MonitoringConfig: monitoring.Config{
+			MimirNamespace:                   DefaultNamespace,
+			MimirAuthSecretName:              DefaultAuthSecretName,
+			MimirIngressAuthSecretName:       DefaultIngressAuthSecretName,
+			MimirPasswordMaxAge:              24 * time.Hour,
+			MimirPasswordRotationGracePeriod: time.Hour,
+		},
+	}
+
+	if _, err := ms.ConfigureMimir(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Age the password past the maximum age.
+	authSecret := &corev1.Secret{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: DefaultAuthSecretName, Namespace: DefaultNamespace}, authSecret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	initialPassword := string(authSecret.Data["credentials"])
+	authSecret.Annotations[PasswordCreatedAtAnnotation] = time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	if err := k8sClient.Update(context.Background(), authSecret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ingressSecret := &corev1.Secret{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: DefaultIngressAuthSecretName, Namespace: DefaultNamespace}, ingressSecret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The cache keeps serving the expired password after the rotation.
+	staleAuthSecret, staleIngressSecret := authSecret.DeepCopy(), ingressSecret.DeepCopy()
+	staleAuthSecret.ResourceVersion, staleIngressSecret.ResourceVersion = "", ""
+	ms.Client = staleCacheClient{
+		Client: k8sClient,
+		cache:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(staleAuthSecret, staleIngressSecret).Build(),
+	}
+
+	for i, expectedRotated := range []bool{true, false} {
+		rotated, err := ms.ConfigureMimir(context.Background())
+		if err != nil {
+			t.Fatalf("reconcile %d: unexpected error: %v", i, err)
+		}
+		if rotated != expectedRotated {
+			t.Fatalf("reconcile %d: expected rotated to be %t, got %t", i, expectedRotated, rotated)
+		}
+	}
+
+	// The entry of the initial password is still accepted during the grace period.
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(ingressSecret), ingressSecret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	accepted := false
+	for _, entry := range strings.Split(string(ingressSecret.Data["auth"]), "\n") {
+		user, hash, _ := strings.Cut(entry, ":")
+		if user == "test-installation" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(initialPassword)) == nil {
+			accepted = true
+		}
+	}
+	if !accepted {
+		t.Errorf("expected the ingress htpasswd to keep the previous password during the grace period")
+	}
+}
@@ -3,6 +3,7 @@ package prometheusagent
 import (
 	"context"
 	"fmt"
+	"maps"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -15,24 +16,28 @@ import (
 	commonmonitoring "github.com/giantswarm/observability-operator/pkg/common/monitoring"
 	"github.com/giantswarm/observability-operator/pkg/metrics"
 	"github.com/giantswarm/observability-operator/pkg/monitoring/mimir/querier"
+	"github.com/giantswarm/observability-operator/pkg/monitoring/prometheusagent/sharding"
 )
 
+// buildRemoteWriteConfig builds the remote write configmap of the cluster and returns the scaling of its shards which is still pending.
 func (pas PrometheusAgentService) buildRemoteWriteConfig(ctx context.Context,
-	cluster *clusterv1.Cluster, logger logr.Logger, currentShards int) (*corev1.ConfigMap, error) {
+	cluster *clusterv1.Cluster, logger logr.Logger, currentShards int) (*corev1.ConfigMap, sharding.PendingScaling, error) {
 
 	organization, err := pas.OrganizationRepository.Read(ctx, cluster)
 	if err != nil {
 		logger.Error(err, "failed to get cluster organization")
-		return nil, errors.WithStack(err)
+		return nil, sharding.PendingScaling{}, errors.WithStack(err)
 	}
 
-	provider, err := common.GetClusterProvider(cluster)
+	provider, err := pas.MonitoringConfig.ClusterProvider(cluster)
 	if err != nil {
 		logger.Error(err, "failed to get cluster provider")
-		return nil, errors.WithStack(err)
+		return nil, sharding.PendingScaling{}, errors.WithStack(err)
 	}
 
-	externalLabels := map[string]string{
+	// The labels set by the operator take precedence over the ones copied from the cluster labels.
+	externalLabels := pas.MonitoringConfig.ClusterExternalLabels(cluster)
+	maps.Copy(externalLabels, map[string]string{
 		"cluster_id":       cluster.Name,
 		"cluster_type":     common.GetClusterType(cluster, pas.ManagementCluster),
 		"customer":         pas.ManagementCluster.Customer,
@@ -42,7 +47,7 @@ func (pas PrometheusAgentService) buildRemoteWriteConfig(ctx context.Context,
 		"provider":         provider,
 		"region":           pas.ManagementCluster.Region,
 		"service_priority": commonmonitoring.GetServicePriority(cluster),
-	}
+	})
 
 	// Compute the number of shards based on the number of series.
 	query := fmt.Sprintf(`sum(max_over_time((sum(prometheus_agent_active_series{cluster_id="%s"})by(pod))[6h:1h]))`, cluster.Name)
@@ -54,11 +59,11 @@ func (pas PrometheusAgentService) buildRemoteWriteConfig(ctx context.Context,
 
 	clusterShardingStrategy, err := commonmonitoring.GetClusterShardingStrategy(cluster)
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, sharding.PendingScaling{}, errors.WithStack(err)
 	}
 
 	shardingStrategy := pas.MonitoringConfig.DefaultShardingStrategy.Merge(clusterShardingStrategy)
-	shards := shardingStrategy.ComputeShards(currentShards, headSeries)
+	shards, pending := commonmonitoring.ComputeClusterShards(ctx, cluster, shardingStrategy, currentShards, headSeries)
 
 	config, err := yaml.Marshal(RemoteWriteConfig{
 		PrometheusAgentConfig: &PrometheusAgentConfig{
@@ -71,7 +76,7 @@ func (pas PrometheusAgentService) buildRemoteWriteConfig(ctx context.Context,
 		},
 	})
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, sharding.PendingScaling{}, errors.WithStack(err)
 	}
 
 	if currentShards < shards {
@@ -82,16 +87,16 @@ func (pas PrometheusAgentService) buildRemoteWriteConfig(ctx context.Context,
 
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      getPrometheusAgentRemoteWriteConfigName(cluster),
+			Name:      GetPrometheusAgentRemoteWriteConfigName(cluster),
 			Namespace: cluster.Namespace,
 		},
 		Data: map[string]string{
 			"values": string(config),
 		},
-	}, nil
+	}, pending, nil
 }
 
-func getPrometheusAgentRemoteWriteConfigName(cluster *clusterv1.Cluster) string {
+func GetPrometheusAgentRemoteWriteConfigName(cluster *clusterv1.Cluster) string {
 	return fmt.Sprintf("%s-remote-write-config", cluster.Name)
 }
 
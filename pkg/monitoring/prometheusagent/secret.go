@@ -6,6 +6,7 @@ import (
 
 	"github.com/pkg/errors"
 	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/common/model"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
@@ -20,14 +21,18 @@ func GetPrometheusAgentRemoteWriteSecretName(cluster *clusterv1.Cluster) string
 }
 
 // buildRemoteWriteSecret builds the secret that contains the remote write configuration for the Prometheus agent.
+// The queue settings depend on the number of shards the Prometheus agent runs with.
 func (pas PrometheusAgentService) buildRemoteWriteSecret(ctx context.Context,
-	cluster *clusterv1.Cluster) (*corev1.Secret, error) {
+	cluster *clusterv1.Cluster, shards int) (*corev1.Secret, error) {
 	url := fmt.Sprintf(commonmonitoring.RemoteWriteEndpointTemplateURL, pas.ManagementCluster.BaseDomain)
-	password, err := commonmonitoring.GetMimirIngressPassword(ctx)
+	username, password, err := commonmonitoring.GetMimirIngressCredentials(ctx, pas.APIReader,
+		pas.MonitoringConfig.MimirAuthSecretName, pas.MonitoringConfig.MimirNamespace, pas.ManagementCluster.Name)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
+	queueConfigTier := pas.MonitoringConfig.QueueConfigTier(shards)
+
 	config := RemoteWriteConfig{
 		PrometheusAgentConfig: &PrometheusAgentConfig{
 			RemoteWrite: []*RemoteWrite{
@@ -36,10 +41,14 @@ func (pas PrometheusAgentService) buildRemoteWriteSecret(ctx context.Context,
 						URL:           url,
 						Name:          ptr.To(commonmonitoring.RemoteWriteName),
 						RemoteTimeout: ptr.To(promv1.Duration(commonmonitoring.RemoteWriteTimeout)),
+						Headers: map[string]string{
+							pas.MonitoringConfig.OrgIDHeaderName(): pas.MonitoringConfig.ClusterWriteTenant(cluster, pas.ManagementCluster),
+						},
 						QueueConfig: &promv1.QueueConfig{
 							Capacity:          commonmonitoring.QueueConfigCapacity,
 							MaxSamplesPerSend: commonmonitoring.QueueConfigMaxSamplesPerSend,
 							MaxShards:         commonmonitoring.QueueConfigMaxShards,
+							BatchSendDeadline: ptr.To(promv1.Duration(model.Duration(queueConfigTier.BatchSendDeadline).String())),
 						},
 						TLSConfig: &promv1.TLSConfig{
 							SafeTLSConfig: promv1.SafeTLSConfig{
@@ -47,13 +56,17 @@ func (pas PrometheusAgentService) buildRemoteWriteSecret(ctx context.Context,
 							},
 						},
 					},
-					Username: pas.ManagementCluster.Name,
+					Username: username,
 					Password: password,
 				},
 			},
 		},
 	}
 
+	if queueConfigTier.SampleAgeLimit > 0 {
+		config.PrometheusAgentConfig.RemoteWrite[0].QueueConfig.SampleAgeLimit = ptr.To(promv1.Duration(model.Duration(queueConfigTier.SampleAgeLimit).String()))
+	}
+
 	marshalledValues, err := yaml.Marshal(config)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -21,6 +21,8 @@ import (
 
 type PrometheusAgentService struct {
 	client.Client
+	// APIReader reads the Mimir authentication secret without going through the cache.
+	APIReader client.Reader
 	organization.OrganizationRepository
 	PasswordManager password.Manager
 	common.ManagementCluster
@@ -28,34 +30,37 @@ type PrometheusAgentService struct {
 }
 
 // ReconcileRemoteWriteConfiguration ensures that the prometheus remote write config is present in the cluster.
+// It returns the scaling of the shards which is still pending, for the caller to record on the cluster.
 func (pas *PrometheusAgentService) ReconcileRemoteWriteConfiguration(
-	ctx context.Context, cluster *clusterv1.Cluster) error {
+	ctx context.Context, cluster *clusterv1.Cluster) (sharding.PendingScaling, error) {
 
 	logger := log.FromContext(ctx)
 	logger.Info("ensuring prometheus agent remote write configmap and secret")
 
-	err := pas.createOrUpdateConfigMap(ctx, cluster, logger)
+	shards, pending, err := pas.createOrUpdateConfigMap(ctx, cluster, logger)
 	if err != nil {
 		logger.Error(err, "failed to create or update prometheus agent remote write configmap")
-		return errors.WithStack(err)
+		return sharding.PendingScaling{}, errors.WithStack(err)
 	}
 
-	err = pas.createOrUpdateSecret(ctx, cluster, logger)
+	err = pas.createOrUpdateSecret(ctx, cluster, logger, shards)
 	if err != nil {
 		logger.Error(err, "failed to create or update prometheus agent remote write secret")
-		return errors.WithStack(err)
+		return sharding.PendingScaling{}, errors.WithStack(err)
 	}
 
 	logger.Info("ensured prometheus agent remote write configmap and secret")
 
-	return nil
+	return pending, nil
 }
 
+// createOrUpdateConfigMap ensures the remote write configmap is up to date and returns the number of shards it configures
+// along with the scaling which is still pending.
 func (pas PrometheusAgentService) createOrUpdateConfigMap(ctx context.Context,
-	cluster *clusterv1.Cluster, logger logr.Logger) error {
+	cluster *clusterv1.Cluster, logger logr.Logger) (int, sharding.PendingScaling, error) {
 
 	objectKey := client.ObjectKey{
-		Name:      getPrometheusAgentRemoteWriteConfigName(cluster),
+		Name:      GetPrometheusAgentRemoteWriteConfigName(cluster),
 		Namespace: cluster.GetNamespace(),
 	}
 
@@ -63,42 +68,44 @@ func (pas PrometheusAgentService) createOrUpdateConfigMap(ctx context.Context,
 	// Get the current configmap if it exists.
 	err := pas.Client.Get(ctx, objectKey, current)
 	if apierrors.IsNotFound(err) {
-		configMap, err := pas.buildRemoteWriteConfig(ctx, cluster, logger, sharding.DefaultShards)
+		configMap, pending, err := pas.buildRemoteWriteConfig(ctx, cluster, logger, sharding.DefaultShards)
 		if err != nil {
-			return errors.WithStack(err)
+			return 0, sharding.PendingScaling{}, errors.WithStack(err)
 		}
 
 		err = pas.Client.Create(ctx, configMap)
 		if err != nil {
-			return errors.WithStack(err)
+			return 0, sharding.PendingScaling{}, errors.WithStack(err)
 		}
-		return nil
+		shards, err := readCurrentShardsFromConfig(*configMap)
+		return shards, pending, err
 	} else if err != nil {
-		return errors.WithStack(err)
+		return 0, sharding.PendingScaling{}, errors.WithStack(err)
 	}
 
 	currentShards, err := readCurrentShardsFromConfig(*current)
 	if err != nil {
-		return errors.WithStack(err)
+		return 0, sharding.PendingScaling{}, errors.WithStack(err)
 	}
 
-	desired, err := pas.buildRemoteWriteConfig(ctx, cluster, logger, currentShards)
+	desired, pending, err := pas.buildRemoteWriteConfig(ctx, cluster, logger, currentShards)
 	if err != nil {
-		return errors.WithStack(err)
+		return 0, sharding.PendingScaling{}, errors.WithStack(err)
 	}
 
 	if !reflect.DeepEqual(current.Data, desired.Data) || !reflect.DeepEqual(current.Finalizers, desired.Finalizers) {
 		err = pas.Client.Update(ctx, desired)
 		if err != nil {
 			logger.Info("could not update prometheus agent remote write configmap")
-			return errors.WithStack(err)
+			return 0, sharding.PendingScaling{}, errors.WithStack(err)
 		}
 	}
-	return nil
+	shards, err := readCurrentShardsFromConfig(*desired)
+	return shards, pending, err
 }
 
 func (pas PrometheusAgentService) createOrUpdateSecret(ctx context.Context,
-	cluster *clusterv1.Cluster, logger logr.Logger) error {
+	cluster *clusterv1.Cluster, logger logr.Logger, shards int) error {
 	objectKey := client.ObjectKey{
 		Name:      GetPrometheusAgentRemoteWriteSecretName(cluster),
 		Namespace: cluster.GetNamespace(),
@@ -109,7 +116,7 @@ func (pas PrometheusAgentService) createOrUpdateSecret(ctx context.Context,
 	err := pas.Client.Get(ctx, objectKey, current)
 	if apierrors.IsNotFound(err) {
 		logger.Info("generating remote write secret for the prometheus agent")
-		secret, err := pas.buildRemoteWriteSecret(ctx, cluster)
+		secret, err := pas.buildRemoteWriteSecret(ctx, cluster, shards)
 		if err != nil {
 			logger.Error(err, "failed to generate the remote write secret for the prometheus agent")
 			return errors.WithStack(err)
@@ -125,7 +132,7 @@ func (pas PrometheusAgentService) createOrUpdateSecret(ctx context.Context,
 		return errors.WithStack(err)
 	}
 
-	desired, err := pas.buildRemoteWriteSecret(ctx, cluster)
+	desired, err := pas.buildRemoteWriteSecret(ctx, cluster, shards)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -164,7 +171,7 @@ func (pas *PrometheusAgentService) DeleteRemoteWriteConfiguration(
 
 func (pas PrometheusAgentService) deleteConfigMap(ctx context.Context, cluster *clusterv1.Cluster) error {
 	objectKey := client.ObjectKey{
-		Name:      getPrometheusAgentRemoteWriteConfigName(cluster),
+		Name:      GetPrometheusAgentRemoteWriteConfigName(cluster),
 		Namespace: cluster.GetNamespace(),
 	}
 	configMap := &corev1.ConfigMap{}
@@ -14,12 +14,28 @@ type Strategy struct {
 	ScaleUpSeriesCount float64
 	// Percentage of needed series based on ScaleUpSeriesCount to scale down agents
 	ScaleDownPercentage float64
+	// Number of consecutive reconciliations the computed number of shards must be above or below the current one before the shards are changed.
+	// The shards are changed immediately when it is 0 or 1. Reconciliations are triggered by events and periodic resyncs, so this is not a time window.
+	ScalingReconciles int
+	// Maximum number of shards, to avoid runaway scaling when the strategy is misconfigured. The number of shards is not limited when it is 0.
+	// It is not overridden per cluster.
+	MaxShards int
+}
+
+// PendingScaling tracks a change of the number of shards which is not applied yet.
+type PendingScaling struct {
+	// Shards is the last computed number of shards.
+	Shards int `json:"shards"`
+	// Reconciles is the number of consecutive reconciliations which computed a change in the same direction.
+	Reconciles int `json:"reconciles"`
 }
 
 func (s Strategy) Merge(newStrategy *Strategy) Strategy {
 	strategy := Strategy{
 		s.ScaleUpSeriesCount,
 		s.ScaleDownPercentage,
+		s.ScalingReconciles,
+		s.MaxShards,
 	}
 	if newStrategy != nil {
 		if newStrategy.ScaleUpSeriesCount > 0 {
@@ -28,12 +44,29 @@ func (s Strategy) Merge(newStrategy *Strategy) Strategy {
 		if newStrategy.ScaleDownPercentage > 0 {
 			strategy.ScaleDownPercentage = newStrategy.ScaleDownPercentage
 		}
+		if newStrategy.ScalingReconciles > 0 {
+			strategy.ScalingReconciles = newStrategy.ScalingReconciles
+		}
 	}
 	return strategy
 }
 
 // We want to start with 1 prometheus-agent for each 1M time series with a scale down 20% threshold.
+// The number of shards is capped at MaxShards.
 func (s Strategy) ComputeShards(currentShardCount int, timeSeries float64) int {
+	shards := s.computeShards(currentShardCount, timeSeries)
+	if s.MaxShards > 0 && shards > s.MaxShards {
+		return s.MaxShards
+	}
+	return shards
+}
+
+// IsCapped returns whether the number of shards computed for the series is reduced to MaxShards.
+func (s Strategy) IsCapped(currentShardCount int, timeSeries float64) bool {
+	return s.MaxShards > 0 && s.computeShards(currentShardCount, timeSeries) > s.MaxShards
+}
+
+func (s Strategy) computeShards(currentShardCount int, timeSeries float64) int {
 	shardScaleDownThreshold := s.ScaleDownPercentage * s.ScaleUpSeriesCount
 	desiredShardCount := int(math.Ceil(timeSeries / s.ScaleUpSeriesCount))
 
@@ -51,3 +84,24 @@ func (s Strategy) ComputeShards(currentShardCount int, timeSeries float64) int {
 	}
 	return desiredShardCount
 }
+
+// Debounce delays the change from the current to the desired number of shards until it was computed for ScalingReconciles consecutive reconciliations,
+// to avoid flapping when the number of series hovers around a threshold. It returns the number of shards to apply and the scaling which is still pending.
+func (s Strategy) Debounce(currentShardCount int, desiredShardCount int, pending PendingScaling) (int, PendingScaling) {
+	if desiredShardCount == currentShardCount {
+		return currentShardCount, PendingScaling{}
+	}
+
+	reconciles := 1
+	// The pending scaling only carries on when it goes in the same direction.
+	sameDirection := (pending.Shards > currentShardCount && desiredShardCount > currentShardCount) ||
+		(pending.Shards < currentShardCount && desiredShardCount < currentShardCount)
+	if pending.Reconciles > 0 && sameDirection {
+		reconciles = pending.Reconciles + 1
+	}
+
+	if reconciles >= s.ScalingReconciles {
+		return desiredShardCount, PendingScaling{}
+	}
+	return currentShardCount, PendingScaling{Shards: desiredShardCount, Reconciles: reconciles}
+}
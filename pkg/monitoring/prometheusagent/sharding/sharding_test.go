@@ -8,6 +8,8 @@ type testCase struct {
 	currentShardCount int
 	timeSeries        float64
 	expected          int
+	// capped is whether more shards than the maximum are needed
+	capped bool
 }
 
 var defaultShardingStrategy = Strategy{ScaleUpSeriesCount: float64(1_000_000), ScaleDownPercentage: float64(0.20)}
@@ -126,6 +128,37 @@ var tests = []struct {
 			},
 		},
 	},
+	{
+		// Series counts which would need more shards than the maximum are clamped
+		name:     "max shards",
+		strategy: Strategy{ScaleUpSeriesCount: float64(1_000_000), ScaleDownPercentage: float64(0.20), MaxShards: 5},
+		cases: []testCase{
+			{
+				currentShardCount: 0,
+				timeSeries:        float64(5_000_000),
+				expected:          5,
+			},
+			{
+				currentShardCount: 5,
+				timeSeries:        float64(50_000_000),
+				expected:          5,
+				capped:            true,
+			},
+			{
+				// a burst of series does not scale the agents without limit
+				currentShardCount: 1,
+				timeSeries:        float64(1_000_000_000),
+				expected:          5,
+				capped:            true,
+			},
+			{
+				currentShardCount: 8,
+				timeSeries:        float64(0),
+				expected:          5,
+				capped:            true,
+			},
+		},
+	},
 }
 
 func TestShardComputationLogic(t *testing.T) {
@@ -136,8 +169,72 @@ func TestShardComputationLogic(t *testing.T) {
 				if result != c.expected {
 					t.Errorf(`expected computeShards(%d, %f) to be %d, got %d`, c.currentShardCount, c.timeSeries, c.expected, result)
 				}
+				if capped := tt.strategy.IsCapped(c.currentShardCount, c.timeSeries); capped != c.capped {
+					t.Errorf(`expected isCapped(%d, %f) to be %t, got %t`, c.currentShardCount, c.timeSeries, c.capped, capped)
+				}
 				t.Logf(`computeShards(%d, %f) = %d`, c.currentShardCount, c.timeSeries, result)
 			}
 		})
 	}
 }
+
+func TestDebounce(t *testing.T) {
+	strategy := Strategy{ScaleUpSeriesCount: float64(1_000_000), ScaleDownPercentage: float64(0.20), ScalingReconciles: 3}
+
+	tests := []struct {
+		name           string
+		strategy       Strategy
+		currentShards  int
+		timeSeries     []float64
+		expectedShards []int
+	}{
+		{
+			name:           "scaling is immediate without scaling reconciles",
+			strategy:       defaultShardingStrategy,
+			currentShards:  1,
+			timeSeries:     []float64{1_500_000, 700_000},
+			expectedShards: []int{2, 1},
+		},
+		{
+			name:           "scale up after consecutive reconciles",
+			strategy:       strategy,
+			currentShards:  1,
+			timeSeries:     []float64{1_500_000, 1_500_000, 2_500_000},
+			expectedShards: []int{1, 1, 3},
+		},
+		{
+			name:           "scale down after consecutive reconciles",
+			strategy:       strategy,
+			currentShards:  3,
+			timeSeries:     []float64{1_000_000, 1_000_000, 1_000_000},
+			expectedShards: []int{3, 3, 1},
+		},
+		{
+			name:           "series hovering around the threshold do not flap",
+			strategy:       strategy,
+			currentShards:  1,
+			timeSeries:     []float64{1_000_001, 1_000_001, 999_999, 1_000_001, 1_000_001, 999_999},
+			expectedShards: []int{1, 1, 1, 1, 1, 1},
+		},
+		{
+			name:           "direction change restarts the count",
+			strategy:       strategy,
+			currentShards:  2,
+			timeSeries:     []float64{2_500_000, 2_500_000, 500_000, 500_000, 500_000},
+			expectedShards: []int{2, 2, 2, 2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			currentShards := tt.currentShards
+			var pending PendingScaling
+			for i, timeSeries := range tt.timeSeries {
+				currentShards, pending = tt.strategy.Debounce(currentShards, tt.strategy.ComputeShards(currentShards, timeSeries), pending)
+				if currentShards != tt.expectedShards[i] {
+					t.Errorf("reconcile %d: expected %d shards, got %d", i, tt.expectedShards[i], currentShards)
+				}
+			}
+		})
+	}
+}